@@ -1,36 +1,77 @@
 package auth
 
 import (
+	"context"
 	"fmt"
+	"os"
+
 	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
 	"github.com/spf13/cobra"
-	"os"
 )
 
 var Cmd = create()
 
 func create() *cobra.Command {
 	var force bool
+	var profile string
 	cmd := &cobra.Command{
 		Use:   "auth",
 		Short: "Authenticate with Devplan service",
-		Long:  `Authenticate with the Devplan service to retrieve and store API key for future communications.`,
-		Run:   func(_ *cobra.Command, _ []string) { runAuth(force) },
+		Long: `Authenticate with the Devplan service to retrieve and store API key for future communications.
+With --profile the key is stored in a named profile, which is created if needed together with the --domain in use.`,
+		Run: func(c *cobra.Command, _ []string) { runAuth(c.Context(), force, profile) },
 	}
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force reauthentication even if token exists")
+	cmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "Profile to authenticate")
+	cmd.AddCommand(loginCmd)
+	cmd.AddCommand(statusCmd)
+	cmd.AddCommand(logoutCmd)
 	return cmd
 }
 
-func runAuth(force bool) {
+func runAuth(ctx context.Context, force bool, profile string) {
+	wasActive := selectProfile(profile)
 	var err error
 	if force {
-		_, err = devplan.RequestAuth()
+		_, err = devplan.RequestAuth(ctx)
 	} else {
-		_, err = devplan.VerifyAuth()
+		_, err = devplan.VerifyAuth(ctx)
 	}
 	if err != nil {
 		fmt.Printf("Failed to authenticate: %v\n", err)
 		os.Exit(1)
 		return
 	}
+	if !wasActive {
+		printUseProfileHint(profile)
+	}
+}
+
+// selectProfile makes the profile given with --profile active for the command, creating it if needed.
+// Returns whether the profile was already active.
+func selectProfile(profile string) bool {
+	if profile == "" {
+		return true
+	}
+	wasActive := prefs.ActiveProfile() == profile
+	if err := prefs.SaveProfile(profile, prefs.Domain); err != nil {
+		fmt.Printf("Failed to create profile: %v\n", err)
+		os.Exit(1)
+	}
+	prefs.Profile = profile
+	return wasActive
+}
+
+func printUseProfileHint(profile string) {
+	fmt.Printf("Run %s to make it the active profile, or set %s.\n",
+		out.H("devplan profile use "+profile), out.H(prefs.ProfileEnv+"="+profile))
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Println(out.Failf("Error: %v", err))
+		os.Exit(1)
+	}
 }
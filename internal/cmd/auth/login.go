@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/credentials"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginCmd = createLoginCmd()
+)
+
+func createLoginCmd() *cobra.Command {
+	var withToken bool
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in and store a new API key",
+		Long: `Log in and store a new API key.
+By default a login link is opened in the browser. With --with-token the API key is read
+from the DEVPLAN_API_KEY environment variable or from stdin, e.g. in CI and containers:
+
+  echo "$KEY" | devplan auth login --with-token`,
+		Run: func(c *cobra.Command, _ []string) {
+			profile, _ := c.Flags().GetString("profile")
+			runLogin(c.Context(), profile, withToken)
+		},
+	}
+	cmd.Flags().BoolVar(&withToken, "with-token", false, "Read the API key from DEVPLAN_API_KEY or stdin")
+	return cmd
+}
+
+func runLogin(ctx context.Context, profile string, withToken bool) {
+	wasActive := selectProfile(profile)
+	if !withToken {
+		_, err := devplan.RequestAuth(ctx)
+		check(err)
+	} else {
+		token, err := readToken(os.Stdin)
+		check(err)
+		self, err := devplan.NewClient(devplan.Config{APIKey: token}).GetSelf(ctx)
+		if devplan.IsUnauthorized(err) {
+			check(errors.New("the API key was rejected by Devplan"))
+		}
+		check(err)
+		check(devplan.StoreAPIKey(prefs.ActiveProfile(), token, tokenKeyName))
+		out.Psuccessf("Logged in as %s\n", out.H(self.GetOwnInfo().GetUser().GetEmail()))
+	}
+	if !wasActive {
+		printUseProfileHint(profile)
+	}
+}
+
+// tokenKeyName is shown by `auth status` for keys whose name is unknown
+const tokenKeyName = "provided with --with-token"
+
+func readToken(stdin io.Reader) (string, error) {
+	if token, _ := credentials.EnvAPIKey(); token != "" {
+		return token, nil
+	}
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read the API key from stdin: %w", err)
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return "", fmt.Errorf("no API key provided, pipe it to stdin or set %s", credentials.APIKeyEnv)
+	}
+	return token, nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/devplan-cli/internal/utils/credentials"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logoutCmd = createLogoutCmd()
+)
+
+func createLogoutCmd() *cobra.Command {
+	var localOnly bool
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Revoke the API key and remove it from this machine",
+		Run: func(c *cobra.Command, _ []string) {
+			profile, _ := c.Flags().GetString("profile")
+			if profile != "" {
+				prefs.Profile = profile
+			}
+			runLogout(c.Context(), localOnly)
+		},
+	}
+	cmd.Flags().BoolVar(&localOnly, "local-only", false, "Only remove the key locally, without revoking it")
+	return cmd
+}
+
+func runLogout(ctx context.Context, localOnly bool) {
+	profile := prefs.ActiveProfile()
+	key, err := credentials.GetAPIKey(profile)
+	check(err)
+	if key == "" {
+		fmt.Printf("Profile %s is not logged in.\n", out.H(profile))
+	} else {
+		if !localOnly {
+			revoke(ctx, key)
+		}
+		check(credentials.DeleteAPIKey(profile))
+		prefs.ClearAPIKeyInfo(profile)
+		if store, err := apicache.Default(); err == nil {
+			_, _ = store.Clear("")
+		}
+		out.Psuccessf("Logged out of profile %s\n", out.H(profile))
+	}
+	if key, env := credentials.EnvAPIKey(); key != "" {
+		out.Pwarnf("%s is still set and will be used for API requests.\n", env)
+	}
+}
+
+// revoke invalidates the key on the server. Failures are reported, but don't prevent the local logout.
+func revoke(ctx context.Context, key string) {
+	err := devplan.NewClient(devplan.Config{APIKey: key}).RevokeAPIKey(ctx)
+	name, _ := prefs.GetAPIKeyInfo(prefs.ActiveProfile())
+	switch {
+	case err == nil:
+		out.Psuccessf("Revoked the API key on the server\n")
+	case devplan.IsUnauthorized(err):
+		fmt.Println("The API key is no longer accepted by the server.")
+	case errors.Is(err, devplan.ErrRevokeUnsupported):
+		out.Pwarnf("The server can't revoke API keys, revoke %s in Devplan settings.\n", out.H(name))
+	default:
+		out.Pwarnf("Failed to revoke the API key on the server: %v\n", err)
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/credentials"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusCmd = createStatusCmd()
+)
+
+func createStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the authenticated user and API key",
+		Run: func(c *cobra.Command, _ []string) {
+			profile, _ := c.Flags().GetString("profile")
+			if profile != "" {
+				prefs.Profile = profile
+			}
+			runStatus(c.Context())
+		},
+	}
+	return cmd
+}
+
+func runStatus(ctx context.Context) {
+	profile := prefs.ActiveProfile()
+	cl := devplan.NewClient(devplan.Config{})
+	fmt.Printf("Profile: %s\n", out.H(profile))
+	fmt.Printf("Domain:  %s\n", out.H(cl.BaseURL))
+
+	// source is the environment variable holding the key, empty for the stored key
+	key, source := credentials.EnvAPIKey()
+	if key == "" {
+		var err error
+		key, err = credentials.GetAPIKey(profile)
+		check(err)
+	}
+	if key == "" {
+		fmt.Println(out.Failf("Not authenticated. Run %s to log in.", out.H("devplan auth login")))
+		os.Exit(1)
+	}
+	name, createdAt := prefs.GetAPIKeyInfo(profile)
+	if source != "" || name == "" {
+		name = "unknown"
+	}
+	fmt.Printf("Key:     %s (%s)\n", out.H(name), describeKey(source, createdAt))
+
+	self, err := devplan.NewClient(devplan.Config{APIKey: key}).GetSelf(ctx)
+	if devplan.IsUnauthorized(err) {
+		fmt.Println(out.Failf("The API key was rejected by Devplan. Run %s to authenticate again.", out.H(devplan.ReauthCommand())))
+		os.Exit(1)
+	}
+	check(err)
+	user := self.GetOwnInfo().GetUser()
+	fmt.Printf("User:    %s\n", out.H(user.GetEmail()))
+	out.Psuccessf("Authenticated\n")
+}
+
+func describeKey(source string, createdAt time.Time) string {
+	if source != "" {
+		return "from " + source
+	}
+	if createdAt.IsZero() {
+		return "stored"
+	}
+	return fmt.Sprintf("stored %s ago", formatAge(time.Since(createdAt)))
+}
+
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "less than a minute"
+	case age < time.Hour:
+		return plural(int(age.Minutes()), "minute")
+	case age < 48*time.Hour:
+		return plural(int(age.Hours()), "hour")
+	default:
+		return plural(int(age.Hours()/24), "day")
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
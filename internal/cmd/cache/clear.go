@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clearCmd = createClearCmd()
+)
+
+func createClearCmd() *cobra.Command {
+	var companyID int32
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove cached Devplan data",
+		Run: func(_ *cobra.Command, _ []string) {
+			store, err := apicache.Default()
+			check(err)
+			scope := ""
+			if companyID > 0 {
+				scope = devplan.CompanyCacheScope(fmt.Sprintf("%v", companyID))
+			}
+			removed, err := store.Clear(scope)
+			check(err)
+			out.Psuccessf("Removed %s cached entries\n", out.H(removed))
+		},
+	}
+	cmd.Flags().Int32VarP(&companyID, "company", "c", 0, "Only clear data of the given company")
+	return cmd
+}
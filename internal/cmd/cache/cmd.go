@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = create()
+)
+
+func create() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local cache of Devplan data",
+		Long: `Manage the local cache of Devplan data.
+Projects, documents, recipes and other lookups are cached under ~/.devplan/cache
+(~/.devplan/cache-<profile> for named profiles),
+so repeated commands are faster and work with --offline.`,
+	}
+	cmd.AddCommand(clearCmd)
+	cmd.AddCommand(statsCmd)
+	return cmd
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Println(out.Failf("Error: %v", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsCmd = createStatsCmd()
+)
+
+func createStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show what is stored in the local cache",
+		Run: func(_ *cobra.Command, _ []string) {
+			store, err := apicache.Default()
+			check(err)
+			stats, err := store.Stats()
+			check(err)
+			fmt.Printf("Location: %s\n", out.H(store.Dir()))
+			fmt.Printf("Entries:  %s (%s)\n", out.H(stats.Entries), formatSize(stats.Bytes))
+			if stats.Entries == 0 {
+				return
+			}
+			fmt.Printf("Oldest:   %s\n", stats.Oldest.Format(time.DateTime))
+			fmt.Printf("Newest:   %s\n", stats.Newest.Format(time.DateTime))
+			scopes := make([]string, 0, len(stats.Scopes))
+			for scope := range stats.Scopes {
+				scopes = append(scopes, scope)
+			}
+			sort.Strings(scopes)
+			for _, scope := range scopes {
+				fmt.Printf("  %s: %d\n", scope, stats.Scopes[scope])
+			}
+		},
+	}
+	return cmd
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -1,10 +1,10 @@
 package clone
 
 import (
-	"context"
 	"fmt"
 	"os"
 
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/devplaninc/devplan-cli/internal/utils/gitws"
 	"github.com/devplaninc/devplan-cli/internal/utils/ide"
@@ -29,15 +29,15 @@ func create() *cobra.Command {
 This command streamlines the workflow of cloning a repository and focusing on a feature.
 It will clone the repository into the configured workplace directory and set up the necessary rules.`,
 		PreRunE: targetPicker.PreRun,
-		Run: func(_ *cobra.Command, _ []string) {
-			ctx := context.Background()
+		Run: func(c *cobra.Command, _ []string) {
+			ctx := c.Context()
 			assistants, err := picker.AssistantForIDE(targetPicker.IDEName)
 			check(err)
 			cloneRes, err := gitws.InteractiveClone(ctx, targetPicker, repoName, "")
 			check(err)
 			target := cloneRes.Target
 			gitRepo := cloneRes.RepoInfo
-			summary, err := loaders.RepoSummary(target, gitRepo)
+			summary, err := loaders.RepoSummary(ctx, target, gitRepo)
 			check(err)
 			prompt, err := picker.GetTargetPrompt(target, target.ProjectWithDocs.GetDocs())
 			check(err)
@@ -81,7 +81,9 @@ func launch(ideName ide.IDE, repoPath string, start bool) error {
 
 func check(err error) {
 	if err != nil {
-		fmt.Println(out.Failf("Error: %v", err))
+		if !devplan.OfferReauth(err) {
+			fmt.Println(out.Failf("Error: %v", err))
+		}
 		os.Exit(1)
 	}
 }
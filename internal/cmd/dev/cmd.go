@@ -18,5 +18,6 @@ func create() *cobra.Command {
 	}
 	cmd.AddCommand(rules.Cmd)
 	cmd.AddCommand(fetch.Cmd)
+	cmd.AddCommand(mockServerCmd)
 	return cmd
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +23,9 @@ func create() *cobra.Command {
 
 func check(err error) {
 	if err != nil {
+		if devplan.OfferReauth(err) {
+			os.Exit(1)
+		}
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		panic(err)
 	}
@@ -19,9 +19,9 @@ func createProjectCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "project",
 		Short: "Get project documents in a Pre Fetch format",
-		Run: func(_ *cobra.Command, _ []string) {
+		Run: func(c *cobra.Command, _ []string) {
 			cl := devplan.NewClient(devplan.Config{})
-			resp, err := cl.GetProjectDocuments(companyID, projectID)
+			resp, err := cl.GetProjectDocuments(c.Context(), companyID, projectID)
 			check(err)
 			var entries []*osdd.FetchedData
 			for _, d := range resp.GetDocuments() {
@@ -0,0 +1,92 @@
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan/devplantest"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockServerCmd = createMockServerCmd()
+)
+
+func createMockServerCmd() *cobra.Command {
+	var fixturesDir string
+	var port int
+	var apiKey string
+	cmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Run a fake Devplan API serving fixtures from a directory",
+		Long: `Run a fake Devplan API serving fixtures from a directory.
+
+Fixtures mirror the API layout: <fixtures>/company/1/projects.json is served for GET /api/v1/company/1/projects.
+Worklog items and spec uploads are accepted and kept in memory. Point the CLI to the server with --domain:
+
+  devplan dev mock-server --fixtures ./fixtures --port 3000
+  devplan --domain local focus`,
+		RunE: func(c *cobra.Command, _ []string) error {
+			return runMockServer(c.Context(), fixturesDir, port, apiKey)
+		},
+	}
+	cmd.Flags().StringVar(&fixturesDir, "fixtures", "", "Directory with fixtures to serve")
+	cmd.Flags().IntVar(&port, "port", 3000, "Port to listen on")
+	cmd.Flags().StringVar(&apiKey, "api-key", "", "Only accept this API key (any key is accepted by default)")
+	_ = cmd.MarkFlagRequired("fixtures")
+	return cmd
+}
+
+func runMockServer(ctx context.Context, fixturesDir string, port int, apiKey string) error {
+	fixtures, err := devplantest.LoadFixtures(fixturesDir)
+	if err != nil {
+		return err
+	}
+	handler := devplantest.NewHandler(fixtures)
+	handler.APIKey = apiKey
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+	url := "http://" + listener.Addr().String()
+	server := &http.Server{Handler: logRequests(handler), ReadHeaderTimeout: 10 * time.Second}
+
+	fmt.Printf("Serving %d fixtures from %s\n", len(fixtures.Paths()), fixturesDir)
+	fmt.Printf("Mock Devplan API is listening on %s\n", out.H(url))
+	fmt.Printf("Run commands with %s to use it.\n", out.H("--domain "+url))
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		fmt.Printf("%s %s %s [%d]\n", time.Now().Format(time.TimeOnly), r.Method, r.URL.Path, rec.status)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
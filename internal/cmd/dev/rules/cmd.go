@@ -1,6 +1,9 @@
 package rules
 
 import (
+	"os"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +22,9 @@ func create() *cobra.Command {
 
 func check(err error) {
 	if err != nil {
+		if devplan.OfferReauth(err) {
+			os.Exit(1)
+		}
 		panic(err)
 	}
 }
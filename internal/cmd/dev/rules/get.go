@@ -17,9 +17,9 @@ func createGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get",
 		Short: "Get a rule by name",
-		Run: func(_ *cobra.Command, _ []string) {
+		Run: func(c *cobra.Command, _ []string) {
 			cl := devplan.NewClient(devplan.Config{})
-			resp, err := cl.GetDevRule(companyID, ruleName)
+			resp, err := cl.GetDevRule(c.Context(), companyID, ruleName)
 			check(err)
 			fmt.Println(resp.GetRule())
 		},
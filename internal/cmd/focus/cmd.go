@@ -1,9 +1,11 @@
 package focus
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/devplaninc/devplan-cli/internal/utils/git"
 	"github.com/devplaninc/devplan-cli/internal/utils/ide"
@@ -23,23 +25,23 @@ func create() *cobra.Command {
 		Aliases: []string{"f"},
 		Short:   "Focus on a specific feature of a project",
 		PreRunE: targetPicker.PreRun,
-		Run: func(_ *cobra.Command, _ []string) {
-			runFocus(targetPicker)
+		Run: func(c *cobra.Command, _ []string) {
+			runFocus(c.Context(), targetPicker)
 		},
 	}
 	targetPicker.Prepare(cmd)
 	return cmd
 }
 
-func runFocus(targetPicker *picker.TargetCmd) {
+func runFocus(ctx context.Context, targetPicker *picker.TargetCmd) {
 	repo := git.EnsureInRepo()
 	out.Psuccessf("Current repository: %+v\n", repo.FullNames[0])
 	ides, err := picker.AssistantForIDE(targetPicker.IDEName)
 	check(err)
-	target, err := picker.Target(targetPicker)
+	target, err := picker.Target(ctx, targetPicker)
 	check(err)
 	project := target.ProjectWithDocs
-	summary, err := loaders.RepoSummary(target, repo)
+	summary, err := loaders.RepoSummary(ctx, target, repo)
 	check(err)
 	prompt, err := picker.GetTargetPrompt(target, project.GetDocs())
 	check(err)
@@ -55,7 +57,9 @@ func runFocus(targetPicker *picker.TargetCmd) {
 
 func check(err error) {
 	if err != nil {
-		fmt.Println(out.Failf("%v", err))
+		if !devplan.OfferReauth(err) {
+			fmt.Println(out.Failf("%v", err))
+		}
 		os.Exit(1)
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/huh"
 	"github.com/devplaninc/devplan-cli/internal/cmd/common"
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/devplaninc/devplan-cli/internal/utils/recentactivity"
 	"github.com/devplaninc/devplan-cli/internal/utils/workspace"
@@ -73,7 +74,9 @@ func runList() {
 
 func check(err error) {
 	if err != nil {
-		fmt.Println(out.Failf("Error: %v", err))
+		if !devplan.OfferReauth(err) {
+			fmt.Println(out.Failf("Error: %v", err))
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,144 @@
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/httplog"
+	"github.com/devplaninc/devplan-cli/internal/utils/logging"
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = create()
+)
+
+func create() *cobra.Command {
+	var httpEntries, bodies bool
+	var limit int
+	var file string
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show recent CLI logs",
+		Long: `Show recent entries of ~/.devplan/cli.log.
+With --http, shows API requests recorded by --trace (or DEVPLAN_TRACE) instead.
+Authorization headers and API keys are redacted in the trace.`,
+		Example: `  devplan --trace focus
+  devplan logs --http --bodies -n 5
+  devplan logs --http --file /tmp/trace.jsonl`,
+		Run: func(_ *cobra.Command, _ []string) {
+			if httpEntries {
+				showHTTP(file, limit, bodies)
+				return
+			}
+			showLog(file, limit)
+		},
+	}
+	cmd.Flags().BoolVar(&httpEntries, "http", false, "Show traced API requests")
+	cmd.Flags().BoolVar(&bodies, "bodies", false, "Include headers and bodies of traced requests")
+	cmd.Flags().IntVarP(&limit, "lines", "n", 50, "Number of most recent entries to show")
+	cmd.Flags().StringVar(&file, "file", "", "Read from the given file instead of the default log")
+	return cmd
+}
+
+func showLog(file string, limit int) {
+	if file == "" {
+		var err error
+		file, err = logging.GetLogFile()
+		check(err)
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		fmt.Println("Nothing was logged yet.")
+		return
+	}
+	check(err)
+	defer func() { _ = f.Close() }()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	check(scanner.Err())
+	for _, line := range tail(lines, limit) {
+		fmt.Println(line)
+	}
+}
+
+func showHTTP(file string, limit int, bodies bool) {
+	if file == "" {
+		var err error
+		file, err = httplog.DefaultFile()
+		check(err)
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		fmt.Printf("No API requests were traced yet. Run a command with %s to record them.\n", out.H("--trace"))
+		return
+	}
+	check(err)
+	defer func() { _ = f.Close() }()
+	entries, err := httplog.Read(f)
+	check(err)
+	for _, e := range tail(entries, limit) {
+		printEntry(e, bodies)
+	}
+}
+
+func printEntry(e *httplog.Entry, bodies bool) {
+	status := out.H(e.Status)
+	if e.Error != "" {
+		status = out.Failf("%s", e.Error)
+	} else if e.Status >= 400 {
+		status = out.Failf("%d", e.Status)
+	}
+	fmt.Printf("%s %s %s %s %v %dB\n", e.Time.Local().Format(time.DateTime), e.Method, e.URL, status,
+		time.Duration(e.Latency).Round(time.Millisecond), e.ResponseSize)
+	if !bodies {
+		return
+	}
+	printHeaders("> ", e.RequestHeader)
+	if e.RequestBody != "" {
+		fmt.Println(indent("> ", e.RequestBody))
+	}
+	printHeaders("< ", e.ResponseHeader)
+	if e.ResponseBody != "" {
+		fmt.Println(indent("< ", e.ResponseBody))
+	}
+	fmt.Println()
+}
+
+func printHeaders(prefix string, header map[string][]string) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s%s: %s\n", prefix, name, strings.Join(header[name], ", "))
+	}
+}
+
+func indent(prefix, s string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+prefix)
+}
+
+func tail[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[len(items)-limit:]
+	}
+	return items
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Println(out.Failf("Error: %v", err))
+		os.Exit(1)
+	}
+}
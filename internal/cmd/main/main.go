@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/devplaninc/devplan-cli/internal/cmd"
@@ -25,7 +28,14 @@ func main() {
 
 	}
 	_ = logging.Setup()
-	if err := cmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore default signal handling after the first signal, so a second Ctrl-C terminates immediately.
+		<-ctx.Done()
+		stop()
+	}()
+	if err := cmd.Execute(ctx); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -1,8 +1,8 @@
 package mcp
 
 import (
-	"context"
 	"fmt"
+	"net"
 	"os"
 
 	"github.com/devplaninc/devplan-cli/internal/mcp"
@@ -10,23 +10,56 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// tokenEnv fixes the bearer token of the HTTP server, e.g. to keep client configs stable across restarts
+const tokenEnv = "DEVPLAN_MCP_TOKEN"
+
 var (
 	Cmd = create()
 )
 
 func create() *cobra.Command {
+	var httpAddr string
 	cmd := &cobra.Command{
 		Use:   "mcp",
 		Short: "Devplan MCP server",
-		Run: func(_ *cobra.Command, _ []string) {
-			ctx := context.Background()
+		Long: `Devplan MCP server.
+
+By default the server talks to a single agent over stdio.
+With --http it serves any number of agents over streamable HTTP, sharing spec syncers between them.
+Clients must send the bearer token printed on start, which is generated for each run unless DEVPLAN_MCP_TOKEN is set.`,
+		Example: "  devplan mcp --http 127.0.0.1:8765",
+		Run: func(c *cobra.Command, _ []string) {
 			server := mcp.NewServer()
-			check(server.Run(ctx))
+			if httpAddr == "" {
+				check(server.Run(c.Context()))
+				return
+			}
+			token := os.Getenv(tokenEnv)
+			if token == "" {
+				var err error
+				token, err = mcp.NewToken()
+				check(err)
+			}
+			listener, err := net.Listen("tcp", httpAddr)
+			check(err)
+			printHTTPInfo(listener.Addr().String(), token)
+			check(server.RunHTTP(c.Context(), listener, token))
 		},
 	}
+	cmd.Flags().StringVar(&httpAddr, "http", "", "serve streamable HTTP on the address, e.g. 127.0.0.1:8765, instead of stdio")
+	cmd.AddCommand(installCmd, uninstallCmd, statusCmd)
 	return cmd
 }
 
+func printHTTPInfo(addr, token string) {
+	out.Psuccessf("Devplan MCP server is listening on http://%s%s\n", addr, mcp.HTTPPath)
+	fmt.Printf("Bearer token: %s\n", out.H(token))
+	fmt.Printf("Clients must send the %s header.\n", out.Faint("Authorization: Bearer <token>"))
+	if !mcp.IsLoopback(addr) {
+		out.Pwarnf("The server accepts connections from other machines, keep the token secret.\n")
+	}
+}
+
 func check(err error) {
 	if err != nil {
 		fmt.Println(out.Failf("Error: %v", err))
@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/git"
+	"github.com/devplaninc/devplan-cli/internal/utils/ide"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installCmd   = createInstallCmd()
+	uninstallCmd = createUninstallCmd()
+)
+
+// targetFlags selects the assistants and scope of install, uninstall and status
+type targetFlags struct {
+	ides  []string
+	scope string
+}
+
+func (f *targetFlags) register(cmd *cobra.Command, scopeDefault string) {
+	cmd.Flags().StringSliceVarP(&f.ides, "ide", "i", nil,
+		"assistants or IDEs to configure, e.g. claude,cursor,junie,windsurf. Defaults to the ones set up in the scope")
+	cmd.Flags().StringVarP(&f.scope, "scope", "s", scopeDefault, "'project' for the current repository or 'user' for all projects")
+}
+
+func createInstallCmd() *cobra.Command {
+	flags := &targetFlags{}
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Register the Devplan MCP server with local coding agents",
+		Long: `Register the Devplan MCP server with local coding agents.
+The devplan entry is merged into the MCP config of each agent, keeping other servers.
+The original config is backed up to a .bak file before it is changed.`,
+		Example: `  devplan mcp install
+  devplan mcp install --ide claude,cursor --scope user`,
+		Run: func(_ *cobra.Command, _ []string) {
+			scope, err := parseScope(flags.scope)
+			check(err)
+			root, err := projectRoot()
+			check(err)
+			assistants, err := selectAssistants(flags.ides, scope, root)
+			check(err)
+			server, err := serverEntry()
+			check(err)
+			for _, asst := range assistants {
+				configPath, err := ide.GetMCPConfigPath(asst, scope, root)
+				check(err)
+				changed, err := ide.InstallMCPServer(configPath, server)
+				check(err)
+				if changed {
+					out.Psuccessf("Registered the Devplan MCP server for %s in %s\n", out.H(asst), out.H(configPath))
+				} else {
+					fmt.Printf("%s is already registered for %s in %s\n", ide.MCPServerName, asst, configPath)
+				}
+			}
+		},
+	}
+	flags.register(cmd, string(ide.MCPScopeProject))
+	return cmd
+}
+
+func createUninstallCmd() *cobra.Command {
+	flags := &targetFlags{}
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the Devplan MCP server from local coding agents",
+		Run: func(_ *cobra.Command, _ []string) {
+			scope, err := parseScope(flags.scope)
+			check(err)
+			root, err := projectRoot()
+			check(err)
+			assistants, err := selectAssistants(flags.ides, scope, root)
+			check(err)
+			for _, asst := range assistants {
+				configPath, err := ide.GetMCPConfigPath(asst, scope, root)
+				check(err)
+				removed, err := ide.UninstallMCPServer(configPath)
+				check(err)
+				if removed {
+					out.Psuccessf("Removed the Devplan MCP server for %s from %s\n", out.H(asst), out.H(configPath))
+				} else {
+					fmt.Printf("%s is not registered for %s in %s\n", ide.MCPServerName, asst, configPath)
+				}
+			}
+		},
+	}
+	flags.register(cmd, string(ide.MCPScopeProject))
+	return cmd
+}
+
+func parseScope(scope string) (ide.MCPScope, error) {
+	switch s := ide.MCPScope(scope); s {
+	case ide.MCPScopeProject, ide.MCPScopeUser:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown scope %q, expected 'project' or 'user'", scope)
+}
+
+// projectRoot returns the root of the current repository, or the working directory outside a repository
+func projectRoot() (string, error) {
+	root, err := git.GetRoot()
+	if err == nil {
+		return root, nil
+	}
+	if !git.IsNotInRepoErr(err) {
+		return "", err
+	}
+	return os.Getwd()
+}
+
+// selectAssistants resolves the --ide values, given as assistant or IDE names, or detects the assistants
+func selectAssistants(names []string, scope ide.MCPScope, root string) ([]ide.Assistant, error) {
+	if len(names) == 0 {
+		assistants, err := ide.DetectMCPAssistants(scope, root)
+		if err != nil {
+			return nil, err
+		}
+		if len(assistants) == 0 {
+			return nil, fmt.Errorf("no coding agents found in the %s scope, select them with --ide", scope)
+		}
+		return assistants, nil
+	}
+	var assistants []ide.Assistant
+	for _, name := range names {
+		asst, err := toAssistant(name)
+		if err != nil {
+			return nil, err
+		}
+		assistants = append(assistants, asst)
+	}
+	return assistants, nil
+}
+
+func toAssistant(name string) (ide.Assistant, error) {
+	for _, asst := range ide.GetAssistants() {
+		if string(asst) == name {
+			return asst, nil
+		}
+	}
+	return ide.GetAssistant(ide.IDE(name))
+}
+
+// serverEntry returns the MCP config entry starting `devplan mcp`. The devplan on PATH is used if there is one,
+// so the entry keeps working after updates, and the active profile is passed on.
+func serverEntry() (ide.MCPServer, error) {
+	command := "devplan"
+	if _, err := exec.LookPath(command); err != nil {
+		executable, err := os.Executable()
+		if err != nil {
+			return ide.MCPServer{}, fmt.Errorf("failed to find the devplan executable: %w", err)
+		}
+		if command, err = filepath.EvalSymlinks(executable); err != nil {
+			return ide.MCPServer{}, err
+		}
+	}
+	server := ide.MCPServer{Command: command, Args: []string{"mcp"}}
+	if profile := prefs.ActiveProfile(); profile != prefs.DefaultProfile {
+		server.Env = map[string]string{prefs.ProfileEnv: profile}
+	}
+	return server, nil
+}
@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/ide"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = createStatusCmd()
+
+func createStatusCmd() *cobra.Command {
+	flags := &targetFlags{}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show which coding agents have the Devplan MCP server registered",
+		Run: func(_ *cobra.Command, _ []string) {
+			scopes := []ide.MCPScope{ide.MCPScopeProject, ide.MCPScopeUser}
+			if flags.scope != "" {
+				scope, err := parseScope(flags.scope)
+				check(err)
+				scopes = []ide.MCPScope{scope}
+			}
+			root, err := projectRoot()
+			check(err)
+			assistants := ide.GetAssistants()
+			if len(flags.ides) > 0 {
+				assistants, err = selectAssistants(flags.ides, scopes[0], root)
+				check(err)
+			}
+			for _, scope := range scopes {
+				fmt.Printf("%s scope:\n", out.H(string(scope)))
+				for _, asst := range assistants {
+					configPath, err := ide.GetMCPConfigPath(asst, scope, root)
+					if err != nil {
+						continue
+					}
+					fmt.Printf("  %-9s %s %s\n", asst, statusLine(configPath), out.Faint(configPath))
+				}
+			}
+		},
+	}
+	flags.register(cmd, "")
+	cmd.Flags().Lookup("ide").Usage = "assistants or IDEs to show, e.g. claude,cursor. Defaults to all"
+	return cmd
+}
+
+func statusLine(configPath string) string {
+	server, err := ide.GetMCPServer(configPath)
+	if err != nil {
+		return out.Failf("error: %v", err)
+	}
+	if server == nil {
+		return "not installed"
+	}
+	return out.Successf("installed (%s)", strings.Join(append([]string{server.Command}, server.Args...), " "))
+}
@@ -0,0 +1,34 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = create()
+)
+
+func create() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage authenticated profiles",
+		Long: `Manage authenticated profiles.
+Each profile has its own domain, API key, default company and workspace directory.
+Profiles are created with 'devplan auth --profile <name>'. DEVPLAN_PROFILE selects a profile for a single command.`,
+	}
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(useCmd)
+	cmd.AddCommand(removeCmd)
+	return cmd
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Println(out.Failf("Error: %v", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,56 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/credentials"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = createListCmd()
+)
+
+func createListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List profiles",
+		Run: func(_ *cobra.Command, _ []string) {
+			runList()
+		},
+	}
+	return cmd
+}
+
+func runList() {
+	active := prefs.ActiveProfile()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "\tPROFILE\tDOMAIN\tCOMPANY\tAUTHENTICATED")
+	for _, name := range prefs.ListProfiles() {
+		marker := ""
+		if name == active {
+			marker = "*"
+		}
+		domain := prefs.GetProfileDomain(name)
+		if domain == "" {
+			domain = "app"
+		}
+		company := "-"
+		if id := prefs.GetProfileCompanyID(name); id > 0 {
+			company = fmt.Sprintf("%d", id)
+		}
+		authenticated := "no"
+		key, err := credentials.GetAPIKey(name)
+		if err != nil {
+			authenticated = "unknown"
+		} else if key != "" {
+			authenticated = "yes"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", marker, name, domain, company, authenticated)
+	}
+	check(w.Flush())
+}
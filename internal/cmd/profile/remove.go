@@ -0,0 +1,58 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/devplan-cli/internal/utils/credentials"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	removeCmd = createRemoveCmd()
+)
+
+func createRemoveCmd() *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:     "remove <profile>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a profile with its API key and cached data",
+		Args:    cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			runRemove(args[0], yes)
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove without confirmation")
+	return cmd
+}
+
+func runRemove(name string, yes bool) {
+	if name == prefs.DefaultProfile {
+		check(fmt.Errorf("the %s profile can't be removed", prefs.DefaultProfile))
+	}
+	if !prefs.ProfileExists(name) {
+		check(fmt.Errorf("profile %q does not exist", name))
+	}
+	if !yes {
+		confirmed := false
+		err := huh.NewConfirm().
+			Title(fmt.Sprintf("Remove profile %s and its API key?", name)).
+			Value(&confirmed).
+			Run()
+		check(err)
+		if !confirmed {
+			os.Exit(0)
+		}
+	}
+	check(credentials.DeleteAPIKey(name))
+	check(prefs.RemoveProfile(name))
+	if configDir, err := prefs.GetConfigDir(); err == nil {
+		_ = os.RemoveAll(apicache.ProfileDir(configDir, name))
+	}
+	out.Psuccessf("Removed profile %s\n", out.H(name))
+}
@@ -0,0 +1,24 @@
+package profile
+
+import (
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	useCmd = createUseCmd()
+)
+
+func createUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <profile>",
+		Short: "Make a profile active for subsequent commands",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			check(prefs.SetActiveProfile(args[0]))
+			out.Psuccessf("Using profile %s\n", out.H(args[0]))
+		},
+	}
+	return cmd
+}
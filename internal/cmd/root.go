@@ -1,18 +1,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/devplaninc/devplan-cli/internal/cmd/auth"
+	"github.com/devplaninc/devplan-cli/internal/cmd/cache"
 	"github.com/devplaninc/devplan-cli/internal/cmd/clean"
 	"github.com/devplaninc/devplan-cli/internal/cmd/clone"
 	"github.com/devplaninc/devplan-cli/internal/cmd/dev"
 	"github.com/devplaninc/devplan-cli/internal/cmd/focus"
 	list_cmd "github.com/devplaninc/devplan-cli/internal/cmd/list"
+	"github.com/devplaninc/devplan-cli/internal/cmd/logs"
 	"github.com/devplaninc/devplan-cli/internal/cmd/mcp"
+	"github.com/devplaninc/devplan-cli/internal/cmd/profile"
 	"github.com/devplaninc/devplan-cli/internal/cmd/spec"
 	switch_cmd "github.com/devplaninc/devplan-cli/internal/cmd/switch"
+	"github.com/devplaninc/devplan-cli/internal/cmd/worklog"
 	prefs_utils "github.com/devplaninc/devplan-cli/internal/utils/prefs"
 	"github.com/spf13/cobra"
 )
@@ -24,19 +29,38 @@ var (
 		Long: `Official cli for https://devplan.com.
 Integrates Devplan project management with local AI-powered IDEs.`,
 		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+		PersistentPostRun: func(c *cobra.Command, _ []string) {
+			flushWorklogQueue(c)
+		},
 	}
 )
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() error {
-	return rootCmd.Execute()
+// The context is cancelled on interrupt, aborting in-flight API calls.
+func Execute(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// flushWorklogQueue submits worklog entries queued while Devplan was unreachable.
+// The MCP server flushes the queue itself, and worklog commands manage it explicitly.
+// Auth and profile commands change the credentials, so the queue is left for the next command.
+func flushWorklogQueue(c *cobra.Command) {
+	for cmd := c; cmd.HasParent(); cmd = cmd.Parent() {
+		if cmd == mcp.Cmd || cmd == worklog.Cmd || cmd == auth.Cmd || cmd == profile.Cmd {
+			return
+		}
+	}
+	worklog.FlushDue(c.Context())
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&prefs_utils.Domain, "domain", "", "domain to use (app, beta, local)")
+	rootCmd.PersistentFlags().StringVar(&prefs_utils.Domain, "domain", "", "domain to use (app, beta, local or an API URL)")
 	rootCmd.PersistentFlags().StringVar(&prefs_utils.InstructionFile, "instructions-file", "", "Instructions file to output instructions instead of executing commands directly.")
 	rootCmd.PersistentFlags().BoolVarP(&prefs_utils.Verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&prefs_utils.Offline, "offline", false, "serve data from the local cache only, without contacting Devplan")
+	rootCmd.PersistentFlags().StringVar(&prefs_utils.Trace, "trace", "", "trace API requests to ~/.devplan/http.log, or to the given file with --trace=<file>")
+	rootCmd.PersistentFlags().Lookup("trace").NoOptDefVal = prefs_utils.TraceToLog
 	if err := rootCmd.PersistentFlags().MarkHidden("domain"); err != nil {
 		fmt.Printf("Failed to initialize CLI (domain flag): %v\n)", err)
 		os.Exit(1)
@@ -46,6 +70,7 @@ func init() {
 		os.Exit(1)
 	}
 	rootCmd.AddCommand(auth.Cmd)
+	rootCmd.AddCommand(cache.Cmd)
 	rootCmd.AddCommand(focus.Cmd)
 	rootCmd.AddCommand(clone.Cmd)
 	rootCmd.AddCommand(switch_cmd.Cmd)
@@ -54,4 +79,7 @@ func init() {
 	rootCmd.AddCommand(dev.Cmd)
 	rootCmd.AddCommand(mcp.Cmd)
 	rootCmd.AddCommand(spec.Cmd)
+	rootCmd.AddCommand(profile.Cmd)
+	rootCmd.AddCommand(logs.Cmd)
+	rootCmd.AddCommand(worklog.Cmd)
 }
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/outbox"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTempHome isolates ~/.devplan and the config of the test, and returns the paths requested from a fake API
+func useTempHome(t *testing.T) (string, func() []string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(prefs.ProfileEnv, "")
+	t.Setenv("DEVPLAN_API_KEY", "")
+	t.Setenv("DEVPLAN_APIKEY", "")
+	configPath := filepath.Join(home, "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte("{}"), 0600))
+	prevFile := viper.ConfigFileUsed()
+	viper.Reset()
+	viper.SetConfigFile(configPath)
+	viper.SetConfigType("json")
+	require.NoError(t, viper.ReadInConfig())
+	t.Cleanup(func() {
+		prefs.Profile, prefs.Domain = "", ""
+		viper.Reset()
+		viper.SetConfigFile(prevFile)
+		viper.SetConfigType("json")
+		viper.SetEnvPrefix("devplan")
+		viper.AutomaticEnv()
+		_ = viper.ReadInConfig()
+	})
+
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, paths...)
+	}
+}
+
+func queueWorklogItem(t *testing.T) *outbox.Store {
+	t.Helper()
+	store, err := outbox.Default()
+	require.NoError(t, err)
+	_, err = store.Add(1, worklog.WorkLogItem_builder{Message: "queued"}.Build())
+	require.NoError(t, err)
+	return store
+}
+
+func TestFlushWorklogQueue_WithoutKeyDoesNotAuthenticate(t *testing.T) {
+	url, requests := useTempHome(t)
+	store := queueWorklogItem(t)
+
+	rootCmd.SetArgs([]string{"cache", "stats", "--domain", url})
+	require.NoError(t, Execute(context.Background()))
+
+	assert.Empty(t, requests(), "no login link is requested and nothing is submitted")
+	items, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestFlushWorklogQueue_SubmitsWithKey(t *testing.T) {
+	url, requests := useTempHome(t)
+	t.Setenv("DEVPLAN_API_KEY", "test-key")
+	store := queueWorklogItem(t)
+
+	rootCmd.SetArgs([]string{"cache", "stats", "--domain", url})
+	require.NoError(t, Execute(context.Background()))
+
+	assert.Len(t, requests(), 1)
+	items, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
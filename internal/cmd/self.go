@@ -21,11 +21,13 @@ func init() {
 	rootCmd.AddCommand(selfCmd)
 }
 
-func runSelf(_ *cobra.Command, _ []string) {
+func runSelf(cmd *cobra.Command, _ []string) {
 	client := devplan.NewClient(devplan.Config{})
-	self, err := client.GetSelf()
+	self, err := client.GetSelf(cmd.Context())
 	if err != nil {
-		fmt.Printf("Failed to get self: %v\n", err)
+		if !devplan.OfferReauth(err) {
+			fmt.Printf("Failed to get self: %v\n", err)
+		}
 		return
 	}
 	fmt.Printf("Self: %s\n", out.H(self.GetOwnInfo().GetUser().GetEmail()))
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/spf13/cobra"
 )
@@ -20,12 +21,16 @@ func create() *cobra.Command {
 	}
 	cmd.AddCommand(startCmd)
 	cmd.AddCommand(pullCmd)
+	cmd.AddCommand(syncCmd)
+	cmd.AddCommand(lsCmd)
 	return cmd
 }
 
 func check(err error) {
 	if err != nil {
-		fmt.Println(out.Failf("%v", err))
+		if !devplan.OfferReauth(err) {
+			fmt.Println(out.Failf("%v", err))
+		}
 		os.Exit(1)
 	}
 }
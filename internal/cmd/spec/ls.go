@@ -0,0 +1,74 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lsCmd = createLsCmd()
+)
+
+func createLsCmd() *cobra.Command {
+	var companyID int32
+	var taskID string
+	var featureID string
+	var specsOnly bool
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List files in the specs directory of a task or feature and whether they are synced",
+		Long: `List files in the specs directory of a task or feature, whether they are synced as specs
+and the pattern deciding it.
+
+Markdown files are specs by default, except hidden files and the input specs written from Devplan documents.
+The company dev rule "` + specsync.PatternsRule + `" can include more files, e.g. *.mdx or *.json, one pattern per line.
+A gitignore-style ` + specsync.IgnoreFile + ` in the specs directory excludes files, or includes them with !pattern.
+The last matching pattern wins. Sync and pull apply the same rules.
+
+IDs default to the workspace containing the current directory.`,
+		Example: `  devplan spec ls
+  devplan spec ls -f <feature-id> --specs`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if taskID != "" && featureID != "" {
+				return fmt.Errorf("--task (-t) and --feature (-f) are mutually exclusive")
+			}
+			return nil
+		},
+		Run: func(c *cobra.Command, _ []string) {
+			ctx := c.Context()
+			companyID, target, root := workspaceTarget(companyID, taskID, featureID)
+			adapter := specsync.NewClientAdapter(devplan.NewClient(devplan.Config{}))
+			dir, err := specsync.SpecsDir(ctx, adapter, companyID, target, root)
+			check(err)
+			rules, err := specsync.LoadRules(dir, specPatterns(ctx, adapter, companyID))
+			check(err)
+			files, err := target.ListFiles(dir, rules)
+			check(err)
+
+			fmt.Printf("Files of %s in %s:\n", out.H(target), dir)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, file := range files {
+				if specsOnly && !file.Spec {
+					continue
+				}
+				mark := out.Cross
+				if file.Spec {
+					mark = out.Check
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", mark, file.Rel, out.Faint(file.Reason))
+			}
+			check(w.Flush())
+		},
+	}
+	cmd.Flags().Int32VarP(&companyID, "company", "c", 0, "Company ID, defaults to the workspace company")
+	cmd.Flags().StringVarP(&taskID, "task", "t", "", "Task ID to list specs of, defaults to the workspace task")
+	cmd.Flags().StringVarP(&featureID, "feature", "f", "", "Feature ID to list specs of")
+	cmd.Flags().BoolVar(&specsOnly, "specs", false, "List only the files that are synced")
+	return cmd
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
 	"github.com/opensdd/osdd-api/clients/go/osdd/recipes"
 	"github.com/opensdd/osdd-core/core"
 	"github.com/opensdd/osdd-core/core/executable"
@@ -32,6 +34,9 @@ Does not clone repository or launch IDE.
 Use -t/--task to pull specs for a single task.
 Use -f/--feature to pull specs for a feature.
 
+Specs synced to Devplan are downloaded into the task or feature specs directory too,
+skipping files excluded by the same rules as ` + "`devplan spec sync`" + `, see ` + "`devplan spec ls`" + `.
+
 Exactly one of -t or -f must be provided.`,
 		PreRunE: func(_ *cobra.Command, _ []string) error {
 			if taskID == "" && featureID == "" {
@@ -42,8 +47,8 @@ Exactly one of -t or -f must be provided.`,
 			}
 			return nil
 		},
-		Run: func(_ *cobra.Command, _ []string) {
-			ctx := context.Background()
+		Run: func(c *cobra.Command, _ []string) {
+			ctx := c.Context()
 
 			outputPath := path
 			if outputPath == "" {
@@ -61,11 +66,11 @@ Exactly one of -t or -f must be provided.`,
 			var execRecipe *recipes.ExecutableRecipe
 			if featureID != "" {
 				var err error
-				execRecipe, err = cl.GetFeatureExecRecipe(companyID, featureID)
+				execRecipe, err = cl.GetFeatureExecRecipe(ctx, companyID, featureID)
 				check(err)
 			} else {
 				var err error
-				execRecipe, err = cl.GetTaskExecRecipe(companyID, taskID)
+				execRecipe, err = cl.GetTaskExecRecipe(ctx, companyID, taskID)
 				check(err)
 			}
 
@@ -90,6 +95,7 @@ Exactly one of -t or -f must be provided.`,
 			_, err = r.Execute(ctx, genCtx)
 			check(err)
 			fmt.Printf("Spec files downloaded successfully to: %s\n", outputPath)
+			pullSyncedSpecs(ctx, cl, companyID, taskID, featureID, outputPath)
 		},
 	}
 	cmd.Flags().Int32VarP(&companyID, "company", "c", 0, "Company ID")
@@ -101,3 +107,25 @@ Exactly one of -t or -f must be provided.`,
 	_ = cmd.MarkFlagRequired("ide")
 	return cmd
 }
+
+// pullSyncedSpecs downloads the specs synced to Devplan into the specs directory under outputPath
+func pullSyncedSpecs(ctx context.Context, cl *devplan.Client, companyID int32, taskID, featureID, outputPath string) {
+	target := specsync.TaskTarget(taskID)
+	if featureID != "" {
+		target = specsync.FeatureTarget(featureID)
+	}
+	adapter := specsync.NewClientAdapter(cl)
+	dir, err := specsync.SpecsDir(ctx, adapter, companyID, target, outputPath)
+	if err != nil {
+		out.Pwarnf("Synced specs were not downloaded: %v\n", err)
+		return
+	}
+	rules, err := specsync.LoadRules(dir, specPatterns(ctx, adapter, companyID))
+	check(err)
+	result, err := specsync.Pull(ctx, adapter, companyID, target, dir, rules)
+	check(err)
+	fmt.Printf("Synced specs downloaded to %s: %d\n", dir, len(result.Written))
+	for _, name := range result.Excluded {
+		fmt.Println(out.Faint(fmt.Sprintf("Skipped %s, excluded by the spec rules", name)))
+	}
+}
@@ -1,7 +1,6 @@
 package spec
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 
@@ -47,8 +46,8 @@ Exactly one of -t or -f must be provided.`,
 			}
 			return nil
 		},
-		Run: func(_ *cobra.Command, _ []string) {
-			ctx := context.Background()
+		Run: func(c *cobra.Command, _ []string) {
+			ctx := c.Context()
 			cl := devplan.NewClient(devplan.Config{})
 
 			var workspacePath string
@@ -62,7 +61,7 @@ Exactly one of -t or -f must be provided.`,
 					slog.Debug("Failed to record recent feature activity", "featureID", featureID, "err", err)
 				}
 			} else {
-				docResp, err := cl.GetDocument(companyID, taskID)
+				docResp, err := cl.GetDocument(ctx, companyID, taskID)
 				check(err)
 				task := docResp.GetDocument()
 				if err := recentactivity.RecordTaskActivity(taskID, "spec_start"); err != nil {
@@ -85,7 +84,7 @@ Exactly one of -t or -f must be provided.`,
 					workspacePath = cloneRes.RepoPath
 				}
 
-				execRecipe, err = cl.GetTaskExecRecipe(companyID, taskID)
+				execRecipe, err = cl.GetTaskExecRecipe(ctx, companyID, taskID)
 				check(err)
 			}
 
@@ -20,7 +20,7 @@ type startFeatureResult struct {
 }
 
 func runStartFeature(ctx context.Context, cl *devplan.Client, companyID int32, featureID string, path string) startFeatureResult {
-	docResp, err := cl.GetDocument(companyID, featureID)
+	docResp, err := cl.GetDocument(ctx, companyID, featureID)
 	check(err)
 	feature := docResp.GetDocument()
 
@@ -32,14 +32,14 @@ func runStartFeature(ctx context.Context, cl *devplan.Client, companyID int32, f
 
 	workspacePath := path
 	if workspacePath == "" {
-		project, err := resolveProjectInfo(cl, companyID, feature.GetProjectId())
+		project, err := resolveProjectInfo(ctx, cl, companyID, feature.GetProjectId())
 		check(err)
 
 		sanitizedProject := gitws.SanitizeName(project.Name, 30)
 		sanitizedFeature := gitws.SanitizeName(feature.GetTitle(), 30)
 		parentPath := workspace.GetFeatureWorkspacePath(sanitizedProject, sanitizedFeature)
 
-		repos, err := gitws.ResolveRepos(details.GetRepoNames(), companyID)
+		repos, err := gitws.ResolveRepos(ctx, details.GetRepoNames(), companyID)
 		check(err)
 
 		slog.Info("Cloning repositories for feature", "feature", feature.GetTitle(), "count", len(repos))
@@ -49,6 +49,7 @@ func runStartFeature(ctx context.Context, cl *devplan.Client, companyID int32, f
 		out.Psuccessf("All %d repositories cloned successfully\n", len(repos))
 
 		meta := metadata.Metadata{
+			CompanyID:        companyID,
 			ProjectID:        feature.GetProjectId(),
 			ProjectName:      project.Name,
 			ProjectNumericID: fmt.Sprintf("%v", project.NumericID),
@@ -63,7 +64,7 @@ func runStartFeature(ctx context.Context, cl *devplan.Client, companyID int32, f
 		workspacePath = cloneResult.ParentPath
 	}
 
-	execRecipe, err := cl.GetFeatureExecRecipe(companyID, featureID)
+	execRecipe, err := cl.GetFeatureExecRecipe(ctx, companyID, featureID)
 	check(err)
 
 	return startFeatureResult{
@@ -77,8 +78,8 @@ type resolvedProject struct {
 	NumericID int32
 }
 
-func resolveProjectInfo(cl *devplan.Client, companyID int32, projectID string) (resolvedProject, error) {
-	prResp, err := cl.GetCompanyProjects(companyID)
+func resolveProjectInfo(ctx context.Context, cl *devplan.Client, companyID int32, projectID string) (resolvedProject, error) {
+	prResp, err := cl.GetCompanyProjects(ctx, companyID)
 	if err != nil {
 		return resolvedProject{}, fmt.Errorf("failed to get company projects: %w", err)
 	}
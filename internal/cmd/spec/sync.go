@@ -0,0 +1,131 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/devplaninc/devplan-cli/internal/utils/metadata"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncCmd = createSyncCmd()
+)
+
+func createSyncCmd() *cobra.Command {
+	var companyID int32
+	var taskID string
+	var featureID string
+	var onConflict string
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync spec files of a task or feature between the workspace and Devplan",
+		Long: `Sync spec files of a task or feature between the workspace and Devplan.
+
+Specs changed locally are uploaded and specs changed in Devplan are downloaded.
+Specs changed on both sides are conflicts, handled by --on-conflict:
+  copy    keep the local spec and write the Devplan version to <spec>.conflict (default).
+          Merge it into the spec and delete the copy, and the spec is uploaded on the next sync.
+  refuse  keep the local spec without uploading it.
+  local   upload the local spec, overwriting the Devplan version.
+  server  download the Devplan version, overwriting the local spec.
+
+IDs default to the workspace containing the current directory.`,
+		Example: `  devplan spec sync
+  devplan spec sync -f <feature-id> --on-conflict server`,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if taskID != "" && featureID != "" {
+				return fmt.Errorf("--task (-t) and --feature (-f) are mutually exclusive")
+			}
+			return nil
+		},
+		Run: func(c *cobra.Command, _ []string) {
+			ctx := c.Context()
+			policy, err := specsync.ParseConflictPolicy(onConflict)
+			check(err)
+			companyID, target, root := workspaceTarget(companyID, taskID, featureID)
+
+			adapter := specsync.NewClientAdapter(devplan.NewClient(devplan.Config{}))
+			dir, err := specsync.SpecsDir(ctx, adapter, companyID, target, root)
+			check(err)
+			syncer := specsync.NewSyncer(adapter, companyID, target, dir, 0)
+			syncer.SetConflictPolicy(policy)
+			syncer.SetServerPatterns(specPatterns(ctx, adapter, companyID))
+			result := syncer.SyncNow(ctx)
+
+			fmt.Printf("Synced specs of %s in %s: %d uploaded, %d downloaded, %d unchanged\n",
+				out.H(target), dir, result.Uploaded, result.Downloaded, result.Skipped)
+			for _, name := range result.Deleted {
+				fmt.Printf("%s was deleted locally and is kept in Devplan\n", name)
+			}
+			for _, rename := range result.Renamed {
+				fmt.Printf("%s was renamed to %s locally, %s is kept in Devplan\n", rename.From, rename.To, rename.From)
+			}
+			for _, migrated := range result.Migrated {
+				fmt.Printf("%s is now synced under its path, %s uploaded by an older version is kept in Devplan\n", migrated.To, migrated.From)
+			}
+			for _, conflict := range result.Conflicts {
+				if conflict.CopyPath != "" {
+					out.Pwarnf("Conflict: %s changed locally and in Devplan, the Devplan version is in %s\n", conflict.Path, conflict.CopyPath)
+				} else {
+					out.Pwarnf("Conflict: %s changed locally and in Devplan, it was not uploaded\n", conflict.Path)
+				}
+			}
+			for _, err := range result.Errors {
+				out.Pfailf("%v\n", err)
+			}
+			if len(result.Conflicts) > 0 || result.Failed > 0 {
+				os.Exit(1)
+			}
+			out.Psuccessf("Specs are in sync\n")
+		},
+	}
+	cmd.Flags().Int32VarP(&companyID, "company", "c", 0, "Company ID, defaults to the workspace company")
+	cmd.Flags().StringVarP(&taskID, "task", "t", "", "Task ID to sync specs of, defaults to the workspace task")
+	cmd.Flags().StringVarP(&featureID, "feature", "f", "", "Feature ID to sync specs of")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", string(specsync.ConflictCopy), "How to handle specs changed locally and in Devplan: copy, refuse, local or server")
+	return cmd
+}
+
+// workspaceTarget resolves the company and the task or feature whose specs are synced, defaulting to the workspace
+// containing the current directory, and returns the root of the workspace
+func workspaceTarget(companyID int32, taskID, featureID string) (int32, specsync.Target, string) {
+	root, err := os.Getwd()
+	check(err)
+	meta, metaDir, err := metadata.FindMetadata(root)
+	check(err)
+	if meta != nil {
+		root = metaDir
+		if companyID == 0 {
+			companyID = meta.CompanyID
+		}
+		if taskID == "" && featureID == "" {
+			taskID, featureID = meta.TaskID, meta.StoryID
+		}
+	}
+	if companyID == 0 {
+		companyID = prefs.GetLastCompanyID()
+	}
+	target := specsync.TaskTarget(taskID)
+	if taskID == "" {
+		target = specsync.FeatureTarget(featureID)
+	}
+	if companyID == 0 || target.ID == "" {
+		check(fmt.Errorf("not inside a Devplan workspace, provide --company and --task or --feature"))
+	}
+	return companyID, target, root
+}
+
+// specPatterns returns the patterns of the company deciding which files are specs, warning if they can't be loaded
+func specPatterns(ctx context.Context, adapter *specsync.ClientAdapter, companyID int32) string {
+	patterns, err := adapter.SpecPatterns(ctx, companyID)
+	if err != nil {
+		out.Pwarnf("%v, only default patterns and %s apply\n", err, specsync.IgnoreFile)
+	}
+	return patterns
+}
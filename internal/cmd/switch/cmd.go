@@ -10,6 +10,7 @@ import (
 
 	"github.com/charmbracelet/huh"
 	"github.com/devplaninc/devplan-cli/internal/cmd/common"
+	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/devplaninc/devplan-cli/internal/utils/ide"
 	"github.com/devplaninc/devplan-cli/internal/utils/metadata"
@@ -199,7 +200,9 @@ func getIDENames(ides map[ide.IDE]string) []ide.IDE {
 
 func check(err error) {
 	if err != nil {
-		fmt.Println(out.Failf("Error: %v", err))
+		if !devplan.OfferReauth(err) {
+			fmt.Println(out.Failf("Error: %v", err))
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,32 @@
+package worklog
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = create()
+)
+
+func create() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worklog",
+		Short: "Manage worklog entries reported to Devplan",
+	}
+	cmd.AddCommand(queueCmd)
+	return cmd
+}
+
+func check(err error) {
+	if err != nil {
+		if !devplan.OfferReauth(err) {
+			fmt.Println(out.Failf("Error: %v", err))
+		}
+		os.Exit(1)
+	}
+}
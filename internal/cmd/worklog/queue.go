@@ -0,0 +1,165 @@
+package worklog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/outbox"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// shortIDLen is enough to identify an item in drop
+	shortIDLen = 8
+	// backgroundFlushTimeout limits how long other commands wait for the queue to be flushed
+	backgroundFlushTimeout = 5 * time.Second
+)
+
+var (
+	queueCmd = createQueueCmd()
+)
+
+func createQueueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Manage worklog entries waiting to be submitted",
+		Long: `Manage worklog entries waiting to be submitted.
+Entries reported while Devplan can't be reached are queued under ~/.devplan/outbox
+(~/.devplan/outbox-<profile> for named profiles) and submitted in order
+by the MCP server or the next devplan command.`,
+	}
+	cmd.AddCommand(createListCmd())
+	cmd.AddCommand(createFlushCmd())
+	cmd.AddCommand(createDropCmd())
+	return cmd
+}
+
+func createListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Show queued worklog entries",
+		Run: func(_ *cobra.Command, _ []string) {
+			store, err := outbox.Default()
+			check(err)
+			items, err := store.List()
+			check(err)
+			if len(items) == 0 {
+				fmt.Println("No queued worklog entries")
+				return
+			}
+			for _, item := range items {
+				printItem(item)
+			}
+		},
+	}
+}
+
+func printItem(item *outbox.Item) {
+	wl, err := item.WorkLogItem()
+	if err != nil {
+		fmt.Printf("%s %s\n", out.H(item.ID[:shortIDLen]), out.Failf("%v", err))
+		return
+	}
+	target := fmt.Sprintf("task %s", wl.GetTaskId())
+	if wl.GetTaskId() == "" {
+		target = fmt.Sprintf("feature %s", wl.GetFeatureId())
+	}
+	fmt.Printf("%s %s company %d, %s: %s\n",
+		out.H(item.ID[:shortIDLen]), item.CreatedAt.Local().Format(time.DateTime), item.CompanyID, target, wl.GetMessage())
+	switch {
+	case item.Rejected:
+		fmt.Printf("  %s\n", out.Failf("rejected after %d attempts: %s", item.Attempts, item.LastError))
+	case item.Attempts > 0:
+		fmt.Printf("  %s\n", out.Faint(fmt.Sprintf("%d attempts, next at %s: %s",
+			item.Attempts, item.NextAttempt.Local().Format(time.DateTime), item.LastError)))
+	}
+}
+
+func createFlushCmd() *cobra.Command {
+	var includeRejected bool
+	cmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Submit queued worklog entries now",
+		Run: func(c *cobra.Command, _ []string) {
+			store, err := outbox.Default()
+			check(err)
+			cl := devplan.NewClient(devplan.Config{})
+			result, err := store.Flush(c.Context(), cl, outbox.FlushOptions{IgnoreBackoff: true, IncludeRejected: includeRejected})
+			check(err)
+			out.Psuccessf("Submitted %s worklog entries\n", out.H(len(result.SentIDs)))
+			for id, rejection := range result.Rejections {
+				out.Pwarnf("Entry %s was rejected: %v\n", id[:shortIDLen], rejection)
+			}
+			if result.Rejected > 0 {
+				out.Pwarnf("%d rejected entries stay queued, fix the issue and flush with --include-rejected or drop them\n", result.Rejected)
+			}
+			if result.Err != nil {
+				check(fmt.Errorf("%d entries are still queued: %w", result.Pending, result.Err))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&includeRejected, "include-rejected", false, "also resubmit entries rejected by Devplan before")
+	return cmd
+}
+
+func createDropCmd() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "drop [id...]",
+		Short: "Remove queued worklog entries without submitting them",
+		Example: `  devplan worklog queue drop 1a2b3c4d
+  devplan worklog queue drop --all`,
+		Run: func(_ *cobra.Command, args []string) {
+			if len(args) == 0 && !all {
+				check(errors.New("specify entry IDs to drop or --all"))
+			}
+			if len(args) > 0 && all {
+				check(errors.New("entry IDs can't be combined with --all"))
+			}
+			store, err := outbox.Default()
+			check(err)
+			removed, err := store.Drop(args...)
+			check(err)
+			out.Psuccessf("Dropped %s worklog entries\n", out.H(removed))
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "drop all queued entries")
+	return cmd
+}
+
+// FlushDue submits queued worklog entries that are due, so entries are not stuck until the next MCP run.
+// It never fails the command: errors are only logged. Without an API key it does nothing, as other
+// commands must not start the login flow.
+func FlushDue(ctx context.Context) {
+	if prefs.IsOffline() {
+		return
+	}
+	store, err := outbox.Default()
+	if err != nil {
+		return
+	}
+	if items, err := store.List(); err != nil || len(items) == 0 {
+		return
+	}
+	key, err := devplan.CurrentAPIKey()
+	if err != nil || key == "" {
+		slog.Debug("Not flushing worklog queue without an API key", "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, backgroundFlushTimeout)
+	defer cancel()
+	result, err := store.Flush(ctx, devplan.NewClient(devplan.Config{APIKey: key}), outbox.FlushOptions{})
+	if err != nil {
+		slog.Debug("Failed to flush worklog queue", "err", err)
+		return
+	}
+	if len(result.SentIDs) > 0 || result.Err != nil {
+		slog.Info("Flushed worklog queue", "sent", len(result.SentIDs), "pending", result.Pending, "rejected", result.Rejected, "err", result.Err)
+	}
+}
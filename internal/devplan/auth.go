@@ -5,10 +5,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/devplaninc/devplan-cli/internal/components/spinner"
 	"github.com/devplaninc/devplan-cli/internal/out"
+	"github.com/devplaninc/devplan-cli/internal/utils/credentials"
 	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
 	"io"
 	"math/big"
@@ -18,23 +21,47 @@ import (
 	"time"
 )
 
-func VerifyAuth() (string, error) {
-	key := prefs.GetAPIKey()
-	if key != "" {
+const (
+	// AuthTimeout limits how long the CLI waits for the login link to be approved
+	AuthTimeout = 5 * time.Minute
+)
+
+// authPollInterval is how often the approval of the login link is checked
+var authPollInterval = 2 * time.Second
+
+// VerifyAuth returns the API key of the active profile, starting the login flow if there is none
+func VerifyAuth(ctx context.Context) (string, error) {
+	key, err := CurrentAPIKey()
+	if err != nil || key != "" {
+		return key, err
+	}
+	return RequestAuth(ctx)
+}
+
+// CurrentAPIKey returns the API key from the environment or the one stored for the active profile,
+// or an empty key if the profile is not authenticated. Unlike VerifyAuth it never starts the login flow.
+func CurrentAPIKey() (string, error) {
+	if key, _ := credentials.EnvAPIKey(); key != "" {
 		return key, nil
 	}
-	return RequestAuth()
+	profile := prefs.ActiveProfile()
+	if !prefs.ProfileExists(profile) {
+		return "", fmt.Errorf("profile %q does not exist, create it with `devplan auth --profile %s`", profile, profile)
+	}
+	return credentials.GetAPIKey(profile)
 }
 
-func RequestAuth() (string, error) {
+// RequestAuth runs the browser login flow and stores the new API key in the active profile.
+// The flow is aborted after AuthTimeout or when ctx is cancelled.
+func RequestAuth(ctx context.Context) (string, error) {
 	fmt.Println("Starting authentication with Devplan service...")
 
+	cl := NewClient(Config{})
 	// Request a login link
-	requestID, err := requestLoginLink()
+	requestID, name, err := cl.requestLoginLink(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to request login link: %w", err)
 	}
-	cl := NewClient(Config{})
 	loginURL := fmt.Sprintf("%s/user/apikey/approve/%s", cl.BaseURL, requestID)
 
 	// Print the login link to the user
@@ -44,21 +71,28 @@ func RequestAuth() (string, error) {
 		key string
 		err error
 	}
-	ctx, cancel := context.WithCancel(context.Background())
+	waitCtx, cancelWait := context.WithTimeout(ctx, AuthTimeout)
+	defer cancelWait()
+	spinnerCtx, stopSpinner := context.WithCancel(waitCtx)
 	resChan := make(chan keyResult, 1)
 	go func() {
-		defer cancel()
-		apiKey, err := waitForAuthentication(requestID)
+		defer stopSpinner()
+		apiKey, err := cl.waitForAuthentication(waitCtx, requestID)
 		if err != nil {
 			resChan <- keyResult{err: err}
 			return
 		}
-		prefs.SetAPIKey(apiKey)
+		if err := StoreAPIKey(prefs.ActiveProfile(), apiKey, name); err != nil {
+			resChan <- keyResult{err: err}
+			return
+		}
 		resChan <- keyResult{key: apiKey}
 	}()
 
-	err = spinner.Run(ctx, "Waiting for authentication to complete", "Authenticated")
+	err = spinner.Run(spinnerCtx, "Waiting for authentication to complete", "Authenticated")
 	if err != nil {
+		// Stop polling, so the key is not stored after the user gave up
+		cancelWait()
 		fmt.Println(out.Fail(err))
 		return "", err
 	}
@@ -72,6 +106,45 @@ func RequestAuth() (string, error) {
 	return res.key, nil
 }
 
+// OfferReauth reports an authentication failure and offers to re-run the auth flow.
+// Returns false without printing anything if err is not an authentication failure.
+func OfferReauth(err error) bool {
+	if !IsUnauthorized(err) {
+		return false
+	}
+	fmt.Println(out.Failf("Devplan rejected the stored API key, it may have been revoked or expired."))
+	reauth := false
+	promptErr := huh.NewConfirm().
+		Title("Authenticate again now?").
+		Value(&reauth).
+		Run()
+	if promptErr != nil || !reauth {
+		fmt.Printf("Run %s to authenticate again.\n", out.H(ReauthCommand()))
+		return true
+	}
+	if _, authErr := RequestAuth(context.Background()); authErr == nil {
+		fmt.Println("Please re-run the command.")
+	}
+	return true
+}
+
+// StoreAPIKey saves the API key of the profile together with its name, shown by `devplan auth status`
+func StoreAPIKey(profile, key, name string) error {
+	if err := credentials.SetAPIKey(profile, key); err != nil {
+		return err
+	}
+	prefs.SetAPIKeyInfo(profile, name, time.Now())
+	return nil
+}
+
+// ReauthCommand returns the command that authenticates the active profile again
+func ReauthCommand() string {
+	if profile := prefs.ActiveProfile(); profile != prefs.DefaultProfile {
+		return "devplan auth --force --profile " + profile
+	}
+	return "devplan auth --force"
+}
+
 func keyName() string {
 	userName := "user"
 	curUser, err := user.Current()
@@ -103,30 +176,37 @@ func rndSuf(sufLen int) string {
 	return string(ret)
 }
 
-// requestLoginLink sends a request to the Devplan service to get a unique login link
-func requestLoginLink() (string, error) {
-	cl := NewClient(Config{})
+// requestLoginLink sends a request to the Devplan service to get a unique login link.
+// Returns the request id and the name of the key to be created.
+func (c *Client) requestLoginLink(ctx context.Context) (string, string, error) {
 	name := keyName()
-	apiKeyRequestURL := fmt.Sprintf("%s/api/v1/apikey/request", cl.BaseURL)
+	apiKeyRequestURL := fmt.Sprintf("%s/api/v1/apikey/request", c.BaseURL)
 	data := map[string]string{"name": name}
 
 	jsonBytes, err := json.Marshal(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to prepare data to send login link request: %w", err)
+		return "", "", fmt.Errorf("failed to prepare data to send login link request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiKeyRequestURL, bytes.NewReader(jsonBytes))
+	if err != nil {
+		return "", "", err
 	}
-	resp, err := http.Post(apiKeyRequestURL, "application/json", bytes.NewReader(jsonBytes))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send login link request: %w", err)
+		return "", "", fmt.Errorf("failed to send login link request: %w", err)
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("login link request failed with status code: %d", resp.StatusCode)
+		return "", "", fmt.Errorf("login link request failed with status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return "", "", fmt.Errorf("failed to read response body: %w", err)
 	}
-	_ = resp.Body.Close()
 
 	var createKeyResponse struct {
 		RequestID string `json:"requestID"`
@@ -134,22 +214,27 @@ func requestLoginLink() (string, error) {
 
 	err = json.Unmarshal(body, &createKeyResponse)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal create key response: %w", err)
+		return "", "", fmt.Errorf("failed to unmarshal create key response: %w", err)
 	}
 
-	return createKeyResponse.RequestID, nil
+	return createKeyResponse.RequestID, name, nil
 }
 
 // waitForAuthentication polls the Devplan service to check if the login link has been clicked
-// and returns the API key when authentication is complete
-func waitForAuthentication(requestID string) (string, error) {
-	cl := NewClient(Config{})
-	url := fmt.Sprintf("%s/api/v1/apikey/request/%s", cl.BaseURL, requestID)
+// and returns the API key when authentication is complete. It gives up when ctx is done.
+func (c *Client) waitForAuthentication(ctx context.Context, requestID string) (string, error) {
+	url := fmt.Sprintf("%s/api/v1/apikey/request/%s", c.BaseURL, requestID)
 
-	maxRetries := 60
-	for i := 0; i < maxRetries; i++ {
-		resp, err := http.Get(url)
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := c.client.Do(req)
 		if err != nil {
+			if ctxErr := authContextError(ctx); ctxErr != nil {
+				return "", ctxErr
+			}
 			return "", fmt.Errorf("failed to check auth status: %w", err)
 		}
 
@@ -175,8 +260,22 @@ func waitForAuthentication(requestID string) (string, error) {
 		}
 
 		// Wait before retrying
-		time.Sleep(2 * time.Second)
+		timer := time.NewTimer(authPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", authContextError(ctx)
+		case <-timer.C:
+		}
 	}
+}
 
-	return "", fmt.Errorf("authentication timed out")
+func authContextError(ctx context.Context) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("authentication timed out, the login link was not approved in time")
+	case ctx.Err() != nil:
+		return fmt.Errorf("authentication cancelled")
+	}
+	return nil
 }
@@ -0,0 +1,60 @@
+package devplan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthServer(t *testing.T, approveAfter int32) *httptest.Server {
+	prevInterval := authPollInterval
+	authPollInterval = time.Millisecond
+	t.Cleanup(func() { authPollInterval = prevInterval })
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/apikey/request/req-1", r.URL.Path)
+		if approveAfter > 0 && polls.Add(1) >= approveAfter {
+			_, _ = w.Write([]byte(`{"apiKey": "new-key"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"pendingMessage": "waiting"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func authClient(srv *httptest.Server) *Client {
+	return NewClient(Config{BaseURL: srv.URL, APIKey: "unused"})
+}
+
+func TestWaitForAuthentication_ReturnsKeyOnceApproved(t *testing.T) {
+	srv := newAuthServer(t, 3)
+
+	key, err := authClient(srv).waitForAuthentication(context.Background(), "req-1")
+	require.NoError(t, err)
+	assert.Equal(t, "new-key", key)
+}
+
+func TestWaitForAuthentication_TimesOut(t *testing.T) {
+	srv := newAuthServer(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := authClient(srv).waitForAuthentication(ctx, "req-1")
+	assert.ErrorContains(t, err, "timed out")
+}
+
+func TestWaitForAuthentication_Cancelled(t *testing.T) {
+	srv := newAuthServer(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := authClient(srv).waitForAuthentication(ctx, "req-1")
+	assert.EqualError(t, err, "authentication cancelled")
+}
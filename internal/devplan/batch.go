@@ -0,0 +1,180 @@
+package devplan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/integrations"
+)
+
+// DefaultConcurrency limits how many requests of a batch are in flight at once
+const DefaultConcurrency = 4
+
+var repoProviders = []string{"github", "bitbucket"}
+
+// BatchError reports the items of a batch that failed. Results of the other items are still returned.
+type BatchError struct {
+	Total int
+	// Errors holds the error of every failed item by its key, e.g. the document ID
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %v", key, e.Errors[key]))
+	}
+	return fmt.Sprintf("%d of %d requests failed: %s", len(e.Errors), e.Total, strings.Join(parts, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	result := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		result = append(result, err)
+	}
+	return result
+}
+
+// GetDocuments fetches the documents concurrently. Documents that could not be fetched are
+// reported in a *BatchError, the map holds all the others.
+func (c *Client) GetDocuments(ctx context.Context, companyID int32, documentIDs []string) (map[string]*company.GetDocResponse, error) {
+	return runBatch(ctx, c.concurrency, documentIDs, func(ctx context.Context, id string) (*company.GetDocResponse, error) {
+		return c.GetDocument(ctx, companyID, id)
+	})
+}
+
+// GetAllRepos returns the repositories of all git integrations of the company, fetched concurrently.
+// If an integration fails, the repositories of the others are returned with a *BatchError.
+func (c *Client) GetAllRepos(ctx context.Context, companyID int32) ([]*integrations.GitRepository, error) {
+	responses, err := runBatch(ctx, c.concurrency, repoProviders, func(ctx context.Context, provider string) (*company.GetIntegrationPropertiesResponse, error) {
+		return c.GetIntegration(ctx, companyID, provider)
+	})
+	var result []*integrations.GitRepository
+	if gh, ok := responses["github"]; ok {
+		result = append(result, gh.GetInfo().GetGithub().GetRepositories()...)
+	}
+	if bb, ok := responses["bitbucket"]; ok {
+		for _, integ := range bb.GetInfo().GetBitBucket().GetIntegrations() {
+			result = append(result, integ.GetRepositories()...)
+		}
+	}
+	return result, err
+}
+
+// runBatch calls fetch for every distinct key with at most concurrency calls at once.
+// All keys are attempted, failures are collected into a *BatchError.
+func runBatch[V any](ctx context.Context, concurrency int, keys []string, fetch func(context.Context, string) (V, error)) (map[string]V, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]V, len(keys))
+		errs    = map[string]error{}
+		seen    = map[string]bool{}
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				errs[key] = ctx.Err()
+				mu.Unlock()
+				return
+			}
+			v, err := fetch(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[key] = err
+				return
+			}
+			results[key] = v
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return results, &BatchError{Total: len(seen), Errors: errs}
+	}
+	return results, nil
+}
+
+// flightGroup deduplicates identical requests that are in flight at the same time
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	body []byte
+	err  error
+	// waiters is the number of callers waiting for the result, the request is cancelled when all of them left
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fn once for concurrent calls with the same key and shares its result. The shared body must not be modified.
+// The request is not bound to the context of any caller: a cancelled caller returns its context error right away,
+// and the request is only cancelled once every caller has left.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = map[string]*flight{}
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go func() {
+			body, err := fn(flightCtx)
+			g.mu.Lock()
+			f.body, f.err = body, err
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+			g.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.body, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Later calls start a new request rather than joining the cancelled one
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+			f.cancel()
+		}
+		return nil, ctx.Err()
+	}
+}
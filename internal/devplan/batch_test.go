@@ -0,0 +1,144 @@
+package devplan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetDocuments(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			prev := maxInFlight.Load()
+			if n <= prev || maxInFlight.CompareAndSwap(prev, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if id == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"document": {"id": %q}}`, id)
+	})
+	cl.concurrency = 2
+
+	docs, err := cl.GetDocuments(context.Background(), 1, []string{"a", "b", "missing", "c", "a", "d"})
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 5, batchErr.Total)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.True(t, IsNotFound(batchErr.Errors["missing"]))
+	assert.True(t, IsNotFound(err), "errors of items are unwrapped")
+
+	require.Len(t, docs, 4)
+	assert.Equal(t, "c", docs["c"].GetDocument().GetId())
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+}
+
+func TestClient_GetAllReposReturnsPartialResults(t *testing.T) {
+	cl := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/bitbucket") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"info": {"github": {"repositories": [{"fullName": "acme/storefront"}]}}}`))
+	})
+
+	repos, err := cl.GetAllRepos(context.Background(), 1)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Contains(t, batchErr.Errors, "bitbucket")
+	require.Len(t, repos, 1)
+	assert.Equal(t, "acme/storefront", repos[0].GetFullName())
+}
+
+func TestClient_DeduplicatesInFlightRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(docJSON))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := cl.GetDocument(context.Background(), 1, "doc-1")
+			assert.NoError(t, err)
+			assert.Equal(t, "Cached", resp.GetDocument().GetTitle())
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_DeduplicatedRequestOutlivesCancelledCaller(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(docJSON))
+	})
+	waiters := func() int {
+		cl.flights.mu.Lock()
+		defer cl.flights.mu.Unlock()
+		if f, ok := cl.flights.flights["GET /company/1/document/doc-1"]; ok {
+			return f.waiters
+		}
+		return 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cl.get(ctx, "/company/1/document/doc-1")
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+	second := make(chan error, 1)
+	go func() {
+		body, err := cl.get(context.Background(), "/company/1/document/doc-1")
+		assert.JSONEq(t, docJSON, string(body))
+		second <- err
+	}()
+	require.Eventually(t, func() bool { return waiters() == 2 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled, "the cancelled caller does not wait for the request")
+	close(release)
+	assert.NoError(t, <-second)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2)
+	assert.Zero(t, l.reserve())
+	assert.Zero(t, l.reserve())
+	delay := l.reserve()
+	assert.Greater(t, delay, 400*time.Millisecond)
+	assert.LessOrEqual(t, delay, 500*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+	assert.NoError(t, (*rateLimiter)(nil).Wait(ctx))
+}
@@ -0,0 +1,135 @@
+package devplan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrOffline is returned for requests that cannot be served from the cache in offline mode
+var ErrOffline = errors.New("not available in offline mode")
+
+// Freshness of cached lookups. Stale entries are revalidated with the server.
+const (
+	// documentTTL applies to content that is actively edited, e.g. documents and task recipes
+	documentTTL = 2 * time.Minute
+	// listTTL applies to lists that change when projects are created or reorganized
+	listTTL = 5 * time.Minute
+	// accountTTL applies to user, company and integration settings
+	accountTTL = 10 * time.Minute
+	// staticTTL applies to rarely changing data like templates, rules and repo summaries
+	staticTTL = time.Hour
+)
+
+func (c *Client) getCachedParsed(ctx context.Context, path string, ttl time.Duration, msg proto.Message) error {
+	body, err := c.getCached(ctx, path, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to get response: %w", err)
+	}
+	u := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err := u.Unmarshal(body, msg); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// getCached serves a GET request from the cache while it is fresh and revalidates it afterward.
+// When the server is unreachable a stale entry is served instead of failing.
+// Concurrent lookups of the same path share a single request.
+func (c *Client) getCached(ctx context.Context, path string, ttl time.Duration) ([]byte, error) {
+	return c.flights.do(ctx, "cached "+path, func(ctx context.Context) ([]byte, error) {
+		return c.fetchCached(ctx, path, ttl)
+	})
+}
+
+func (c *Client) fetchCached(ctx context.Context, path string, ttl time.Duration) ([]byte, error) {
+	if c.cache == nil {
+		return c.get(ctx, path)
+	}
+	scope := cacheScope(path)
+	key := apicache.Key(c.BaseURL, path)
+	entry, found := c.cache.Get(scope, key)
+	if c.offline {
+		if !found {
+			return nil, fmt.Errorf("%s is not cached: %w", path, ErrOffline)
+		}
+		return entry.Body, nil
+	}
+	if found && entry.Age() < ttl {
+		return entry.Body, nil
+	}
+
+	r := request{method: http.MethodGet, path: path, idempotent: true}
+	if found {
+		r.header = conditionalHeaders(entry)
+	}
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		if _, isAPIErr := AsAPIError(err); found && !isAPIErr && ctx.Err() == nil {
+			slog.Warn("Serving stale cached response", "path", path, "age", entry.Age(), "err", err)
+			return entry.Body, nil
+		}
+		return nil, err
+	}
+	if resp.status == http.StatusNotModified {
+		entry.StoredAt = time.Now()
+		c.storeCached(scope, key, entry)
+		return entry.Body, nil
+	}
+	c.storeCached(scope, key, &apicache.Entry{
+		Path:         path,
+		StoredAt:     time.Now(),
+		ETag:         resp.header.Get("ETag"),
+		LastModified: resp.header.Get("Last-Modified"),
+		Body:         resp.body,
+	})
+	return resp.body, nil
+}
+
+func (c *Client) storeCached(scope, key string, entry *apicache.Entry) {
+	if err := c.cache.Put(scope, key, entry); err != nil {
+		slog.Debug("Failed to cache response", "path", entry.Path, "err", err)
+	}
+}
+
+func conditionalHeaders(entry *apicache.Entry) http.Header {
+	header := http.Header{}
+	if entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		header.Set("If-Modified-Since", entry.LastModified)
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	return header
+}
+
+// isConditional reports whether the headers make the request conditional, so 304 means the cached copy is valid
+func isConditional(header http.Header) bool {
+	return header.Get("If-None-Match") != "" || header.Get("If-Modified-Since") != ""
+}
+
+// cacheScope groups cache entries by company, so they can be cleared per company
+func cacheScope(path string) string {
+	prefix := apiPath + "/company/"
+	if !strings.HasPrefix(path, prefix) {
+		return "user"
+	}
+	companyID, _, _ := strings.Cut(strings.TrimPrefix(path, prefix), "/")
+	return CompanyCacheScope(companyID)
+}
+
+// CompanyCacheScope returns the cache scope holding responses of the company
+func CompanyCacheScope(companyID string) string {
+	return "company-" + companyID
+}
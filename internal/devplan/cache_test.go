@@ -0,0 +1,137 @@
+package devplan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const docJSON = `{"document":{"id":"doc-1","title":"Cached"}}`
+
+func TestClient_ServesFreshLookupsFromCache(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(docJSON))
+	})
+
+	for i := 0; i < 3; i++ {
+		resp, err := cl.GetDocument(context.Background(), 1, "doc-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Cached", resp.GetDocument().GetTitle())
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_RevalidatesStaleEntries(t *testing.T) {
+	var calls atomic.Int32
+	var notModified atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(docJSON))
+	})
+	path := documentPath(1, "doc-1")
+
+	_, err := cl.GetDocument(context.Background(), 1, "doc-1")
+	require.NoError(t, err)
+	expireEntry(t, cl, path)
+
+	resp, err := cl.GetDocument(context.Background(), 1, "doc-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Cached", resp.GetDocument().GetTitle())
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+
+	entry, ok := cl.cache.Get(cacheScope(path), apicache.Key(cl.BaseURL, path))
+	require.True(t, ok)
+	assert.Less(t, entry.Age(), time.Minute)
+}
+
+func TestClient_ServesStaleEntryWhenServerUnreachable(t *testing.T) {
+	t.Setenv("DEVPLAN_API_KEY", "test-key")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(docJSON))
+	}))
+	cl := NewClient(Config{BaseURL: srv.URL, Timeout: time.Second, Retry: fastRetry, Cache: apicache.New(t.TempDir())})
+
+	_, err := cl.GetDocument(context.Background(), 1, "doc-1")
+	require.NoError(t, err)
+	expireEntry(t, cl, documentPath(1, "doc-1"))
+	srv.Close()
+
+	resp, err := cl.GetDocument(context.Background(), 1, "doc-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Cached", resp.GetDocument().GetTitle())
+}
+
+func TestClient_OfflineMode(t *testing.T) {
+	var calls atomic.Int32
+	store := apicache.New(t.TempDir())
+	t.Setenv("DEVPLAN_API_KEY", "test-key")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(docJSON))
+	}))
+	defer srv.Close()
+
+	online := NewClient(Config{BaseURL: srv.URL, Cache: store})
+	_, err := online.GetDocument(context.Background(), 1, "doc-1")
+	require.NoError(t, err)
+
+	offline := NewClient(Config{BaseURL: srv.URL, Cache: store, Offline: true})
+	expireEntry(t, offline, documentPath(1, "doc-1"))
+	resp, err := offline.GetDocument(context.Background(), 1, "doc-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Cached", resp.GetDocument().GetTitle())
+
+	_, err = offline.GetDocument(context.Background(), 1, "doc-2")
+	assert.ErrorIs(t, err, ErrOffline)
+	_, err = offline.SubmitWorklogItem(context.Background(), 1, nil)
+	assert.ErrorIs(t, err, ErrOffline)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_DoesNotCacheWrites(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := cl.SubmitWorklogItem(context.Background(), 1, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+	stats, err := cl.cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+}
+
+func TestCacheScope(t *testing.T) {
+	assert.Equal(t, "company-12", cacheScope(documentPath(12, "doc")))
+	assert.Equal(t, "company-12", cacheScope(devIDERecipePath(12)))
+	assert.Equal(t, "user", cacheScope(selfPath))
+}
+
+func expireEntry(t *testing.T, cl *Client, path string) {
+	t.Helper()
+	scope, key := cacheScope(path), apicache.Key(cl.BaseURL, path)
+	entry, ok := cl.cache.Get(scope, key)
+	require.True(t, ok)
+	entry.StoredAt = time.Now().Add(-24 * time.Hour)
+	require.NoError(t, cl.cache.Put(scope, key, entry))
+}
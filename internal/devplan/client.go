@@ -2,16 +2,22 @@ package devplan
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/devplan-cli/internal/utils/httplog"
 	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/devplaninc/devplan-cli/internal/utils/transport"
 	"github.com/devplaninc/devplan-cli/internal/version"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/user"
-	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/integrations"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
 	"github.com/opensdd/osdd-api/clients/go/osdd/recipes"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -20,122 +26,238 @@ import (
 
 type Config struct {
 	BaseURL string
+	// Timeout limits a single HTTP attempt. Defaults to the http_timeout preference or DefaultTimeout.
+	Timeout time.Duration
+	// Retry controls retries of idempotent requests. Defaults to DefaultRetryPolicy.
+	Retry *RetryPolicy
+	// Cache stores lookup responses. Defaults to the store under ~/.devplan/cache.
+	Cache *apicache.Store
+	// Offline serves lookups only from the cache and fails all other requests.
+	// Also enabled by the --offline flag.
+	Offline bool
+	// Concurrency limits how many requests of a batch, e.g. GetDocuments, run at once. Defaults to DefaultConcurrency.
+	Concurrency int
+	// RateLimit caps requests per second to the API host, shared by all clients.
+	// Defaults to the http_rate_limit preference or DefaultRateLimit, negative disables it.
+	RateLimit float64
+	// APIKey authenticates requests instead of the key of the active profile.
+	// Responses cached for the profile are not used with it unless Cache is set.
+	APIKey string
 }
 
 type Client struct {
 	BaseURL string
 
-	client *http.Client
+	client  *http.Client
+	timeout time.Duration
+	retry   RetryPolicy
+	cache   *apicache.Store
+	offline bool
+	apiKey  string
+
+	concurrency int
+	limiter     *rateLimiter
+	flights     flightGroup
 }
 
 func NewClient(config Config) *Client {
 	baseURL := config.BaseURL
 	if baseURL == "" {
-		domain := prefs.Domain
-		if domain == "" {
-			domain = os.Getenv("DEVPLAN_API_DOMAIN")
+		baseURL = GetBaseURL(prefs.GetDomain())
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = prefs.GetHTTPTimeout()
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	retry := DefaultRetryPolicy
+	if config.Retry != nil {
+		retry = *config.Retry
+	} else if attempts := prefs.GetHTTPMaxAttempts(); attempts > 0 {
+		retry.MaxAttempts = attempts
+	}
+	cache := config.Cache
+	if cache == nil && config.APIKey == "" {
+		var err error
+		if cache, err = apicache.Default(); err != nil {
+			slog.Warn("API cache is disabled", "err", err)
 		}
-		baseURL = GetBaseURL(domain)
 	}
-	return &Client{BaseURL: baseURL, client: &http.Client{}}
+	rate := config.RateLimit
+	if rate == 0 {
+		rate = prefs.GetHTTPRateLimit()
+	}
+	if rate == 0 {
+		rate = DefaultRateLimit
+	}
+	var limiter *rateLimiter
+	if rate > 0 {
+		limiter = limiterFor(hostOf(baseURL), rate)
+	}
+	var base http.RoundTripper
+	t, err := transport.Default()
+	if err != nil {
+		// Reported by the first request, commands that only use the cache still work
+		base = failingTransport{err: err}
+	} else {
+		base = t
+	}
+	return &Client{
+		BaseURL: baseURL,
+		client:  &http.Client{Transport: traceTransport(base)},
+		timeout: timeout,
+		retry:   retry,
+		cache:   cache,
+		offline: config.Offline || prefs.IsOffline(),
+		apiKey:  config.APIKey,
+
+		concurrency: config.Concurrency,
+		limiter:     limiter,
+	}
+}
+
+func hostOf(baseURL string) string {
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return baseURL
+}
+
+// traceTransport wraps base to record API requests when tracing is enabled
+func traceTransport(base http.RoundTripper) http.RoundTripper {
+	dest := prefs.GetTrace()
+	if dest == "" {
+		return base
+	}
+	sink, err := httplog.Open(dest)
+	if err != nil {
+		slog.Warn("HTTP trace is disabled", "err", err)
+		return base
+	}
+	return httplog.NewTransport(base, sink)
+}
+
+// failingTransport fails all requests when the network settings are invalid, e.g. a missing CA bundle
+type failingTransport struct {
+	err error
+}
+
+func (f failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("invalid network settings: %w", f.err)
 }
 
-func (c *Client) GetCompanyProjects(companyID int32) (*company.GetProjectsWithDocsResponse, error) {
+func (c *Client) GetCompanyProjects(ctx context.Context, companyID int32) (*company.GetProjectsWithDocsResponse, error) {
 	result := &company.GetProjectsWithDocsResponse{}
-	return result, c.getParsed(projectsPath(companyID), result)
+	return result, c.getCachedParsed(ctx, projectsPath(companyID), listTTL, result)
 }
 
-func (c *Client) GetProjectDocuments(companyID int32, projectID string) (*company.GetAllProjectDocsResponse, error) {
+func (c *Client) GetProjectDocuments(ctx context.Context, companyID int32, projectID string) (*company.GetAllProjectDocsResponse, error) {
 	result := &company.GetAllProjectDocsResponse{}
-	return result, c.getParsed(projectDocsPath(companyID, projectID), result)
+	return result, c.getCachedParsed(ctx, projectDocsPath(companyID, projectID), documentTTL, result)
 }
 
-func (c *Client) GetDocument(companyID int32, documentID string) (*company.GetDocResponse, error) {
+func (c *Client) GetDocument(ctx context.Context, companyID int32, documentID string) (*company.GetDocResponse, error) {
 	result := &company.GetDocResponse{}
-	return result, c.getParsed(documentPath(companyID, documentID), result)
+	return result, c.getCachedParsed(ctx, documentPath(companyID, documentID), documentTTL, result)
 }
 
-func (c *Client) GetProjectTemplates(companyID int32) (*company.GetTemplatesResponse, error) {
+func (c *Client) GetProjectTemplates(ctx context.Context, companyID int32) (*company.GetTemplatesResponse, error) {
 	result := &company.GetTemplatesResponse{}
-	return result, c.getParsed(templatesPath(companyID), result)
+	return result, c.getCachedParsed(ctx, templatesPath(companyID), staticTTL, result)
 }
 
-func (c *Client) GetGroup(companyID int32, groupID string) (*company.GetGroupResponse, error) {
+func (c *Client) GetGroup(ctx context.Context, companyID int32, groupID string) (*company.GetGroupResponse, error) {
 	result := &company.GetGroupResponse{}
-	return result, c.getParsed(groupPath(companyID, groupID), result)
+	return result, c.getCachedParsed(ctx, groupPath(companyID, groupID), listTTL, result)
 }
 
-func (c *Client) GetSelf() (*user.GetSelfResponse, error) {
+func (c *Client) GetSelf(ctx context.Context) (*user.GetSelfResponse, error) {
 	result := &user.GetSelfResponse{}
-	return result, c.getParsed(selfPath, result)
+	return result, c.getCachedParsed(ctx, selfPath, accountTTL, result)
 }
 
-func (c *Client) GetIntegration(companyID int32, provider string) (*company.GetIntegrationPropertiesResponse, error) {
+func (c *Client) GetIntegration(ctx context.Context, companyID int32, provider string) (*company.GetIntegrationPropertiesResponse, error) {
 	result := &company.GetIntegrationPropertiesResponse{}
-	return result, c.getParsed(integrationPath(companyID, provider), result)
+	return result, c.getCachedParsed(ctx, integrationPath(companyID, provider), accountTTL, result)
 }
 
-func (c *Client) GetAllRepos(companyID int32) ([]*integrations.GitRepository, error) {
-	ghResult := &company.GetIntegrationPropertiesResponse{}
-	err := c.getParsed(integrationPath(companyID, "github"), ghResult)
-	if err != nil {
-		return nil, err
-	}
-	bbResult := &company.GetIntegrationPropertiesResponse{}
-	err = c.getParsed(integrationPath(companyID, "bitbucket"), bbResult)
-	if err != nil {
-		return nil, err
-	}
-	result := ghResult.GetInfo().GetGithub().GetRepositories()
-	for _, integ := range bbResult.GetInfo().GetBitBucket().GetIntegrations() {
-		result = append(result, integ.GetRepositories()...)
-	}
-	return result, nil
-}
-
-func (c *Client) GetDevRule(companyID int32, ruleName string) (*company.GetDevRuleResponse, error) {
+func (c *Client) GetDevRule(ctx context.Context, companyID int32, ruleName string) (*company.GetDevRuleResponse, error) {
 	result := &company.GetDevRuleResponse{}
-	return result, c.getParsed(devRulePath(companyID, ruleName), result)
+	return result, c.getCachedParsed(ctx, devRulePath(companyID, ruleName), staticTTL, result)
 }
 
-func (c *Client) GetIDERecipe(companyID int32) (*recipes.Recipe, error) {
+func (c *Client) GetIDERecipe(ctx context.Context, companyID int32) (*recipes.Recipe, error) {
 	result := &company.GetDevRecipeResponse{}
-	if err := c.getParsed(devIDERecipePath(companyID), result); err != nil {
+	if err := c.getCachedParsed(ctx, devIDERecipePath(companyID), staticTTL, result); err != nil {
 		return nil, err
 	}
 	return unmarshalRecipe(result.GetJsonRecipe())
 }
 
-func (c *Client) GetTaskRecipe(companyID int32, taskID string) (*recipes.Recipe, error) {
+func (c *Client) GetTaskRecipe(ctx context.Context, companyID int32, taskID string) (*recipes.Recipe, error) {
 	result := &company.GetTaskRecipeResponse{}
-	if err := c.getParsed(devTaskPath(companyID, taskID), result); err != nil {
+	if err := c.getCachedParsed(ctx, devTaskPath(companyID, taskID), documentTTL, result); err != nil {
 		return nil, err
 	}
 	return unmarshalRecipe(result.GetJsonRecipe())
 }
 
-func (c *Client) GetTaskExecRecipe(companyID int32, taskID string) (*recipes.ExecutableRecipe, error) {
+func (c *Client) GetTaskExecRecipe(ctx context.Context, companyID int32, taskID string) (*recipes.ExecutableRecipe, error) {
 	result := &company.GetTaskRecipeResponse{}
-	if err := c.getParsed(devTaskExecRecipePath(companyID, taskID), result); err != nil {
+	if err := c.getCachedParsed(ctx, devTaskExecRecipePath(companyID, taskID), documentTTL, result); err != nil {
 		return nil, err
 	}
 	return unmarshalExecRecipe(result.GetJsonRecipe())
 }
 
-func (c *Client) GetFeatureExecRecipe(companyID int32, featureID string) (*recipes.ExecutableRecipe, error) {
+func (c *Client) GetFeatureExecRecipe(ctx context.Context, companyID int32, featureID string) (*recipes.ExecutableRecipe, error) {
 	result := &company.GetUserStoryRecipeResponse{}
-	if err := c.getParsed(devFeatureExecRecipePath(companyID, featureID), result); err != nil {
+	if err := c.getCachedParsed(ctx, devFeatureExecRecipePath(companyID, featureID), documentTTL, result); err != nil {
 		return nil, err
 	}
 	return unmarshalExecRecipe(result.GetJsonRecipe())
 }
 
-func (c *Client) SubmitWorklogItem(companyID int32, item *worklog.WorkLogItem) (*company.SubmitWorkLogResponse, error) {
+func (c *Client) SubmitWorklogItem(ctx context.Context, companyID int32, item *worklog.WorkLogItem) (*company.SubmitWorkLogResponse, error) {
 	result := &company.SubmitWorkLogResponse{}
 	req := company.SubmitWorkLogRequest_builder{
 		Item: item,
 	}.Build()
-	return result, c.postParsed(submitWorkLogPath(companyID), req, result)
+	return result, c.postParsed(ctx, submitWorkLogPath(companyID), req, result)
+}
+
+// IdempotencyKeyHeader lets the server drop repeated submissions of the same request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// SubmitWorklogItemWithKey submits the item with an idempotency key. The server ignores repeated submissions
+// with the same key, so unlike SubmitWorklogItem the request is retried on transient failures.
+func (c *Client) SubmitWorklogItemWithKey(ctx context.Context, companyID int32, item *worklog.WorkLogItem, key string) (*company.SubmitWorkLogResponse, error) {
+	req := company.SubmitWorkLogRequest_builder{
+		Item: item,
+	}.Build()
+	payload, err := protojson.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal worklog item: %w", err)
+	}
+	body, err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        submitWorkLogPath(companyID),
+		body:        payload,
+		contentType: "application/json",
+		header:      http.Header{IdempotencyKeyHeader: {key}},
+		idempotent:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post response: %w", err)
+	}
+	result := &company.SubmitWorkLogResponse{}
+	u := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err := u.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return result, nil
 }
 
 func unmarshalRecipe(js string) (*recipes.Recipe, error) {
@@ -150,58 +272,84 @@ func unmarshalExecRecipe(js string) (*recipes.ExecutableRecipe, error) {
 	return recipe, u.Unmarshal([]byte(js), recipe)
 }
 
-func (c *Client) GetRepoSummaries(companyID int32) (*company.GetRepoSummariesResponse, error) {
+func (c *Client) GetRepoSummaries(ctx context.Context, companyID int32) (*company.GetRepoSummariesResponse, error) {
 	result := &company.GetRepoSummariesResponse{}
-	return result, c.getParsed(repoSummariesPath(companyID), result)
+	return result, c.getCachedParsed(ctx, repoSummariesPath(companyID), staticTTL, result)
 }
 
 // GetTaskSpecs retrieves task specs.
-func (c *Client) GetTaskSpecs(companyID int32, taskID string) (*company.GetTaskSpecsResponse, error) {
+func (c *Client) GetTaskSpecs(ctx context.Context, companyID int32, taskID string) (*company.GetTaskSpecsResponse, error) {
 	response := &company.GetTaskSpecsResponse{}
-	err := c.getParsed(taskSpecsPath(companyID, taskID), response)
+	err := c.getParsed(ctx, taskSpecsPath(companyID, taskID), response)
 	return response, err
 }
 
-// UploadTaskSpec uploads a spec for atask
-func (c *Client) UploadTaskSpec(companyID int32, taskID string, req *company.UploadSpecRequest) error {
-	_, err := c.post(taskSpecsPath(companyID, taskID), req)
+// UploadTaskSpec uploads a spec for a task. Uploads are keyed by name and checksum, so they are safe to retry.
+func (c *Client) UploadTaskSpec(ctx context.Context, companyID int32, taskID string, req *company.UploadSpecRequest) error {
+	_, err := c.postIdempotent(ctx, taskSpecsPath(companyID, taskID), req)
 	return err
 }
 
-func (c *Client) put(path string, data io.Reader, contentType string) ([]byte, error) {
-	url := fmt.Sprintf("%s/%s", c.BaseURL, path)
+// GetTaskSpec downloads a spec of a task with its content. The spec is returned in the format it is uploaded in.
+func (c *Client) GetTaskSpec(ctx context.Context, companyID int32, taskID string, name string) (*company.UploadSpecRequest, error) {
+	response := &company.UploadSpecRequest{}
+	err := c.getParsed(ctx, specPath(taskSpecsPath(companyID, taskID), name), response)
+	return response, err
+}
 
-	httpReq, err := http.NewRequest("PUT", url, data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request for %s: %w", path, err)
-	}
-	if err := c.setHeaders(httpReq); err != nil {
-		return nil, err
-	}
-	httpReq.Header.Set("Content-Type", contentType)
+// GetFeatureSpecs retrieves the specs of a feature, e.g. planning docs written in a feature workspace.
+// The specs directory is returned in the paths info under the feature ID.
+func (c *Client) GetFeatureSpecs(ctx context.Context, companyID int32, featureID string) (*company.GetTaskSpecsResponse, error) {
+	response := &company.GetTaskSpecsResponse{}
+	err := c.getParsed(ctx, featureSpecsPath(companyID, featureID), response)
+	return response, err
+}
 
-	resp, err := c.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to put %s: %w", url, err)
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
+// UploadFeatureSpec uploads a spec for a feature. Like task specs, uploads are safe to retry.
+func (c *Client) UploadFeatureSpec(ctx context.Context, companyID int32, featureID string, req *company.UploadSpecRequest) error {
+	_, err := c.postIdempotent(ctx, featureSpecsPath(companyID, featureID), req)
+	return err
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response %s: %w", url, err)
-	}
+// GetFeatureSpec downloads a spec of a feature with its content, like GetTaskSpec.
+func (c *Client) GetFeatureSpec(ctx context.Context, companyID int32, featureID string, name string) (*company.UploadSpecRequest, error) {
+	response := &company.UploadSpecRequest{}
+	err := c.getParsed(ctx, specPath(featureSpecsPath(companyID, featureID), name), response)
+	return response, err
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("failed to put %s: %s [%v]: %s", url, resp.Status, resp.StatusCode, string(body))
+// RevokeAPIKey revokes the key used by the client on the server.
+// Returns ErrRevokeUnsupported if the server has no revocation endpoint.
+func (c *Client) RevokeAPIKey(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: currentAPIKeyPath, idempotent: true})
+	if code := StatusCode(err); code == http.StatusNotFound || code == http.StatusMethodNotAllowed {
+		return fmt.Errorf("%w: %w", ErrRevokeUnsupported, err)
 	}
+	return err
+}
 
-	return body, nil
+// request describes a single API call
+type request struct {
+	method      string
+	path        string
+	body        []byte
+	contentType string
+	// header holds extra request headers, e.g. for conditional requests or idempotency keys
+	header http.Header
+	// idempotent requests are retried on transient failures
+	idempotent bool
 }
 
-func (c *Client) getParsed(path string, msg proto.Message) error {
-	body, err := c.get(path)
+func (c *Client) put(ctx context.Context, path string, data io.Reader, contentType string) ([]byte, error) {
+	payload, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body for %s: %w", path, err)
+	}
+	return c.do(ctx, request{method: http.MethodPut, path: path, body: payload, contentType: contentType, idempotent: true})
+}
+
+func (c *Client) getParsed(ctx context.Context, path string, msg proto.Message) error {
+	body, err := c.get(ctx, path)
 	if err != nil {
 		return fmt.Errorf("failed to get response: %w", err)
 	}
@@ -214,59 +362,127 @@ func (c *Client) getParsed(path string, msg proto.Message) error {
 	return nil
 }
 
-func (c *Client) get(path string) ([]byte, error) {
-	url := fmt.Sprintf("%s/%s", c.BaseURL, path)
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	return c.flights.do(ctx, http.MethodGet+" "+path, func(ctx context.Context) ([]byte, error) {
+		return c.do(ctx, request{method: http.MethodGet, path: path, idempotent: true})
+	})
+}
+
+// post sends a non-idempotent POST request which is never retried
+func (c *Client) post(ctx context.Context, path string, req proto.Message) ([]byte, error) {
+	return c.postRequest(ctx, path, req, false)
+}
+
+// postIdempotent sends a POST request that is safe to repeat, so it is retried on transient failures
+func (c *Client) postIdempotent(ctx context.Context, path string, req proto.Message) ([]byte, error) {
+	return c.postRequest(ctx, path, req, true)
+}
+
+func (c *Client) postRequest(ctx context.Context, path string, req proto.Message, idempotent bool) ([]byte, error) {
+	m := protojson.MarshalOptions{}
+	payload, err := m.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request for %s: %w", path, err)
+		return nil, fmt.Errorf("failed to marshal request for %s: %w", path, err)
 	}
+	return c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        path,
+		body:        payload,
+		contentType: "application/json",
+		idempotent:  idempotent,
+	})
+}
 
-	if err := c.setHeaders(req); err != nil {
+// response is a successful HTTP exchange
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do executes the request and returns the response body
+func (c *Client) do(ctx context.Context, r request) ([]byte, error) {
+	resp, err := c.send(ctx, r)
+	if err != nil {
 		return nil, err
 	}
+	return resp.body, nil
+}
 
-	// Send the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get %s: %w", url, err)
+// send executes the request, retrying idempotent ones with exponential backoff and jitter
+func (c *Client) send(ctx context.Context, r request) (*response, error) {
+	if c.offline {
+		return nil, fmt.Errorf("%s %s: %w", r.method, r.path, ErrOffline)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get %s: %s [%v]", url, resp.Status, resp.StatusCode)
+	key := c.apiKey
+	if key == "" {
+		var err error
+		if key, err = VerifyAuth(ctx); err != nil {
+			return nil, err
+		}
 	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response %s: %w", url, err)
+	maxAttempts := 1
+	if r.idempotent && c.retry.MaxAttempts > 1 {
+		maxAttempts = c.retry.MaxAttempts
+	}
+	for attempt := 0; ; attempt++ {
+		resp, wait, retryable, err := c.attempt(ctx, r, key)
+		if err == nil {
+			return resp, nil
+		}
+		if !retryable || attempt+1 >= maxAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+		// Waiting longer than a backoff would hang commands that run without a deadline
+		if wait > c.retry.MaxDelay {
+			return nil, err
+		}
+		delay := c.retry.backoff(attempt)
+		if wait > delay {
+			delay = wait
+		}
+		slog.Debug("Retrying request", "method", r.method, "path", r.path, "attempt", attempt+1, "delay", delay, "err", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
 	}
-	return body, nil
 }
 
-func (c *Client) post(path string, req proto.Message) ([]byte, error) {
-	url := fmt.Sprintf("%s/%s", c.BaseURL, path)
+// attempt performs a single HTTP exchange. It returns the server requested delay and
+// whether the failure is transient.
+func (c *Client) attempt(ctx context.Context, r request, key string) (*response, time.Duration, bool, error) {
+	url := fmt.Sprintf("%s/%s", c.BaseURL, r.path)
+	verb := strings.ToLower(r.method)
 
-	m := protojson.MarshalOptions{}
-	payload, err := m.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request for %s: %w", path, err)
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, 0, false, err
 	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
 
-	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	var data io.Reader
+	if r.body != nil {
+		data = bytes.NewReader(r.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, r.method, url, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request for %s: %w", path, err)
+		return nil, 0, false, fmt.Errorf("failed to create request for %s: %w", r.path, err)
 	}
-	if err := c.setHeaders(httpReq); err != nil {
-		return nil, err
+	for name, values := range r.header {
+		httpReq.Header[name] = values
+	}
+	c.setHeaders(httpReq, key)
+	if r.contentType != "" {
+		httpReq.Header.Set("Content-Type", r.contentType)
 	}
 
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to post %s: %w", url, err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to post %s: %s [%v]", url, resp.Status, resp.StatusCode)
+		return nil, 0, isRetryableError(err), fmt.Errorf("failed to %s %s: %w", verb, url, err)
 	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
@@ -274,26 +490,27 @@ func (c *Client) post(path string, req proto.Message) ([]byte, error) {
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response %s: %w", url, err)
+		return nil, 0, isRetryableError(err), fmt.Errorf("failed to read response %s: %w", url, err)
 	}
-	return body, nil
-}
 
-func (c *Client) setHeaders(req *http.Request) error {
-	key, err := VerifyAuth()
-	if err != nil {
-		return err
+	switch {
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusCreated, resp.StatusCode == http.StatusNoContent:
+	case resp.StatusCode == http.StatusNotModified && isConditional(r.header):
+		// Conditional request, the cached copy is still valid
+	default:
+		err := newAPIError(r.method, r.path, resp, body)
+		return nil, retryAfter(resp), isRetryableStatus(resp.StatusCode), err
 	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: body}, 0, false, nil
+}
+
+func (c *Client) setHeaders(req *http.Request, key string) {
 	req.Header.Add("Authorization", "Bearer "+key)
 	req.Header.Add("x-devplan-cli-version", version.GetVersion())
-	if req.Method == "POST" {
-		req.Header.Add("Content-Type", "application/json")
-	}
-	return nil
 }
 
-func (c *Client) postParsed(path string, req proto.Message, msg proto.Message) error {
-	body, err := c.post(path, req)
+func (c *Client) postParsed(ctx context.Context, path string, req proto.Message, msg proto.Message) error {
+	body, err := c.post(ctx, path, req)
 	if err != nil {
 		return fmt.Errorf("failed to post response: %w", err)
 	}
@@ -0,0 +1,177 @@
+package devplan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Setenv("DEVPLAN_API_KEY", "test-key")
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(Config{BaseURL: srv.URL, Timeout: time.Second, Retry: fastRetry, Cache: apicache.New(t.TempDir())})
+}
+
+func TestClient_RetriesIdempotentOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	_, err := cl.GetSelf(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := cl.GetSelf(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, err := cl.GetDocument(context.Background(), 1, "missing")
+	require.Error(t, err)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_DoesNotRetryNonIdempotentPost(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := cl.SubmitWorklogItem(context.Background(), 1, nil)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first time.Time
+	var second time.Time
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		second = time.Now()
+		_, _ = w.Write([]byte(`{}`))
+	})
+	cl.retry.MaxDelay = 2 * time.Second
+
+	_, err := cl.GetSelf(context.Background())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, second.Sub(first), time.Second)
+}
+
+func TestClient_DoesNotWaitLongerThanMaxDelay(t *testing.T) {
+	var calls atomic.Int32
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	start := time.Now()
+	_, err := cl.GetSelf(context.Background())
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_CancelledContextAbortsRetries(t *testing.T) {
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	cl.retry.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := cl.GetSelf(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	assert.Equal(t, time.Duration(0), retryAfter(resp))
+
+	resp.Header.Set("Retry-After", "7")
+	assert.Equal(t, 7*time.Second, retryAfter(resp))
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.Greater(t, retryAfter(resp), 59*time.Minute)
+
+	resp.Header.Set("Retry-After", "garbage")
+	assert.Equal(t, time.Duration(0), retryAfter(resp))
+}
+
+func TestClient_UsesConfiguredAPIKey(t *testing.T) {
+	t.Setenv("DEVPLAN_API_KEY", "env-key")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer explicit-key", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	cl := NewClient(Config{BaseURL: srv.URL, APIKey: "explicit-key"})
+	_, err := cl.GetSelf(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, cl.cache)
+}
+
+func TestClient_RevokeAPIKey(t *testing.T) {
+	status := http.StatusNoContent
+	cl := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/v1/apikey/current", r.URL.Path)
+		w.WriteHeader(status)
+	})
+
+	require.NoError(t, cl.RevokeAPIKey(context.Background()))
+
+	status = http.StatusNotFound
+	assert.ErrorIs(t, cl.RevokeAPIKey(context.Background()), ErrRevokeUnsupported)
+
+	status = http.StatusUnauthorized
+	err := cl.RevokeAPIKey(context.Background())
+	assert.True(t, IsUnauthorized(err))
+	assert.NotErrorIs(t, err, ErrRevokeUnsupported)
+}
@@ -0,0 +1,204 @@
+package devplantest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/user"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/documents"
+	"github.com/opensdd/osdd-api/clients/go/osdd/recipes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const apiPath = "api/v1"
+
+// Fixtures holds the responses served by the fake API, keyed by request path relative to the API root,
+// e.g. "company/1/projects". Responses are JSON documents in the format of the Devplan API.
+type Fixtures struct {
+	mu        sync.RWMutex
+	responses map[string][]byte
+}
+
+// NewFixtures creates an empty set of fixtures
+func NewFixtures() *Fixtures {
+	return &Fixtures{responses: make(map[string][]byte)}
+}
+
+// LoadFixtures reads fixtures from a directory mirroring the API layout.
+// Every file <path>.json is served for GET /api/v1/<path>, e.g. company/1/projects.json
+// is served for GET /api/v1/company/1/projects.
+func LoadFixtures(dir string) (*Fixtures, error) {
+	f := NewFixtures()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		key := strings.TrimSuffix(filepath.ToSlash(rel), ".json")
+		if err := f.SetJSON(key, data); err != nil {
+			return fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fixtures from %s: %w", dir, err)
+	}
+	return f, nil
+}
+
+// Paths returns the sorted paths of all fixtures
+func (f *Fixtures) Paths() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	paths := make([]string, 0, len(f.responses))
+	for p := range f.responses {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Get returns the fixture stored for the path
+func (f *Fixtures) Get(path string) ([]byte, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	data, ok := f.responses[normalize(path)]
+	return data, ok
+}
+
+// SetJSON stores a raw JSON response for the path
+func (f *Fixtures) SetJSON(path string, data []byte) error {
+	if !json.Valid(data) {
+		return fmt.Errorf("response for %s is not valid JSON", path)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[normalize(path)] = data
+	return nil
+}
+
+// Set stores a response message for the path
+func (f *Fixtures) Set(path string, msg proto.Message) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal fixture for %s: %v", path, err))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[normalize(path)] = data
+}
+
+func (f *Fixtures) SetSelf(resp *user.GetSelfResponse) {
+	f.Set("user", resp)
+}
+
+func (f *Fixtures) SetProjects(companyID int32, resp *company.GetProjectsWithDocsResponse) {
+	f.Set(fmt.Sprintf("company/%v/projects", companyID), resp)
+}
+
+func (f *Fixtures) SetProjectDocuments(companyID int32, projectID string, resp *company.GetAllProjectDocsResponse) {
+	f.Set(fmt.Sprintf("company/%v/projects/%v/docs", companyID, projectID), resp)
+}
+
+// SetDocument stores the document under its id
+func (f *Fixtures) SetDocument(companyID int32, doc *documents.DocumentEntity) {
+	f.Set(fmt.Sprintf("company/%v/document/%v", companyID, doc.GetId()), company.GetDocResponse_builder{Document: doc}.Build())
+}
+
+func (f *Fixtures) SetTemplates(companyID int32, resp *company.GetTemplatesResponse) {
+	f.Set(fmt.Sprintf("company/%v/templates", companyID), resp)
+}
+
+func (f *Fixtures) SetGroup(companyID int32, groupID string, resp *company.GetGroupResponse) {
+	f.Set(fmt.Sprintf("company/%v/groups/%v", companyID, groupID), resp)
+}
+
+// SetIntegration stores properties of the integration, e.g. "github" or "bitbucket"
+func (f *Fixtures) SetIntegration(companyID int32, provider string, resp *company.GetIntegrationPropertiesResponse) {
+	f.Set(fmt.Sprintf("company/%v/integration/%v", companyID, provider), resp)
+}
+
+func (f *Fixtures) SetRepoSummaries(companyID int32, resp *company.GetRepoSummariesResponse) {
+	f.Set(fmt.Sprintf("company/%v/repo-summaries", companyID), resp)
+}
+
+func (f *Fixtures) SetDevRule(companyID int32, ruleName string, rule string) {
+	f.Set(fmt.Sprintf("company/%v/dev/rule/%v", companyID, ruleName), company.GetDevRuleResponse_builder{Rule: rule}.Build())
+}
+
+func (f *Fixtures) SetIDERecipe(companyID int32, recipe *recipes.Recipe) {
+	f.Set(fmt.Sprintf("company/%v/dev/ide", companyID), company.GetDevRecipeResponse_builder{JsonRecipe: mustJSON(recipe)}.Build())
+}
+
+func (f *Fixtures) SetTaskRecipe(companyID int32, taskID string, recipe *recipes.Recipe) {
+	f.Set(fmt.Sprintf("company/%v/dev/task/%v", companyID, taskID), company.GetTaskRecipeResponse_builder{JsonRecipe: mustJSON(recipe)}.Build())
+}
+
+func (f *Fixtures) SetTaskExecRecipe(companyID int32, taskID string, recipe *recipes.ExecutableRecipe) {
+	f.Set(fmt.Sprintf("company/%v/dev/task/%v/executable", companyID, taskID), company.GetTaskRecipeResponse_builder{JsonRecipe: mustJSON(recipe)}.Build())
+}
+
+func (f *Fixtures) SetFeatureExecRecipe(companyID int32, featureID string, recipe *recipes.ExecutableRecipe) {
+	f.Set(fmt.Sprintf("company/%v/dev/user-story/%v/executable", companyID, featureID), company.GetUserStoryRecipeResponse_builder{JsonRecipe: mustJSON(recipe)}.Build())
+}
+
+// SetTaskSpecs stores the specs of a task. Specs uploaded to the fake server are added to them.
+func (f *Fixtures) SetTaskSpecs(companyID int32, taskID string, resp *company.GetTaskSpecsResponse) {
+	f.Set(taskSpecsPath(companyID, taskID), resp)
+}
+
+// SetFeatureSpecs stores the specs of a feature. Specs uploaded to the fake server are added to them.
+func (f *Fixtures) SetFeatureSpecs(companyID int32, featureID string, resp *company.GetTaskSpecsResponse) {
+	f.Set(featureSpecsPath(companyID, featureID), resp)
+}
+
+// SetTaskSpec stores a spec of a task with its content. Only SetTaskSpecs lists it.
+func (f *Fixtures) SetTaskSpec(companyID int32, taskID string, spec *company.UploadSpecRequest) {
+	f.Set(taskSpecsPath(companyID, taskID)+"/"+spec.GetName(), spec)
+}
+
+// SetFeatureSpec stores a spec of a feature with its content. Only SetFeatureSpecs lists it.
+func (f *Fixtures) SetFeatureSpec(companyID int32, featureID string, spec *company.UploadSpecRequest) {
+	f.Set(featureSpecsPath(companyID, featureID)+"/"+spec.GetName(), spec)
+}
+
+func taskSpecsPath(companyID int32, taskID string) string {
+	return fmt.Sprintf("company/%v/dev/task/%v/specs", companyID, taskID)
+}
+
+func featureSpecsPath(companyID int32, featureID string) string {
+	return fmt.Sprintf("company/%v/dev/user-story/%v/specs", companyID, featureID)
+}
+
+// normalize turns a request path or a fixture path into a fixture key
+func normalize(path string) string {
+	path = strings.Trim(path, "/")
+	path = strings.TrimPrefix(path, apiPath+"/")
+	return path
+}
+
+func mustJSON(msg proto.Message) string {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %T: %v", msg, err))
+	}
+	return string(data)
+}
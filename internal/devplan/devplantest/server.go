@@ -0,0 +1,281 @@
+// Package devplantest provides an in-process fake of the Devplan API for tests and local development.
+package devplantest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/artifacts"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// DefaultAPIKey is issued by the fake auth flow when Handler.APIKey is not set
+const DefaultAPIKey = "devplantest-api-key"
+
+// Request is a request received by the fake API
+type Request struct {
+	Method string
+	// Path is the request path relative to the API root, e.g. "company/1/projects"
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Handler serves the Devplan API from fixtures and records the requests it receives.
+// Worklog items and task spec uploads are accepted and kept in memory, so later reads observe them.
+type Handler struct {
+	Fixtures *Fixtures
+	// APIKey is the only accepted bearer token. Any token is accepted when empty.
+	APIKey string
+
+	mux      *http.ServeMux
+	mu       sync.Mutex
+	requests []Request
+	worklog  map[int32][]*worklog.WorkLogItem
+	// worklogKeys maps idempotency keys of submitted worklog items to their IDs
+	worklogKeys map[string]string
+	revoked     map[string]bool
+}
+
+// NewHandler creates a handler serving the fixtures
+func NewHandler(fixtures *Fixtures) *Handler {
+	if fixtures == nil {
+		fixtures = NewFixtures()
+	}
+	h := &Handler{
+		Fixtures:    fixtures,
+		mux:         http.NewServeMux(),
+		worklog:     make(map[int32][]*worklog.WorkLogItem),
+		worklogKeys: make(map[string]string),
+		revoked:     make(map[string]bool),
+	}
+	h.mux.HandleFunc("POST /api/v1/apikey/request", h.requestAPIKey)
+	h.mux.HandleFunc("GET /api/v1/apikey/request/{requestID}", h.approveAPIKey)
+	h.mux.HandleFunc("DELETE /api/v1/apikey/current", h.authorized(h.revokeAPIKey))
+	h.mux.HandleFunc("POST /api/v1/company/{companyID}/worklog/submit", h.authorized(h.submitWorklog))
+	h.mux.HandleFunc("POST /api/v1/company/{companyID}/dev/task/{taskID}/specs", h.authorized(h.uploadSpec))
+	h.mux.HandleFunc("POST /api/v1/company/{companyID}/dev/user-story/{featureID}/specs", h.authorized(h.uploadSpec))
+	h.mux.HandleFunc("GET /api/v1/", h.authorized(h.serveFixture))
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	h.mu.Lock()
+	h.requests = append(h.requests, Request{
+		Method: r.Method,
+		Path:   normalize(r.URL.Path),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	h.mu.Unlock()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	h.mux.ServeHTTP(w, r)
+}
+
+// Requests returns all requests received so far
+func (h *Handler) Requests() []Request {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Request(nil), h.requests...)
+}
+
+// RequestsTo returns the requests received for the path, relative to the API root
+func (h *Handler) RequestsTo(method, path string) []Request {
+	var result []Request
+	for _, r := range h.Requests() {
+		if r.Method == method && r.Path == normalize(path) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Worklog returns the worklog items submitted for the company
+func (h *Handler) Worklog(companyID int32) []*worklog.WorkLogItem {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*worklog.WorkLogItem(nil), h.worklog[companyID]...)
+}
+
+func (h *Handler) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		h.mu.Lock()
+		revoked := h.revoked[token]
+		h.mu.Unlock()
+		if !ok || revoked || (h.APIKey != "" && token != h.APIKey) {
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) serveFixture(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.Fixtures.Get(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no fixture for %s", normalize(r.URL.Path)))
+		return
+	}
+	hash := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(hash[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (h *Handler) submitWorklog(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := parseCompanyID(w, r)
+	if !ok {
+		return
+	}
+	req := &company.SubmitWorkLogRequest{}
+	if !readMessage(w, r, req) {
+		return
+	}
+	h.mu.Lock()
+	key := r.Header.Get(devplan.IdempotencyKeyHeader)
+	id, seen := h.worklogKeys[key]
+	if !seen || key == "" {
+		h.worklog[companyID] = append(h.worklog[companyID], req.GetItem())
+		id = fmt.Sprintf("worklog-%d", len(h.worklog[companyID]))
+		if key != "" {
+			h.worklogKeys[key] = id
+		}
+	}
+	h.mu.Unlock()
+	writeMessage(w, company.SubmitWorkLogResponse_builder{Id: id}.Build())
+}
+
+func (h *Handler) uploadSpec(w http.ResponseWriter, r *http.Request) {
+	companyID, ok := parseCompanyID(w, r)
+	if !ok {
+		return
+	}
+	req := &company.UploadSpecRequest{}
+	if !readMessage(w, r, req) {
+		return
+	}
+	path := taskSpecsPath(companyID, r.PathValue("taskID"))
+	if featureID := r.PathValue("featureID"); featureID != "" {
+		path = featureSpecsPath(companyID, featureID)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	specs := &company.GetTaskSpecsResponse{}
+	if data, ok := h.Fixtures.Get(path); ok {
+		if err := protojson.Unmarshal(data, specs); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("invalid specs fixture: %v", err))
+			return
+		}
+	}
+	var existing *artifacts.SpecDetails
+	for _, s := range specs.GetSpecs() {
+		if s.GetName() == req.GetName() {
+			existing = s
+			break
+		}
+	}
+	if existing == nil {
+		existing = &artifacts.SpecDetails{}
+		existing.SetName(req.GetName())
+		specs.SetSpecs(append(specs.GetSpecs(), existing))
+	}
+	existing.SetChecksum(req.GetChecksum())
+	h.Fixtures.Set(path, specs)
+	// The uploaded spec is served with its content by GET <specs path>/<name>
+	h.Fixtures.Set(path+"/"+req.GetName(), req)
+	writeJSON(w, map[string]any{})
+}
+
+// requestAPIKey starts the login flow of `devplan auth`
+func (h *Handler) requestAPIKey(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{"requestID": "devplantest"})
+}
+
+// approveAPIKey completes the login flow right away
+func (h *Handler) approveAPIKey(w http.ResponseWriter, _ *http.Request) {
+	key := h.APIKey
+	if key == "" {
+		key = DefaultAPIKey
+	}
+	writeJSON(w, map[string]string{"apiKey": key})
+}
+
+// revokeAPIKey rejects the bearer token of the request from now on
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+	h.mu.Lock()
+	h.revoked[token] = true
+	h.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Server is a fake Devplan API listening on a local port
+type Server struct {
+	*Handler
+	URL string
+
+	t testing.TB
+}
+
+// NewServer starts a fake API serving the fixtures. The server is closed when the test finishes.
+func NewServer(t testing.TB, fixtures *Fixtures) *Server {
+	t.Helper()
+	h := NewHandler(fixtures)
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &Server{Handler: h, URL: srv.URL, t: t}
+}
+
+// Client returns a client of the fake API with an isolated cache.
+// It sets DEVPLAN_API_KEY for the test, so it can't be used in parallel tests.
+func (s *Server) Client() *devplan.Client {
+	key := s.APIKey
+	if key == "" {
+		key = DefaultAPIKey
+	}
+	s.t.Setenv("DEVPLAN_API_KEY", key)
+	return devplan.NewClient(devplan.Config{
+		BaseURL: s.URL,
+		Retry:   &devplan.RetryPolicy{MaxAttempts: 1},
+		Cache:   apicache.New(s.t.TempDir()),
+	})
+}
+
+func parseCompanyID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue("companyID"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid company id")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
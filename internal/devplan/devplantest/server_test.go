@@ -0,0 +1,200 @@
+package devplantest
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	t.Parallel()
+	fixtures, err := LoadFixtures("testdata/fixtures")
+	require.NoError(t, err)
+	assert.Contains(t, fixtures.Paths(), "company/1/projects")
+	assert.Contains(t, fixtures.Paths(), "company/1/projects/p1/docs")
+	assert.Contains(t, fixtures.Paths(), "user")
+
+	_, ok := fixtures.Get("/api/v1/company/1/document/t1")
+	assert.True(t, ok)
+}
+
+func TestFixtures_Errors(t *testing.T) {
+	t.Parallel()
+	assert.Error(t, NewFixtures().SetJSON("user", []byte("{")))
+
+	_, err := LoadFixtures(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestServer_ServesFixtures(t *testing.T) {
+	fixtures, err := LoadFixtures("testdata/fixtures")
+	require.NoError(t, err)
+	srv := NewServer(t, fixtures)
+	cl := srv.Client()
+	ctx := context.Background()
+
+	self, err := cl.GetSelf(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "dev@example.com", self.GetOwnInfo().GetUser().GetEmail())
+
+	projects, err := cl.GetCompanyProjects(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, projects.GetProjects(), 1)
+	assert.Equal(t, "Checkout redesign", projects.GetProjects()[0].GetProject().GetTitle())
+
+	doc, err := cl.GetDocument(ctx, 1, "t1")
+	require.NoError(t, err)
+	assert.Equal(t, "Add payment method picker", doc.GetDocument().GetTitle())
+
+	repos, err := cl.GetAllRepos(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, "acme/storefront", repos[0].GetFullName())
+
+	rule, err := cl.GetDevRule(ctx, 1, "general")
+	require.NoError(t, err)
+	assert.NotEmpty(t, rule.GetRule())
+
+	requests := srv.RequestsTo(http.MethodGet, "company/1/projects")
+	require.Len(t, requests, 1)
+	assert.Equal(t, "Bearer "+DefaultAPIKey, requests[0].Header.Get("Authorization"))
+}
+
+func TestServer_MissingFixture(t *testing.T) {
+	srv := NewServer(t, nil)
+	_, err := srv.Client().GetDocument(context.Background(), 1, "unknown")
+	require.Error(t, err)
+	assert.True(t, devplan.IsNotFound(err))
+}
+
+func TestServer_RejectsWrongAPIKey(t *testing.T) {
+	fixtures := NewFixtures()
+	fixtures.SetDevRule(1, "general", "rule")
+	srv := NewServer(t, fixtures)
+	srv.APIKey = "expected"
+
+	t.Setenv("DEVPLAN_API_KEY", "other")
+	cl := devplan.NewClient(devplan.Config{BaseURL: srv.URL, Cache: apicache.New(t.TempDir())})
+	_, err := cl.GetDevRule(context.Background(), 1, "general")
+	require.Error(t, err)
+	assert.True(t, devplan.IsUnauthorized(err))
+
+	resp, err := srv.Client().GetDevRule(context.Background(), 1, "general")
+	require.NoError(t, err)
+	assert.Equal(t, "rule", resp.GetRule())
+}
+
+func TestServer_RevokesAPIKey(t *testing.T) {
+	fixtures := NewFixtures()
+	fixtures.SetDevRule(1, "general", "rule")
+	srv := NewServer(t, fixtures)
+
+	cl := srv.Client()
+	require.NoError(t, cl.RevokeAPIKey(context.Background()))
+	_, err := cl.GetDevRule(context.Background(), 1, "general")
+	assert.True(t, devplan.IsUnauthorized(err))
+}
+
+func TestServer_RecordsWorklog(t *testing.T) {
+	srv := NewServer(t, nil)
+	item := worklog.WorkLogItem_builder{
+		Message: "Implemented the picker",
+		TaskId:  proto.String("t1"),
+		Type:    worklog.WorkLogType_CODING,
+	}.Build()
+
+	resp, err := srv.Client().SubmitWorklogItem(context.Background(), 1, item)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GetId())
+
+	items := srv.Worklog(1)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Implemented the picker", items[0].GetMessage())
+	assert.Equal(t, "t1", items[0].GetTaskId())
+	assert.Empty(t, srv.Worklog(2))
+}
+
+func TestServer_StoresUploadedSpecs(t *testing.T) {
+	srv := NewServer(t, nil)
+	cl := srv.Client()
+	ctx := context.Background()
+
+	for _, checksum := range []string{"v1", "v2"} {
+		req := company.UploadSpecRequest_builder{Name: "plan.md", Content: "# Plan", Checksum: checksum}.Build()
+		require.NoError(t, cl.UploadTaskSpec(ctx, 1, "t1", req))
+	}
+
+	specs, err := cl.GetTaskSpecs(ctx, 1, "t1")
+	require.NoError(t, err)
+	require.Len(t, specs.GetSpecs(), 1)
+	assert.Equal(t, "plan.md", specs.GetSpecs()[0].GetName())
+	assert.Equal(t, "v2", specs.GetSpecs()[0].GetChecksum())
+	assert.Len(t, srv.RequestsTo(http.MethodPost, "company/1/dev/task/t1/specs"), 2)
+}
+
+func TestServer_StoresUploadedFeatureSpecs(t *testing.T) {
+	srv := NewServer(t, nil)
+	cl := srv.Client()
+	ctx := context.Background()
+
+	req := company.UploadSpecRequest_builder{Name: "design.md", Content: "# Design", Checksum: "v1"}.Build()
+	require.NoError(t, cl.UploadFeatureSpec(ctx, 1, "f1", req))
+
+	specs, err := cl.GetFeatureSpecs(ctx, 1, "f1")
+	require.NoError(t, err)
+	require.Len(t, specs.GetSpecs(), 1)
+	assert.Equal(t, "design.md", specs.GetSpecs()[0].GetName())
+	spec, err := cl.GetFeatureSpec(ctx, 1, "f1", "design.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Design", spec.GetContent())
+	assert.Equal(t, "v1", spec.GetChecksum())
+	_, err = cl.GetTaskSpecs(ctx, 1, "f1")
+	assert.Error(t, err, "feature and task specs are kept apart")
+}
+
+func TestServer_RevalidatesWithETag(t *testing.T) {
+	fixtures := NewFixtures()
+	fixtures.SetDevRule(1, "general", "rule")
+	srv := NewServer(t, fixtures)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/company/1/dev/rule/general", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer key")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
+
+func TestServer_DeduplicatesWorklogByIdempotencyKey(t *testing.T) {
+	srv := NewServer(t, nil)
+	cl := srv.Client()
+	item := worklog.WorkLogItem_builder{Message: "Implemented the picker"}.Build()
+
+	first, err := cl.SubmitWorklogItemWithKey(context.Background(), 1, item, "key-1")
+	require.NoError(t, err)
+	second, err := cl.SubmitWorklogItemWithKey(context.Background(), 1, item, "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.GetId(), second.GetId())
+	assert.Len(t, srv.Worklog(1), 1)
+
+	_, err = cl.SubmitWorklogItemWithKey(context.Background(), 1, item, "key-2")
+	require.NoError(t, err)
+	assert.Len(t, srv.Worklog(1), 2)
+}
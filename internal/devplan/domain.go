@@ -1,13 +1,18 @@
 package devplan
 
-// GetBaseURL returns the base URL for API calls based on the domain flag
+import "strings"
+
+// GetBaseURL returns the base URL for API calls based on the domain flag.
+// A full URL, e.g. of a mock server, is used as is.
 func GetBaseURL(domain string) string {
 	switch domain {
 	case "beta":
 		return "https://beta.devplan.com"
 	case "local":
 		return "http://localhost:3000"
-	default:
-		return "https://app.devplan.com"
 	}
+	if strings.HasPrefix(domain, "http://") || strings.HasPrefix(domain, "https://") {
+		return strings.TrimSuffix(domain, "/")
+	}
+	return "https://app.devplan.com"
 }
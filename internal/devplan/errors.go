@@ -0,0 +1,125 @@
+package devplan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const maxErrorMessageLen = 300
+
+// ErrRevokeUnsupported is returned when the server has no endpoint to revoke API keys
+var ErrRevokeUnsupported = errors.New("revoking API keys is not supported by the server")
+
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "X-Amzn-Trace-Id"}
+
+// APIError is returned by Client methods when the Devplan API responds with a non-success status
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message is the error message decoded from the response body, if the server provided one
+	Message string
+	// RequestID identifies the request in server logs, if the server provided one
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	result := fmt.Sprintf("%s %s failed [%d]: %s", e.Method, e.Path, e.StatusCode, msg)
+	if e.RequestID != "" {
+		result += fmt.Sprintf(" (request id: %s)", e.RequestID)
+	}
+	return result
+}
+
+// AsAPIError extracts an APIError from the error chain
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// StatusCode returns the HTTP status of an API error or 0 if err is not an APIError
+func StatusCode(err error) int {
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsUnauthorized reports whether the API rejected the credentials
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether the user has no access to the requested resource, e.g. another company
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
+
+// IsNotFound reports whether the requested resource does not exist
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsServerError reports whether the API failed on its side
+func IsServerError(err error) bool {
+	return StatusCode(err) >= http.StatusInternalServerError
+}
+
+func newAPIError(method, path string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Message:    decodeErrorMessage(body),
+	}
+	for _, h := range requestIDHeaders {
+		if id := resp.Header.Get(h); id != "" {
+			apiErr.RequestID = id
+			break
+		}
+	}
+	return apiErr
+}
+
+// decodeErrorMessage extracts a human-readable message from an error response body.
+// JSON bodies are checked for the common message fields, plain text is used as is and HTML pages are dropped.
+func decodeErrorMessage(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if text == "" || strings.HasPrefix(text, "<") {
+		return ""
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err == nil {
+		for _, key := range []string{"message", "error", "errorMessage", "detail"} {
+			switch v := payload[key].(type) {
+			case string:
+				if v != "" {
+					return truncate(v)
+				}
+			case map[string]any:
+				if msg, ok := v["message"].(string); ok && msg != "" {
+					return truncate(msg)
+				}
+			}
+		}
+		return ""
+	}
+	return truncate(text)
+}
+
+func truncate(msg string) string {
+	if len(msg) <= maxErrorMessageLen {
+		return msg
+	}
+	return msg[:maxErrorMessageLen] + "..."
+}
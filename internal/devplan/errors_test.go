@@ -0,0 +1,65 @@
+package devplan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeErrorMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "empty", body: "", expected: ""},
+		{name: "json message", body: `{"message":"task not found"}`, expected: "task not found"},
+		{name: "json error", body: `{"error":"forbidden for this company"}`, expected: "forbidden for this company"},
+		{name: "nested json error", body: `{"error":{"message":"invalid key"}}`, expected: "invalid key"},
+		{name: "json without message", body: `{"code":5}`, expected: ""},
+		{name: "plain text", body: "  upstream unavailable\n", expected: "upstream unavailable"},
+		{name: "html page", body: "<html><body>502</body></html>", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, decodeErrorMessage([]byte(tt.body)))
+		})
+	}
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	wrap := func(code int) error {
+		return fmt.Errorf("failed to get response: %w", &APIError{StatusCode: code})
+	}
+	assert.True(t, IsUnauthorized(wrap(http.StatusUnauthorized)))
+	assert.True(t, IsForbidden(wrap(http.StatusForbidden)))
+	assert.True(t, IsNotFound(wrap(http.StatusNotFound)))
+	assert.True(t, IsServerError(wrap(http.StatusBadGateway)))
+	assert.False(t, IsNotFound(wrap(http.StatusUnauthorized)))
+	assert.False(t, IsServerError(fmt.Errorf("connection refused")))
+	assert.Equal(t, 0, StatusCode(nil))
+}
+
+func TestClient_ReturnsAPIError(t *testing.T) {
+	cl := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req-42")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"document not found"}`))
+	})
+
+	_, err := cl.GetDocument(context.Background(), 7, "doc-1")
+	require.Error(t, err)
+	apiErr, ok := AsAPIError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.MethodGet, apiErr.Method)
+	assert.Equal(t, "api/v1/company/7/document/doc-1", apiErr.Path)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "document not found", apiErr.Message)
+	assert.Equal(t, "req-42", apiErr.RequestID)
+	assert.Contains(t, err.Error(), "document not found")
+	assert.Contains(t, err.Error(), "req-42")
+}
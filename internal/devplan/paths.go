@@ -2,10 +2,12 @@ package devplan
 
 import (
 	"fmt"
+	"net/url"
 )
 
 const apiPath = "api/v1"
 const selfPath = apiPath + "/user"
+const currentAPIKeyPath = apiPath + "/apikey/current"
 
 func companyPath(companyID int32) string {
 	return fmt.Sprintf("%v/company/%v", apiPath, companyID)
@@ -67,6 +69,15 @@ func taskSpecsPath(companyID int32, taskID string) string {
 	return fmt.Sprintf("%v/specs", devTaskPath(companyID, taskID))
 }
 
+func featureSpecsPath(companyID int32, featureID string) string {
+	return fmt.Sprintf("%v/dev/user-story/%v/specs", companyPath(companyID), featureID)
+}
+
+// specPath returns the path of a single spec under the specs path of a task or feature
+func specPath(specsPath, name string) string {
+	return fmt.Sprintf("%v/%v", specsPath, url.PathEscape(name))
+}
+
 func devFeatureExecRecipePath(companyID int32, featureID string) string {
 	return fmt.Sprintf("%v/dev/user-story/%v/executable", companyPath(companyID), featureID)
 }
@@ -0,0 +1,74 @@
+package devplan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is the number of requests per second sent to a single API host
+const DefaultRateLimit = 10
+
+var (
+	hostLimitersMu sync.Mutex
+	hostLimiters   = map[string]*rateLimiter{}
+)
+
+// limiterFor returns the limiter shared by all clients talking to the host
+func limiterFor(host string, rate float64) *rateLimiter {
+	hostLimitersMu.Lock()
+	defer hostLimitersMu.Unlock()
+	l, ok := hostLimiters[host]
+	if !ok || l.rate != rate {
+		l = newRateLimiter(rate)
+		hostLimiters[host] = l
+	}
+	return l
+}
+
+// rateLimiter is a token bucket allowing bursts of up to rate requests
+type rateLimiter struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, or returns how long to wait for the next one
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
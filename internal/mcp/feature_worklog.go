@@ -4,7 +4,7 @@ import (
 	"context"
 	"log/slog"
 
-	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
 	"github.com/devplaninc/devplan-cli/internal/utils/recentactivity"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -12,8 +12,8 @@ import (
 
 type FeatureWorkLogReportInput struct {
 	Message           string `json:"message" jsonschema:"message describing what happened"`
-	FeatureID         string `json:"featureId,omitempty" jsonschema:"optional feature identifier"`
-	CompanyID         int32  `json:"companyId,omitempty" jsonschema:"required company identifier"`
+	FeatureID         string `json:"featureId,omitempty" jsonschema:"optional feature identifier, defaults to the feature of the workspace"`
+	CompanyID         int32  `json:"companyId,omitempty" jsonschema:"company identifier, defaults to the company of the workspace"`
 	Type              string `json:"type,omitempty" jsonschema:"optional worklog type. If present should be one of 'full_workflow', 'research', 'planning', 'coding', 'review', 'address_review', 'analysis', 'commit', 'finalize'"`
 	Stage             string `json:"stage,omitempty" jsonschema:"optional worklog stage. If present should be one of 'started', 'running', 'ended', 'error'"`
 	ActionDescription string `json:"actionDescription,omitempty" jsonschema:"description of the action. Should be a 1-2 words description of what this work log is about"`
@@ -23,8 +23,16 @@ type FeatureWorkLogReportInput struct {
 type FeatureWorkLogReportOutput struct {
 }
 
-func (s *Server) reportFeatureWorkLog(_ context.Context, _ *mcp.CallToolRequest, input FeatureWorkLogReportInput) (*mcp.CallToolResult, FeatureWorkLogReportOutput, error) {
-	cl := devplan.NewClient(devplan.Config{})
+func (s *Server) reportFeatureWorkLog(ctx context.Context, req *mcp.CallToolRequest, input FeatureWorkLogReportInput) (*mcp.CallToolResult, FeatureWorkLogReportOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, FeatureWorkLogReportOutput{}, err
+	}
+	featureID, err := ws.matchWorkspace(input.FeatureID, ws.FeatureID, "featureId")
+	if err != nil {
+		return nil, FeatureWorkLogReportOutput{}, err
+	}
 	wlType := getWorkloadType(input.Type)
 	customType := ""
 	if wlType == worklog.WorkLogType_WORK_LOG_TYPE_UNSPECIFIED {
@@ -32,24 +40,29 @@ func (s *Server) reportFeatureWorkLog(_ context.Context, _ *mcp.CallToolRequest,
 	}
 	item := worklog.WorkLogItem_builder{
 		Message:           input.Message,
-		CompanyId:         &input.CompanyID,
-		FeatureId:         &input.FeatureID,
+		CompanyId:         &companyID,
+		FeatureId:         &featureID,
 		Type:              wlType,
 		CustomType:        customType,
 		Stage:             input.Stage,
 		ActionDescription: input.ActionDescription,
 		AgentName:         input.AgentName,
 	}.Build()
-	_, err := cl.SubmitWorklogItem(input.CompanyID, item)
+	result, err := s.submitWorklog(ctx, companyID, item)
 	slog.Info("Reporting feature worklog item", "item", item)
 
 	// Record feature activity using the story ID (feature ID) so the feature
 	// workspace sorts to the top in list/switch/clean.
-	if input.FeatureID != "" {
-		if raErr := recentactivity.RecordTaskActivity(input.FeatureID, "worklog"); raErr != nil {
-			slog.Debug("Failed to record recent feature activity", "featureID", input.FeatureID, "err", raErr)
+	if featureID != "" {
+		// Like task worklogs, the first report starts syncing the feature specs. Failures don't fail the report.
+		_, _ = s.addSyncer(ctx, ws, companyID, specsync.FeatureTarget(featureID))
+		if raErr := recentactivity.RecordTaskActivity(featureID, "worklog"); raErr != nil {
+			slog.Debug("Failed to record recent feature activity", "featureID", featureID, "err", raErr)
 		}
 	}
 
-	return nil, FeatureWorkLogReportOutput{}, err
+	if err != nil {
+		return nil, FeatureWorkLogReportOutput{}, err
+	}
+	return result, FeatureWorkLogReportOutput{}, nil
 }
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// HTTPPath is the endpoint of the streamable HTTP transport
+	HTTPPath = "/mcp"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// NewToken generates a random bearer token for an HTTP server run
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Handler serves the MCP server over streamable HTTP to clients presenting the bearer token.
+// All sessions share the server, so its API client and spec syncers are shared by every connected agent.
+func (s *Server) Handler(token string) http.Handler {
+	streamable := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return s.srv }, &mcp.StreamableHTTPOptions{
+		Logger: slog.Default(),
+	})
+	mux := http.NewServeMux()
+	mux.Handle(HTTPPath, auth.RequireBearerToken(tokenVerifier(token), nil)(streamable))
+	return mux
+}
+
+func tokenVerifier(token string) auth.TokenVerifier {
+	return func(_ context.Context, presented string, _ *http.Request) (*auth.TokenInfo, error) {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			return nil, auth.ErrInvalidToken
+		}
+		// The token is valid for the whole run, the SDK requires an expiration
+		return &auth.TokenInfo{UserID: "devplan", Expiration: time.Now().Add(time.Hour)}, nil
+	}
+}
+
+// RunHTTP serves clients over streamable HTTP on the listener until ctx is cancelled
+func (s *Server) RunHTTP(ctx context.Context, listener net.Listener, token string) error {
+	if token == "" {
+		return fmt.Errorf("a bearer token is required")
+	}
+	// The agents work in different workspaces, so the workspace of a session is the root shared by its client
+	// rather than the working directory of the server
+	s.sessionRoots = true
+	s.start(ctx)
+	defer s.stopped()
+
+	httpServer := &http.Server{Handler: s.Handler(token), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("MCP server: failed to shut down HTTP server", "err", err)
+		}
+	}()
+	slog.Info("MCP Server: serving HTTP", "addr", listener.Addr().String())
+	err := httpServer.Serve(listener)
+	s.shutdown()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// IsLoopback reports whether the listen address only accepts local connections
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
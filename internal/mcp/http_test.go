@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bearerTransport struct {
+	token string
+}
+
+func (b bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// startHTTPServer serves a test server over HTTP and returns its endpoint
+func startHTTPServer(t *testing.T, token string) (string, *Server) {
+	t.Helper()
+	server, _ := newTestServer(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.RunHTTP(ctx, listener, token) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Error("HTTP server did not stop")
+		}
+	})
+	return "http://" + listener.Addr().String() + HTTPPath, server
+}
+
+// connectHTTP connects a client sharing the given workspace roots
+func connectHTTP(t *testing.T, endpoint, token string, roots ...string) (*mcp.ClientSession, error) {
+	t.Helper()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, nil)
+	for _, root := range roots {
+		client.AddRoots(&mcp.Root{URI: "file://" + filepath.ToSlash(root)})
+	}
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Transport: bearerTransport{token: token}},
+	}, nil)
+	if err == nil {
+		t.Cleanup(func() { _ = session.Close() })
+	}
+	return session, err
+}
+
+func TestRunHTTP_SessionsShareServer(t *testing.T) {
+	endpoint, server := startHTTPServer(t, "secret")
+
+	first, err := connectHTTP(t, endpoint, "secret", server.dir)
+	require.NoError(t, err)
+	second, err := connectHTTP(t, endpoint, "secret", t.TempDir(), server.dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID(), second.ID())
+
+	for _, session := range []*mcp.ClientSession{first, second} {
+		_, doc := callTool[Document](t, session, "getDocument", nil)
+		assert.Equal(t, "t1", doc.ID)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.NotNil(t, server.client, "sessions use the API client of the server")
+}
+
+func TestRunHTTP_SessionWithoutRootInfersNothing(t *testing.T) {
+	// The working directory of the server is a task workspace, which must not be used for HTTP sessions
+	endpoint, _ := startHTTPServer(t, "secret")
+	session, err := connectHTTP(t, endpoint, "secret")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"getDocument", nil, "companyId is required"},
+		{"startSpecSync", map[string]any{"companyId": 1, "taskId": "t1"}, "workspace directory is unknown"},
+	} {
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: tc.tool, Arguments: tc.args})
+		require.NoError(t, err)
+		assert.True(t, res.IsError, tc.want)
+		assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, tc.want)
+	}
+
+	_, doc := callTool[Document](t, session, "getDocument", map[string]any{"companyId": 1, "documentId": "t1"})
+	assert.Equal(t, "t1", doc.ID, "explicit IDs work without a workspace")
+}
+
+func TestRunHTTP_RequiresToken(t *testing.T) {
+	endpoint, _ := startHTTPServer(t, "secret")
+
+	_, err := connectHTTP(t, endpoint, "wrong")
+	assert.Error(t, err)
+
+	resp, err := http.Post(endpoint, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestRunHTTP_RejectsEmptyToken(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	assert.Error(t, NewServer().RunHTTP(context.Background(), listener, ""))
+}
+
+func TestIsLoopback(t *testing.T) {
+	assert.True(t, IsLoopback("127.0.0.1:8765"))
+	assert.True(t, IsLoopback("localhost:8765"))
+	assert.True(t, IsLoopback("[::1]:8765"))
+	assert.False(t, IsLoopback("0.0.0.0:8765"))
+	assert.False(t, IsLoopback(":8765"))
+	assert.False(t, IsLoopback("192.168.1.10:8765"))
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/outbox"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// outboxFlushInterval is how often queued worklog items are retried while the server runs
+const outboxFlushInterval = time.Minute
+
+// worklogOutbox returns the queue of worklog items that could not be submitted, or nil if it is unavailable
+func (s *Server) worklogOutbox() *outbox.Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.outbox == nil {
+		store, err := outbox.Default()
+		if err != nil {
+			slog.Warn("Worklog queue is unavailable", "err", err)
+			return nil
+		}
+		s.outbox = store
+	}
+	return s.outbox
+}
+
+// submitWorklog queues the item after earlier items that could not be submitted and flushes the queue.
+// If Devplan can't be reached, the item stays queued and the returned result tells the agent so.
+func (s *Server) submitWorklog(ctx context.Context, companyID int32, item *worklog.WorkLogItem) (*mcp.CallToolResult, error) {
+	cl := s.apiClient()
+	store := s.worklogOutbox()
+	if store == nil {
+		_, err := cl.SubmitWorklogItem(ctx, companyID, item)
+		return nil, toolError(err)
+	}
+	queued, err := store.Add(companyID, item)
+	if err != nil {
+		slog.Warn("Failed to queue worklog item, submitting it directly", "err", err)
+		_, err := cl.SubmitWorklogItem(ctx, companyID, item)
+		return nil, toolError(err)
+	}
+	result, err := store.Flush(ctx, cl, outbox.FlushOptions{IgnoreBackoff: true})
+	if err != nil && !errors.Is(err, outbox.ErrFlushInProgress) {
+		return nil, err
+	}
+	if slices.Contains(result.SentIDs, queued.ID) {
+		return nil, nil
+	}
+	if rejection, ok := result.Rejections[queued.ID]; ok {
+		// The item is invalid, so it is reported to the agent instead of being kept
+		if _, err := store.Drop(queued.ID); err != nil {
+			slog.Warn("Failed to drop rejected worklog item", "id", queued.ID, "err", err)
+		}
+		return nil, toolError(rejection)
+	}
+	reason := "another devplan process is submitting queued entries"
+	if result.Err != nil {
+		reason = toolError(result.Err).Error()
+	}
+	slog.Info("Worklog item queued", "id", queued.ID, "pending", result.Pending, "reason", reason)
+	text := fmt.Sprintf("The worklog entry was queued and will be submitted automatically once Devplan is reachable (%d entries pending): %s", result.Pending, reason)
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil
+}
+
+// runOutbox retries queued worklog items until ctx is cancelled
+func (s *Server) runOutbox(ctx context.Context) {
+	ticker := time.NewTicker(outboxFlushInterval)
+	defer ticker.Stop()
+	for {
+		s.flushOutbox(ctx, outbox.FlushOptions{})
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) flushOutbox(ctx context.Context, opts outbox.FlushOptions) {
+	store := s.worklogOutbox()
+	if store == nil {
+		return
+	}
+	if items, err := store.List(); err != nil || len(items) == 0 {
+		return
+	}
+	result, err := store.Flush(ctx, s.apiClient(), opts)
+	if err != nil {
+		slog.Debug("Failed to flush worklog queue", "err", err)
+		return
+	}
+	if len(result.SentIDs) > 0 || result.Err != nil {
+		slog.Info("Flushed worklog queue", "sent", len(result.SentIDs), "pending", result.Pending, "rejected", result.Rejected, "err", result.Err)
+	}
+}
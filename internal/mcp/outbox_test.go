@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/devplan-cli/internal/utils/outbox"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachableClient returns a client of an API that refuses connections
+func unreachableClient(t *testing.T) *devplan.Client {
+	return devplan.NewClient(devplan.Config{
+		BaseURL: "http://127.0.0.1:1",
+		APIKey:  "key",
+		Retry:   &devplan.RetryPolicy{MaxAttempts: 1},
+		Cache:   apicache.New(t.TempDir()),
+	})
+}
+
+func reportWorkLog(t *testing.T, session *mcp.ClientSession, message string) *mcp.CallToolResult {
+	t.Helper()
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "reportWorkLog",
+		Arguments: map[string]any{"companyId": 1, "message": message},
+	})
+	require.NoError(t, err)
+	return res
+}
+
+func worklogMessages(items []*worklog.WorkLogItem) []string {
+	var messages []string
+	for _, item := range items {
+		messages = append(messages, item.GetMessage())
+	}
+	return messages
+}
+
+func TestReportWorkLog_QueuesWhenUnreachable(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	apiClient := api.Client()
+	server.client = unreachableClient(t)
+
+	res := reportWorkLog(t, session, "started")
+	require.False(t, res.IsError)
+	require.NotEmpty(t, res.Content)
+	assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "queued")
+	items, err := server.outbox.List()
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	server.client = apiClient
+	res = reportWorkLog(t, session, "finished")
+	require.False(t, res.IsError)
+	assert.NotContains(t, res.Content[0].(*mcp.TextContent).Text, "queued")
+	assert.Equal(t, []string{"started", "finished"}, worklogMessages(api.Worklog(1)), "queued items are sent first")
+	items, err = server.outbox.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestFlushOutbox(t *testing.T) {
+	server, api := newTestServer(t)
+	for _, msg := range []string{"first", "second"} {
+		_, err := server.outbox.Add(1, worklog.WorkLogItem_builder{Message: msg}.Build())
+		require.NoError(t, err)
+	}
+
+	server.flushOutbox(context.Background(), outbox.FlushOptions{})
+	assert.Equal(t, []string{"first", "second"}, worklogMessages(api.Worklog(1)))
+
+	// Items are sent with idempotency keys, so repeated submissions are recorded once
+	requests := api.RequestsTo("POST", "company/1/worklog/submit")
+	require.Len(t, requests, 2)
+	assert.NotEmpty(t, requests[0].Header.Get(devplan.IdempotencyKeyHeader))
+}
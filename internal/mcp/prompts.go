@@ -0,0 +1,261 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/opensdd/osdd-api/clients/go/osdd/recipes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// generalRule holds the company rules included in every prompt
+const generalRule = "general"
+
+// promptDef is an MCP prompt rendered from the company rule with the same name.
+// Instructions are used when the company has no such rule.
+type promptDef struct {
+	Name         string
+	Title        string
+	Description  string
+	Instructions string
+}
+
+var promptDefs = []promptDef{
+	{
+		Name:         "implement-task",
+		Title:        "Implement Devplan task",
+		Description:  "Implement a Devplan task or feature following the company rules.",
+		Instructions: "Implement the requirements below in this repository. Explore the relevant code first, follow its conventions, keep the change focused, and make sure it builds and the tests pass.",
+	},
+	{
+		Name:         "review-changes",
+		Title:        "Review changes",
+		Description:  "Review the current changes against a Devplan task or feature and the company rules.",
+		Instructions: "Review the changes of the current branch and the uncommitted changes against the requirements below. Point out missed requirements, bugs, missing tests and deviations from the company rules, ordered by severity.",
+	},
+	{
+		Name:         "write-tests",
+		Title:        "Write tests",
+		Description:  "Write tests for a Devplan task or feature following the company rules.",
+		Instructions: "Write tests covering the requirements below. Follow the test layout and helpers already used in this repository, cover edge cases and failures, and make sure the tests pass.",
+	},
+}
+
+var promptArguments = []*mcp.PromptArgument{
+	{Name: "taskId", Description: "Devplan task identifier. Defaults to the task of the workspace."},
+	{Name: "featureId", Description: "Devplan feature identifier, used instead of a task."},
+	{Name: "companyId", Description: "Devplan company identifier. Defaults to the current company."},
+}
+
+func (s *Server) addPrompts() {
+	for _, def := range promptDefs {
+		s.srv.AddPrompt(&mcp.Prompt{
+			Name:        def.Name,
+			Title:       def.Title,
+			Description: def.Description,
+			Arguments:   promptArguments,
+		}, s.promptHandler(def))
+	}
+}
+
+func (s *Server) promptHandler(def promptDef) mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		text, err := s.renderPrompt(ctx, s.workspace(ctx, req.Session), def, req.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.GetPromptResult{
+			Description: def.Description,
+			Messages:    []*mcp.PromptMessage{{Role: "user", Content: &mcp.TextContent{Text: text}}},
+		}, nil
+	}
+}
+
+func (s *Server) renderPrompt(ctx context.Context, ws workspace, def promptDef, args map[string]string) (string, error) {
+	var requestedCompanyID int32
+	if id := args["companyId"]; id != "" {
+		parsed, err := strconv.ParseInt(id, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid companyId %q", id)
+		}
+		requestedCompanyID = int32(parsed)
+	}
+	companyID, err := ws.companyID(requestedCompanyID)
+	if err != nil {
+		return "", err
+	}
+	taskID, featureID := args["taskId"], args["featureId"]
+	if taskID == "" && featureID == "" {
+		taskID, featureID = ws.TaskID, ws.FeatureID
+	}
+	docID := taskID
+	if docID == "" {
+		docID = featureID
+	}
+	if docID == "" {
+		return "", fmt.Errorf("taskId or featureId is required, it could not be determined from the workspace")
+	}
+
+	cl := s.apiClient()
+	docResp, err := cl.GetDocument(ctx, companyID, docID)
+	if err != nil {
+		return "", toolError(err)
+	}
+
+	var md strings.Builder
+	instructions := s.devRule(ctx, companyID, def.Name)
+	if instructions == "" {
+		instructions = def.Instructions
+	}
+	md.WriteString(instructions)
+	md.WriteString("\n")
+	if general := s.devRule(ctx, companyID, generalRule); general != "" {
+		fmt.Fprintf(&md, "\n## Company rules\n\n%s\n", general)
+	}
+	md.WriteString("\n")
+	md.WriteString(documentMarkdown(toDocument(docResp.GetDocument(), true)))
+
+	recipe, err := s.promptRecipe(ctx, companyID, taskID, featureID)
+	if err != nil && !devplan.IsNotFound(err) {
+		slog.Warn("Failed to get recipe for prompt", "prompt", def.Name, "err", err)
+	}
+	if text := recipeMarkdown(recipe); text != "" {
+		fmt.Fprintf(&md, "\n## Recipe\n\n%s\n", text)
+	}
+	// The company IDE recipe applies to all tasks and features, so it is shown apart from their recipe
+	ideRecipe, err := cl.GetIDERecipe(ctx, companyID)
+	if err != nil && !devplan.IsNotFound(err) {
+		slog.Warn("Failed to get IDE recipe for prompt", "prompt", def.Name, "err", err)
+	}
+	if text := recipeMarkdown(ideRecipe); text != "" {
+		fmt.Fprintf(&md, "\n## IDE recipe\n\n%s\n", text)
+	}
+	return md.String(), nil
+}
+
+// promptRecipe returns the recipe of the task, or of the feature like `devplan spec start -f` uses
+func (s *Server) promptRecipe(ctx context.Context, companyID int32, taskID, featureID string) (*recipes.Recipe, error) {
+	cl := s.apiClient()
+	if taskID != "" {
+		return cl.GetTaskRecipe(ctx, companyID, taskID)
+	}
+	execRecipe, err := cl.GetFeatureExecRecipe(ctx, companyID, featureID)
+	if err != nil {
+		return nil, err
+	}
+	return execRecipe.GetRecipe(), nil
+}
+
+// devRule returns the company rule, or an empty string if the company has none
+func (s *Server) devRule(ctx context.Context, companyID int32, name string) string {
+	resp, err := s.apiClient().GetDevRule(ctx, companyID, name)
+	if err != nil {
+		if !devplan.IsNotFound(err) {
+			slog.Warn("Failed to get dev rule", "rule", name, "err", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(resp.GetRule())
+}
+
+// recipeMarkdown renders the fields set in a recipe as a nested markdown list. Fields are found by reflection,
+// so steps and instructions added to recipes show up without changes here.
+func recipeMarkdown(recipe proto.Message) string {
+	if recipe == nil || !recipe.ProtoReflect().IsValid() {
+		return ""
+	}
+	var md strings.Builder
+	writeMessageMarkdown(&md, recipe.ProtoReflect(), "")
+	return strings.TrimRight(md.String(), "\n")
+}
+
+func writeMessageMarkdown(md *strings.Builder, m protoreflect.Message, indent string) {
+	var fields []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+	// Range does not guarantee an order
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Number() < fields[j].Number() })
+	for _, fd := range fields {
+		value := m.Get(fd)
+		label := fieldLabel(fd)
+		switch {
+		case fd.IsList():
+			fmt.Fprintf(md, "%s- **%s**:\n", indent, label)
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				if fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+					marker := fmt.Sprintf("%d. ", i+1)
+					fmt.Fprintf(md, "%s  %s\n", indent, strings.TrimSpace(marker))
+					writeMessageMarkdown(md, list.Get(i).Message(), indent+"  "+strings.Repeat(" ", len(marker)))
+					continue
+				}
+				writeTextMarkdown(md, indent+"  - ", indent+"    ", scalarText(fd, list.Get(i)))
+			}
+		case fd.IsMap():
+			fmt.Fprintf(md, "%s- **%s**:\n", indent, label)
+			entries := value.Map()
+			keys := make([]string, 0, entries.Len())
+			values := make(map[string]protoreflect.Value, entries.Len())
+			entries.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				keys = append(keys, k.String())
+				values[k.String()] = v
+				return true
+			})
+			sort.Strings(keys)
+			for _, key := range keys {
+				if fd.MapValue().Kind() == protoreflect.MessageKind {
+					fmt.Fprintf(md, "%s  - **%s**:\n", indent, key)
+					writeMessageMarkdown(md, values[key].Message(), indent+"    ")
+					continue
+				}
+				writeTextMarkdown(md, fmt.Sprintf("%s  - **%s**: ", indent, key), indent+"    ", scalarText(fd.MapValue(), values[key]))
+			}
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			fmt.Fprintf(md, "%s- **%s**:\n", indent, label)
+			writeMessageMarkdown(md, value.Message(), indent+"  ")
+		default:
+			writeTextMarkdown(md, fmt.Sprintf("%s- **%s**: ", indent, label), indent+"  ", scalarText(fd, value))
+		}
+	}
+}
+
+// writeTextMarkdown writes text after prefix. Multiline text, e.g. instructions, starts on the next line
+// indented to stay within the list item.
+func writeTextMarkdown(md *strings.Builder, prefix, indent, text string) {
+	if !strings.Contains(text, "\n") {
+		fmt.Fprintf(md, "%s%s\n", prefix, text)
+		return
+	}
+	fmt.Fprintf(md, "%s\n", strings.TrimRight(prefix, " "))
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			md.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(md, "%s%s\n", indent, line)
+	}
+}
+
+func scalarText(fd protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	if fd.Kind() == protoreflect.EnumKind {
+		if enum := fd.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name())
+		}
+	}
+	return value.String()
+}
+
+// fieldLabel turns a field name like entry_point into "Entry point"
+func fieldLabel(fd protoreflect.FieldDescriptor) string {
+	label := strings.ReplaceAll(string(fd.Name()), "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestListPrompts(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	res, err := session.ListPrompts(context.Background(), nil)
+	require.NoError(t, err)
+	var names []string
+	for _, p := range res.Prompts {
+		names = append(names, p.Name)
+		assert.Len(t, p.Arguments, 3)
+	}
+	assert.ElementsMatch(t, []string{"implement-task", "review-changes", "write-tests"}, names)
+}
+
+func TestGetPrompt_UsesWorkspaceTaskAndCompanyRules(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	res, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: "implement-task"})
+	require.NoError(t, err)
+	require.Len(t, res.Messages, 1)
+	text := res.Messages[0].Content.(*mcp.TextContent).Text
+	assert.Contains(t, text, "Implement the requirements below")
+	assert.Contains(t, text, "## Company rules\n\nKeep functions small and covered by tests.")
+	assert.Contains(t, text, "# Add payment method picker")
+}
+
+func TestGetPrompt_CompanyRuleReplacesInstructions(t *testing.T) {
+	session, _, api := newTestSession(t, nil)
+	api.Fixtures.SetDevRule(1, "review-changes", "Check the changes against our review checklist.")
+
+	res, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "review-changes",
+		Arguments: map[string]string{"featureId": "f1", "companyId": "1"},
+	})
+	require.NoError(t, err)
+	text := res.Messages[0].Content.(*mcp.TextContent).Text
+	assert.Contains(t, text, "Check the changes against our review checklist.")
+	assert.NotContains(t, text, "Review the changes of the current branch")
+	assert.Contains(t, text, "# One-click checkout")
+}
+
+func TestGetPrompt_RequiresTarget(t *testing.T) {
+	session, server, _ := newTestSession(t, nil)
+	server.dir = t.TempDir()
+
+	_, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: "write-tests"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "taskId or featureId is required")
+}
+
+func TestGetPrompt_FeatureUsesFeatureRecipe(t *testing.T) {
+	session, _, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/ide", []byte(`{"jsonRecipe": "{\"id\": \"company-ide-recipe\"}"}`)))
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/user-story/f1/executable", []byte(`{"jsonRecipe": "{\"recipe\": {\"id\": \"checkout-recipe\"}}"}`)))
+
+	res, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "implement-task",
+		Arguments: map[string]string{"featureId": "f1"},
+	})
+	require.NoError(t, err)
+	text := res.Messages[0].Content.(*mcp.TextContent).Text
+	recipe, ideRecipe, found := strings.Cut(text, "## IDE recipe")
+	require.True(t, found)
+	assert.Contains(t, recipe, "checkout-recipe")
+	assert.NotContains(t, recipe, "company-ide-recipe", "the IDE recipe does not replace the feature recipe")
+	assert.Contains(t, ideRecipe, "company-ide-recipe")
+	assert.NotContains(t, text, "```json")
+}
+
+func TestRecipeMarkdown(t *testing.T) {
+	msg := &descriptorpb.FileDescriptorProto{
+		Name: proto.String("checkout.proto"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Cart")},
+			{Name: proto.String("Payment")},
+		},
+		Options:    &descriptorpb.FileOptions{OptimizeFor: descriptorpb.FileOptions_SPEED.Enum()},
+		Dependency: []string{"money.proto"},
+		Syntax:     proto.String("first line\nsecond line"),
+	}
+	assert.Equal(t, `- **Name**: checkout.proto
+- **Dependency**:
+  - money.proto
+- **Message type**:
+  1.
+     - **Name**: Cart
+  2.
+     - **Name**: Payment
+- **Options**:
+  - **Optimize for**: SPEED
+- **Syntax**:
+  first line
+  second line`, recipeMarkdown(msg))
+	assert.Empty(t, recipeMarkdown((*descriptorpb.FileDescriptorProto)(nil)))
+}
@@ -0,0 +1,312 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/devplaninc/devplan-cli/internal/utils/git"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/documents"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const workspaceDefaultNote = " Defaults to the one of the current Devplan workspace."
+
+func (s *Server) addReadTools() {
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "getDocument", Description: "Get a Devplan document (task, feature, PRD, etc.) with its full content." + workspaceDefaultNote}, s.getDocument)
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "listProjectDocuments", Description: "List the documents of a Devplan project, e.g. to find the PRD, tech brief or other features." + workspaceDefaultNote}, s.listProjectDocuments)
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "getTaskSpecs", Description: "Get the specs written for a Devplan task. Content is included for specs present in the workspace." + workspaceDefaultNote}, s.getTaskSpecs)
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "getFeatureTasks", Description: "Get all tasks of a Devplan feature with their content, e.g. to see sibling tasks." + workspaceDefaultNote}, s.getFeatureTasks)
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "getRepoSummary", Description: "Get the Devplan summary of a repository: its purpose, structure and conventions." + workspaceDefaultNote}, s.getRepoSummary)
+}
+
+type DocumentInput struct {
+	CompanyID  int32  `json:"companyId,omitempty" jsonschema:"optional company identifier"`
+	DocumentID string `json:"documentId,omitempty" jsonschema:"optional document identifier. Defaults to the task or feature of the workspace"`
+}
+
+type Document struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Type      string `json:"type"`
+	NumericID int32  `json:"numericId,omitempty"`
+	ProjectID string `json:"projectId,omitempty"`
+	ParentID  string `json:"parentId,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+func (s *Server) getDocument(ctx context.Context, req *mcp.CallToolRequest, input DocumentInput) (*mcp.CallToolResult, Document, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, Document{}, err
+	}
+	docID := input.DocumentID
+	if docID == "" {
+		docID = ws.TaskID
+	}
+	docID, err = orDefault(docID, ws.FeatureID, "documentId")
+	if err != nil {
+		return nil, Document{}, err
+	}
+	resp, err := s.apiClient().GetDocument(ctx, companyID, docID)
+	if err != nil {
+		return nil, Document{}, toolError(err)
+	}
+	doc := toDocument(resp.GetDocument(), true)
+	return markdownResult(documentMarkdown(doc)), doc, nil
+}
+
+type ProjectDocumentsInput struct {
+	CompanyID int32  `json:"companyId,omitempty" jsonschema:"optional company identifier"`
+	ProjectID string `json:"projectId,omitempty" jsonschema:"optional project identifier"`
+}
+
+type ProjectDocumentsOutput struct {
+	ProjectID string     `json:"projectId"`
+	Documents []Document `json:"documents"`
+}
+
+func (s *Server) listProjectDocuments(ctx context.Context, req *mcp.CallToolRequest, input ProjectDocumentsInput) (*mcp.CallToolResult, ProjectDocumentsOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, ProjectDocumentsOutput{}, err
+	}
+	projectID, err := orDefault(input.ProjectID, ws.ProjectID, "projectId")
+	if err != nil {
+		return nil, ProjectDocumentsOutput{}, err
+	}
+	resp, err := s.apiClient().GetProjectDocuments(ctx, companyID, projectID)
+	if err != nil {
+		return nil, ProjectDocumentsOutput{}, toolError(err)
+	}
+	result := ProjectDocumentsOutput{ProjectID: projectID, Documents: []Document{}}
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Documents of project %s\n\n", projectID)
+	for _, d := range resp.GetDocuments() {
+		doc := toDocument(d, false)
+		result.Documents = append(result.Documents, doc)
+		fmt.Fprintf(&md, "- %s `%s` (%s)", doc.Title, doc.ID, doc.Type)
+		if doc.ParentID != "" {
+			fmt.Fprintf(&md, ", parent `%s`", doc.ParentID)
+		}
+		md.WriteString("\n")
+	}
+	return markdownResult(md.String()), result, nil
+}
+
+type TaskSpecsInput struct {
+	CompanyID int32  `json:"companyId,omitempty" jsonschema:"optional company identifier"`
+	TaskID    string `json:"taskId,omitempty" jsonschema:"optional task identifier"`
+}
+
+type TaskSpec struct {
+	Name     string `json:"name"`
+	Checksum string `json:"checksum,omitempty"`
+	// Content is set for specs present in the local task directory
+	Content string `json:"content,omitempty"`
+}
+
+type TaskSpecsOutput struct {
+	TaskID  string     `json:"taskId"`
+	TaskDir string     `json:"taskDir,omitempty"`
+	Specs   []TaskSpec `json:"specs"`
+}
+
+func (s *Server) getTaskSpecs(ctx context.Context, req *mcp.CallToolRequest, input TaskSpecsInput) (*mcp.CallToolResult, TaskSpecsOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, TaskSpecsOutput{}, err
+	}
+	taskID, err := orDefault(input.TaskID, ws.TaskID, "taskId")
+	if err != nil {
+		return nil, TaskSpecsOutput{}, err
+	}
+	resp, err := s.apiClient().GetTaskSpecs(ctx, companyID, taskID)
+	if err != nil {
+		return nil, TaskSpecsOutput{}, toolError(err)
+	}
+	result := TaskSpecsOutput{TaskID: taskID, Specs: []TaskSpec{}}
+	local := map[string]specsync.Spec{}
+	if taskDir := resp.GetPathsInfo().GetTaskPaths()[taskID].GetTaskDir(); taskDir != "" {
+		result.TaskDir = taskDir
+		// Without a workspace directory only the server side of the specs is known
+		if ws.Dir != "" {
+			for _, spec := range s.discoverTaskSpecs(ctx, companyID, taskID, filepath.Join(ws.Dir, taskDir)) {
+				local[spec.Name] = spec
+			}
+		}
+	}
+	for _, spec := range resp.GetSpecs() {
+		result.Specs = append(result.Specs, TaskSpec{
+			Name:     spec.GetName(),
+			Checksum: spec.GetChecksum(),
+			Content:  string(local[spec.GetName()].Content),
+		})
+	}
+	sort.Slice(result.Specs, func(i, j int) bool { return result.Specs[i].Name < result.Specs[j].Name })
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Specs of task %s\n", taskID)
+	if len(result.Specs) == 0 {
+		md.WriteString("\nNo specs were written for this task yet.\n")
+	}
+	for _, spec := range result.Specs {
+		fmt.Fprintf(&md, "\n## %s\n\n", spec.Name)
+		if spec.Content == "" {
+			md.WriteString("_Content is not available in this workspace._\n")
+			continue
+		}
+		md.WriteString(spec.Content)
+		md.WriteString("\n")
+	}
+	return markdownResult(md.String()), result, nil
+}
+
+type FeatureTasksInput struct {
+	CompanyID int32  `json:"companyId,omitempty" jsonschema:"optional company identifier"`
+	FeatureID string `json:"featureId,omitempty" jsonschema:"optional feature identifier"`
+}
+
+type FeatureTasksOutput struct {
+	Feature Document   `json:"feature"`
+	Tasks   []Document `json:"tasks"`
+	// FailedTaskIDs holds the tasks that could not be loaded, which are left out of Tasks
+	FailedTaskIDs []string `json:"failedTaskIds,omitempty"`
+}
+
+func (s *Server) getFeatureTasks(ctx context.Context, req *mcp.CallToolRequest, input FeatureTasksInput) (*mcp.CallToolResult, FeatureTasksOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, FeatureTasksOutput{}, err
+	}
+	featureID, err := orDefault(input.FeatureID, ws.FeatureID, "featureId")
+	if err != nil {
+		return nil, FeatureTasksOutput{}, err
+	}
+	cl := s.apiClient()
+	featureResp, err := cl.GetDocument(ctx, companyID, featureID)
+	if err != nil {
+		return nil, FeatureTasksOutput{}, toolError(err)
+	}
+	feature := featureResp.GetDocument()
+	docsResp, err := cl.GetProjectDocuments(ctx, companyID, feature.GetProjectId())
+	if err != nil {
+		return nil, FeatureTasksOutput{}, toolError(err)
+	}
+	var taskIDs []string
+	for _, d := range docsResp.GetDocuments() {
+		if d.GetType() == documents.DocumentType_TASK && d.GetParentId() == featureID {
+			taskIDs = append(taskIDs, d.GetId())
+		}
+	}
+	tasks, err := cl.GetDocuments(ctx, companyID, taskIDs)
+	// Tasks that loaded are returned when only some failed
+	var batchErr *devplan.BatchError
+	if err != nil && (!errors.As(err, &batchErr) || len(tasks) == 0) {
+		return nil, FeatureTasksOutput{}, toolError(err)
+	}
+	result := FeatureTasksOutput{Feature: toDocument(feature, false), Tasks: []Document{}}
+	for _, id := range taskIDs {
+		task, ok := tasks[id]
+		if !ok {
+			continue
+		}
+		result.Tasks = append(result.Tasks, toDocument(task.GetDocument(), true))
+	}
+	sort.SliceStable(result.Tasks, func(i, j int) bool { return result.Tasks[i].NumericID < result.Tasks[j].NumericID })
+	if batchErr != nil {
+		for id := range batchErr.Errors {
+			result.FailedTaskIDs = append(result.FailedTaskIDs, id)
+		}
+		sort.Strings(result.FailedTaskIDs)
+	}
+
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Tasks of feature %s\n", result.Feature.Title)
+	for _, task := range result.Tasks {
+		md.WriteString("\n")
+		md.WriteString(documentMarkdown(task))
+	}
+	if len(result.FailedTaskIDs) > 0 {
+		fmt.Fprintf(&md, "\nThese tasks could not be loaded: %s\n", strings.Join(result.FailedTaskIDs, ", "))
+	}
+	return markdownResult(md.String()), result, nil
+}
+
+type RepoSummaryInput struct {
+	CompanyID int32  `json:"companyId,omitempty" jsonschema:"optional company identifier"`
+	RepoName  string `json:"repoName,omitempty" jsonschema:"optional full repository name, e.g. owner/repo"`
+}
+
+type RepoSummaryOutput struct {
+	RepoName string `json:"repoName"`
+	Summary  string `json:"summary"`
+}
+
+func (s *Server) getRepoSummary(ctx context.Context, req *mcp.CallToolRequest, input RepoSummaryInput) (*mcp.CallToolResult, RepoSummaryOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, RepoSummaryOutput{}, err
+	}
+	repoName, err := orDefault(input.RepoName, ws.RepoName, "repoName")
+	if err != nil {
+		return nil, RepoSummaryOutput{}, err
+	}
+	resp, err := s.apiClient().GetRepoSummaries(ctx, companyID)
+	if err != nil {
+		return nil, RepoSummaryOutput{}, toolError(err)
+	}
+	repo := git.RepoInfo{FullNames: []string{repoName}}
+	var known []string
+	for _, summary := range resp.GetRepoSummaries() {
+		if repo.MatchesName(summary.GetRepoName()) {
+			result := RepoSummaryOutput{RepoName: summary.GetRepoName(), Summary: summary.GetSummary()}
+			return markdownResult(fmt.Sprintf("# %s\n\n%s\n", result.RepoName, result.Summary)), result, nil
+		}
+		known = append(known, summary.GetRepoName())
+	}
+	return nil, RepoSummaryOutput{}, fmt.Errorf("no summary for repository %q, summaries exist for: %s", repoName, strings.Join(known, ", "))
+}
+
+func toDocument(d *documents.DocumentEntity, withContent bool) Document {
+	doc := Document{
+		ID:        d.GetId(),
+		Title:     d.GetTitle(),
+		Type:      strings.ToLower(d.GetType().String()),
+		NumericID: d.GetNumericId(),
+		ProjectID: d.GetProjectId(),
+		ParentID:  d.GetParentId(),
+	}
+	if withContent {
+		doc.Content = d.GetContent()
+	}
+	return doc
+}
+
+func documentMarkdown(doc Document) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# %s\n\n", doc.Title)
+	fmt.Fprintf(&md, "- ID: `%s`\n- Type: %s\n", doc.ID, doc.Type)
+	if doc.ParentID != "" {
+		fmt.Fprintf(&md, "- Parent: `%s`\n", doc.ParentID)
+	}
+	if doc.Content != "" {
+		fmt.Fprintf(&md, "\n%s\n", doc.Content)
+	}
+	return md.String()
+}
+
+// markdownResult returns the markdown for the agent to read. The typed output is added as structured content.
+func markdownResult(md string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: md}}}
+}
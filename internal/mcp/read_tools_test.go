@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan/devplantest"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/devplaninc/devplan-cli/internal/utils/metadata"
+	"github.com/devplaninc/devplan-cli/internal/utils/outbox"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSession connects an MCP client to a server created by newTestServer
+func newTestSession(t *testing.T, opts *mcp.ClientOptions) (*mcp.ClientSession, *Server, *devplantest.Server) {
+	t.Helper()
+	server, api := newTestServer(t)
+
+	ctx := context.Background()
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.srv.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = serverSession.Close() })
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, opts)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = session.Close() })
+	return session, server, api
+}
+
+// newTestServer creates a server backed by the fake Devplan API in a workspace of task t1 of feature f1
+func newTestServer(t *testing.T) (*Server, *devplantest.Server) {
+	t.Helper()
+	fixtures, err := devplantest.LoadFixtures("../devplan/devplantest/testdata/fixtures")
+	require.NoError(t, err)
+	api := devplantest.NewServer(t, fixtures)
+	t.Setenv("DEVPLAN_LAST_COMPANY_ID", "1")
+
+	dir := t.TempDir()
+	require.NoError(t, metadata.WriteMetadata(dir, metadata.Metadata{
+		CompanyID: 1,
+		ProjectID: "p1",
+		StoryID:   "f1",
+		TaskID:    "t1",
+		RepoName:  "acme/storefront",
+	}))
+	server := NewServer()
+	server.client = api.Client()
+	server.dir = dir
+	server.outbox = outbox.New(t.TempDir())
+	return server, api
+}
+
+func callTool[Out any](t *testing.T, session *mcp.ClientSession, name string, args map[string]any) (string, Out) {
+	t.Helper()
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Content)
+	text := res.Content[0].(*mcp.TextContent).Text
+	require.False(t, res.IsError, text)
+	var out Out
+	data, err := json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &out))
+	return text, out
+}
+
+func TestGetDocument_DefaultsToWorkspaceTask(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	md, doc := callTool[Document](t, session, "getDocument", nil)
+	assert.Equal(t, "t1", doc.ID)
+	assert.Equal(t, "task", doc.Type)
+	assert.Equal(t, "f1", doc.ParentID)
+	assert.Contains(t, md, "# Add payment method picker")
+	assert.Contains(t, md, "Show saved payment methods")
+}
+
+func TestListProjectDocuments(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	md, out := callTool[ProjectDocumentsOutput](t, session, "listProjectDocuments", map[string]any{"companyId": 1})
+	assert.Equal(t, "p1", out.ProjectID)
+	require.Len(t, out.Documents, 2)
+	assert.Equal(t, "feature", out.Documents[0].Type)
+	assert.Empty(t, out.Documents[0].Content)
+	assert.Contains(t, md, "- One-click checkout `f1` (feature)")
+}
+
+func TestGetTaskSpecs_IncludesLocalContent(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/task/t1/specs", []byte(`{
+		"specs": [{"name": "plan.md", "checksum": "c1"}, {"name": "notes.md", "checksum": "c2"}],
+		"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}
+	}`)))
+	taskDir := filepath.Join(server.dir, "specs", "t1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "plan.md"), []byte("# Plan\nStep 1"), 0644))
+
+	md, out := callTool[TaskSpecsOutput](t, session, "getTaskSpecs", nil)
+	assert.Equal(t, "specs/t1", out.TaskDir)
+	require.Len(t, out.Specs, 2)
+	assert.Equal(t, TaskSpec{Name: "notes.md", Checksum: "c2"}, out.Specs[0])
+	assert.Equal(t, "# Plan\nStep 1", out.Specs[1].Content)
+	assert.Contains(t, md, "## notes.md\n\n_Content is not available in this workspace._")
+}
+
+func TestGetTaskSpecs_AppliesSpecRules(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/task/t1/specs", []byte(`{
+		"specs": [{"name": "flow.mermaid", "checksum": "c1"}, {"name": "draft.md", "checksum": "c2"}],
+		"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}
+	}`)))
+	api.Fixtures.SetDevRule(1, specsync.PatternsRule, "*.mermaid")
+	taskDir := filepath.Join(server.dir, "specs", "t1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "flow.mermaid"), []byte("graph TD"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "draft.md"), []byte("# Draft"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, specsync.IgnoreFile), []byte("draft.md\n"), 0644))
+
+	_, out := callTool[TaskSpecsOutput](t, session, "getTaskSpecs", nil)
+	require.Len(t, out.Specs, 2)
+	assert.Equal(t, TaskSpec{Name: "draft.md", Checksum: "c2"}, out.Specs[0], "ignored files are not specs")
+	assert.Equal(t, "graph TD", out.Specs[1].Content)
+}
+
+func TestGetFeatureTasks(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	md, out := callTool[FeatureTasksOutput](t, session, "getFeatureTasks", nil)
+	assert.Equal(t, "One-click checkout", out.Feature.Title)
+	require.Len(t, out.Tasks, 1)
+	assert.Equal(t, "t1", out.Tasks[0].ID)
+	assert.Equal(t, "Show saved payment methods on the checkout page.", out.Tasks[0].Content)
+	assert.Contains(t, md, "# Tasks of feature One-click checkout")
+}
+
+func TestGetFeatureTasks_ReturnsLoadedTasks(t *testing.T) {
+	session, _, api := newTestSession(t, nil)
+	// t2 and t3 are listed in the project, but their documents can't be loaded
+	require.NoError(t, api.Fixtures.SetJSON("company/1/projects/p1/docs", []byte(`{"documents": [
+		{"id": "f1", "title": "One-click checkout", "type": "FEATURE", "projectId": "p1"},
+		{"id": "t1", "title": "Add payment method picker", "type": "TASK", "parentId": "f1", "projectId": "p1"},
+		{"id": "t2", "title": "Remember the last method", "type": "TASK", "parentId": "f1", "projectId": "p1"},
+		{"id": "t3", "title": "Confirm with one click", "type": "TASK", "parentId": "f1", "projectId": "p1"}
+	]}`)))
+
+	md, out := callTool[FeatureTasksOutput](t, session, "getFeatureTasks", nil)
+	require.Len(t, out.Tasks, 1)
+	assert.Equal(t, "t1", out.Tasks[0].ID)
+	assert.Equal(t, []string{"t2", "t3"}, out.FailedTaskIDs)
+	assert.Contains(t, md, "These tasks could not be loaded: t2, t3")
+}
+
+func TestGetRepoSummary(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	_, out := callTool[RepoSummaryOutput](t, session, "getRepoSummary", nil)
+	assert.Equal(t, "acme/storefront", out.RepoName)
+	assert.Contains(t, out.Summary, "Next.js")
+
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "getRepoSummary", Arguments: map[string]any{"repoName": "acme/other"}})
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+}
+
+func TestReadTools_RequireIDsOutsideWorkspace(t *testing.T) {
+	session, server, _ := newTestSession(t, nil)
+	server.dir = t.TempDir()
+
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "getTaskSpecs"})
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "taskId is required")
+}
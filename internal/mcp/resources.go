@@ -0,0 +1,199 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	resourceScheme      = "devplan://"
+	documentURITemplate = resourceScheme + "company/{companyId}/project/{projectId}/doc/{docId}"
+	specURITemplate     = resourceScheme + "task/{taskId}/spec/{name}"
+	markdownMIMEType    = "text/markdown"
+)
+
+func (s *Server) addResourceTemplates() {
+	s.srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "document",
+		Title:       "Devplan document",
+		Description: "A Devplan document (PRD, feature, task, etc.) of a project, with its full content.",
+		MIMEType:    markdownMIMEType,
+		URITemplate: documentURITemplate,
+	}, s.readDocumentResource)
+	s.srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "task-spec",
+		Title:       "Devplan task spec",
+		Description: "A spec written for a Devplan task, read from the current workspace.",
+		MIMEType:    markdownMIMEType,
+		URITemplate: specURITemplate,
+	}, s.readSpecResource)
+}
+
+func documentURI(companyID int32, projectID, docID string) string {
+	return fmt.Sprintf("%scompany/%d/project/%s/doc/%s", resourceScheme, companyID, url.PathEscape(projectID), url.PathEscape(docID))
+}
+
+func specURI(taskID, name string) string {
+	return fmt.Sprintf("%stask/%s/spec/%s", resourceScheme, url.PathEscape(taskID), url.PathEscape(name))
+}
+
+// parseResourceURI matches uri against a pattern like "company/{}/project/{}" and returns the values of the placeholders
+func parseResourceURI(uri, pattern string) ([]string, bool) {
+	path, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return nil, false
+	}
+	parts := strings.Split(path, "/")
+	expected := strings.Split(pattern, "/")
+	if len(parts) != len(expected) {
+		return nil, false
+	}
+	var values []string
+	for i, part := range parts {
+		if expected[i] != "{}" {
+			if part != expected[i] {
+				return nil, false
+			}
+			continue
+		}
+		value, err := url.PathUnescape(part)
+		if err != nil || value == "" {
+			return nil, false
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+func (s *Server) readDocumentResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	values, ok := parseResourceURI(uri, "company/{}/project/{}/doc/{}")
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	companyID, err := strconv.ParseInt(values[0], 10, 32)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	resp, err := s.apiClient().GetDocument(ctx, int32(companyID), values[2])
+	if err != nil {
+		return nil, toolError(err)
+	}
+	doc := toDocument(resp.GetDocument(), true)
+	if doc.ProjectID != "" && doc.ProjectID != values[1] {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	return markdownResource(uri, documentMarkdown(doc)), nil
+}
+
+func (s *Server) readSpecResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	values, ok := parseResourceURI(uri, "task/{}/spec/{}")
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	taskID, name := values[0], values[1]
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(0)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.apiClient().GetTaskSpecs(ctx, companyID, taskID)
+	if err != nil {
+		return nil, toolError(err)
+	}
+	taskDir := resp.GetPathsInfo().GetTaskPaths()[taskID].GetTaskDir()
+	if taskDir == "" {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	dir, err := ws.dir()
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range s.discoverTaskSpecs(ctx, companyID, taskID, filepath.Join(dir, taskDir)) {
+		if spec.Name == name {
+			return markdownResource(uri, string(spec.Content)), nil
+		}
+	}
+	return nil, mcp.ResourceNotFoundError(uri)
+}
+
+// addProjectResources lists the documents of the workspace project as resources, so they can be attached without knowing their IDs.
+// Over HTTP there is no workspace of the server, so nothing is listed.
+func (s *Server) addProjectResources(ctx context.Context) {
+	ws := s.workspace(ctx, nil)
+	if ws.CompanyID <= 0 || ws.ProjectID == "" {
+		return
+	}
+	resp, err := s.apiClient().GetProjectDocuments(ctx, ws.CompanyID, ws.ProjectID)
+	if err != nil {
+		slog.Warn("Failed to list project documents as resources", "projectID", ws.ProjectID, "err", err)
+		return
+	}
+	for _, d := range resp.GetDocuments() {
+		doc := toDocument(d, false)
+		s.srv.AddResource(&mcp.Resource{
+			URI:         documentURI(ws.CompanyID, ws.ProjectID, doc.ID),
+			Name:        doc.ID,
+			Title:       doc.Title,
+			Description: fmt.Sprintf("Devplan %s", doc.Type),
+			MIMEType:    markdownMIMEType,
+		}, s.readDocumentResource)
+	}
+}
+
+// updateSpecResources replaces the spec resources of the task with the given specs.
+// Adding and removing resources sends resources/list_changed to the clients, and subscribers of edited specs are notified.
+func (s *Server) updateSpecResources(ctx context.Context, taskID string, specs []specsync.Spec) {
+	current := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		current[specURI(taskID, spec.Name)] = spec.Checksum
+	}
+
+	s.mu.Lock()
+	previous := s.specResources[taskID]
+	s.specResources[taskID] = current
+	s.mu.Unlock()
+
+	var removed []string
+	for uri := range previous {
+		if _, ok := current[uri]; !ok {
+			removed = append(removed, uri)
+		}
+	}
+	if len(removed) > 0 {
+		s.srv.RemoveResources(removed...)
+	}
+	for _, spec := range specs {
+		uri := specURI(taskID, spec.Name)
+		checksum, known := previous[uri]
+		if known && checksum == spec.Checksum {
+			continue
+		}
+		if !known {
+			s.srv.AddResource(&mcp.Resource{
+				URI:         uri,
+				Name:        spec.Name,
+				Title:       spec.Name,
+				Description: fmt.Sprintf("Spec of task %s", taskID),
+				MIMEType:    markdownMIMEType,
+			}, s.readSpecResource)
+			continue
+		}
+		if err := s.srv.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+			slog.Warn("Failed to notify about updated spec", "uri", uri, "err", err)
+		}
+	}
+}
+
+func markdownResource(uri, md string) *mcp.ReadResourceResult {
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: uri, MIMEType: markdownMIMEType, Text: md}}}
+}
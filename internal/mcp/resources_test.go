@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTemplates(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+
+	res, err := session.ListResourceTemplates(context.Background(), nil)
+	require.NoError(t, err)
+	var templates []string
+	for _, tmpl := range res.ResourceTemplates {
+		templates = append(templates, tmpl.URITemplate)
+	}
+	assert.ElementsMatch(t, []string{documentURITemplate, specURITemplate}, templates)
+}
+
+func TestReadDocumentResource(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+	ctx := context.Background()
+
+	res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "devplan://company/1/project/p1/doc/t1"})
+	require.NoError(t, err)
+	require.Len(t, res.Contents, 1)
+	assert.Equal(t, markdownMIMEType, res.Contents[0].MIMEType)
+	assert.Contains(t, res.Contents[0].Text, "# Add payment method picker")
+
+	_, err = session.ReadResource(ctx, &mcp.ReadResourceParams{URI: "devplan://company/1/project/p2/doc/t1"})
+	assert.Error(t, err, "the document belongs to another project")
+}
+
+func TestReadSpecResource(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/task/t1/specs", []byte(`{
+		"specs": [{"name": "plan.md", "checksum": "c1"}],
+		"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}
+	}`)))
+	taskDir := filepath.Join(server.dir, "specs", "t1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "plan.md"), []byte("# Plan"), 0644))
+	ctx := context.Background()
+
+	res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: specURI("t1", "plan.md")})
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan", res.Contents[0].Text)
+
+	_, err = session.ReadResource(ctx, &mcp.ReadResourceParams{URI: specURI("t1", "missing.md")})
+	assert.Error(t, err)
+}
+
+func TestProjectResources(t *testing.T) {
+	session, server, _ := newTestSession(t, nil)
+	server.addProjectResources(context.Background())
+
+	res, err := session.ListResources(context.Background(), nil)
+	require.NoError(t, err)
+	var uris []string
+	for _, r := range res.Resources {
+		uris = append(uris, r.URI)
+	}
+	assert.ElementsMatch(t, []string{
+		"devplan://company/1/project/p1/doc/f1",
+		"devplan://company/1/project/p1/doc/t1",
+	}, uris)
+}
+
+func TestUpdateSpecResources_NotifiesClients(t *testing.T) {
+	listChanged := make(chan struct{}, 10)
+	updated := make(chan string, 10)
+	session, server, _ := newTestSession(t, &mcp.ClientOptions{
+		ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) { listChanged <- struct{}{} },
+		ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	ctx := context.Background()
+	planURI := specURI("t1", "plan.md")
+
+	server.updateSpecResources(ctx, "t1", []specsync.Spec{{Name: "plan.md", Checksum: "c1"}})
+	waitFor(t, listChanged)
+	res, err := session.ListResources(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Resources, 1)
+	assert.Equal(t, planURI, res.Resources[0].URI)
+
+	require.NoError(t, session.Subscribe(ctx, &mcp.SubscribeParams{URI: planURI}))
+	server.updateSpecResources(ctx, "t1", []specsync.Spec{{Name: "plan.md", Checksum: "c2"}})
+	assert.Equal(t, planURI, waitFor(t, updated))
+
+	server.updateSpecResources(ctx, "t1", nil)
+	waitFor(t, listChanged)
+	res, err = session.ListResources(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res.Resources)
+}
+
+func waitFor[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timed out waiting for a notification")
+	}
+	var zero T
+	return zero
+}
+
+func TestParseResourceURI(t *testing.T) {
+	values, ok := parseResourceURI("devplan://task/t%201/spec/plan.md", "task/{}/spec/{}")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"t 1", "plan.md"}, values)
+
+	_, ok = parseResourceURI("devplan://task/t1/spec/", "task/{}/spec/{}")
+	assert.False(t, ok)
+	_, ok = parseResourceURI("other://task/t1/spec/a.md", "task/{}/spec/{}")
+	assert.False(t, ok)
+}
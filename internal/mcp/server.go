@@ -5,75 +5,240 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/devplaninc/devplan-cli/internal/utils/outbox"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// shutdownFlushTimeout bounds the final spec sync and worklog flush on shutdown
+const shutdownFlushTimeout = 10 * time.Second
+
 func NewServer() *Server {
-	srv := mcp.NewServer(&mcp.Implementation{Name: "devplan", Version: "v1.0.0"}, nil)
-	server := &Server{srv: srv, syncers: make(map[string]*specsync.Syncer)}
+	srv := mcp.NewServer(&mcp.Implementation{Name: "devplan", Version: "v1.0.0"}, &mcp.ServerOptions{
+		// Subscriptions are tracked by the SDK, updates are sent by updateSpecResources
+		SubscribeHandler:   func(context.Context, *mcp.SubscribeRequest) error { return nil },
+		UnsubscribeHandler: func(context.Context, *mcp.UnsubscribeRequest) error { return nil },
+	})
+	server := &Server{
+		srv:           srv,
+		syncers:       make(map[string]*specsync.Syncer),
+		specResources: make(map[string]map[string]string),
+		ctx:           context.Background(),
+	}
 	mcp.AddTool(srv, &mcp.Tool{Name: "reportWorkLog", Description: "Report a worklog entry scoped to a task. Use this when working on a task-level workflow (defined in focus file)."}, server.reportWorkLog)
 	mcp.AddTool(srv, &mcp.Tool{Name: "reportFeatureWorkLog", Description: "Report a worklog entry scoped to a feature (not a task). Use this when working on a feature-level workflow (defined in focus file)."}, server.reportFeatureWorkLog)
+	server.addReadTools()
+	server.addSpecSyncTools()
+	server.addResourceTemplates()
+	server.addPrompts()
 	return server
 }
 
 type Server struct {
 	srv     *mcp.Server
 	syncers map[string]*specsync.Syncer
+	// specResources holds the checksums of the spec resources by task ID and resource URI
+	specResources map[string]map[string]string
+	// ctx is the server lifetime context. Syncers started by tool calls are bound to it.
+	ctx context.Context
+	// cancel stops the syncers and other background work on shutdown
+	cancel context.CancelFunc
+	// client is the Devplan API client, created on first use
+	client *devplan.Client
+	// outbox queues worklog items that could not be submitted, created on first use
+	outbox *outbox.Store
+	// dir is the workspace directory, the working directory of the process if empty
+	dir string
+	// sessionRoots is set when serving over HTTP: the workspace of each session is the root shared by its client
+	sessionRoots bool
 
 	mu sync.Mutex
 }
 
+func (s *Server) apiClient() *devplan.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		s.client = devplan.NewClient(devplan.Config{})
+	}
+	return s.client
+}
+
+func (s *Server) workDir() (string, error) {
+	if s.dir != "" {
+		return s.dir, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return cwd, nil
+}
+
+// Run serves a single client over stdio until the client closes the pipe or ctx is cancelled, e.g. on SIGTERM
 func (s *Server) Run(ctx context.Context) error {
+	s.start(ctx)
+	defer s.stopped()
+	err := s.srv.Run(ctx, &mcp.StdioTransport{})
+	s.shutdown()
+	return err
+}
+
+// start binds syncers started by tool calls to the server lifetime context
+func (s *Server) start(ctx context.Context) {
 	slog.Info("MCP Server: starting")
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.ctx = ctx
+	s.cancel = cancel
+	s.mu.Unlock()
+	go s.addProjectResources(ctx)
+	go s.runOutbox(ctx)
+}
 
-	defer func() {
-		if r := recover(); r != nil {
-			slog.Error("MCP server: panicked", "panic", r)
-			return
+// shutdown uploads spec changes made since the last sync run and submits queued worklog items before
+// stopping the background work. Sync runs in progress are waited for. It takes at most shutdownFlushTimeout,
+// so the process exits even if Devplan can't be reached; anything left is synced by the next run.
+func (s *Server) shutdown() {
+	slog.Info("MCP server: shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for _, syncer := range s.syncerList() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := syncer.SyncNow(ctx)
+				slog.Info("MCP server: final spec sync", "target", syncer.Status().Target.String(),
+					"uploaded", result.Uploaded, "failed", result.Failed)
+			}()
 		}
-		slog.Info("MCP server: stopped")
+		wg.Wait()
+		s.flushOutbox(ctx, outbox.FlushOptions{IgnoreBackoff: true})
 	}()
-	return s.srv.Run(ctx, &mcp.StdioTransport{})
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("MCP server: final sync did not finish in time", "timeout", shutdownFlushTimeout)
+	}
+
+	s.mu.Lock()
+	stop := s.cancel
+	s.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
 }
 
-func (s *Server) addSyncer(ctx context.Context, companyID int32, taskID string) error {
-	if companyID <= 0 || taskID == "" {
-		return fmt.Errorf("invalid parameters: companyID=%d, taskID=%s", companyID, taskID)
+func (s *Server) stopped() {
+	if r := recover(); r != nil {
+		slog.Error("MCP server: panicked", "panic", r)
+		return
+	}
+	slog.Info("MCP server: stopped")
+}
+
+// addSyncer starts syncing the specs of the task or feature in the workspace unless a syncer is running already,
+// and returns the syncer. A running syncer is shared by all sessions, whichever workspace started it. The specs directory and patterns are looked up without holding mu, so a slow API does not block other tools
+// or shutdown. If another call started a syncer meanwhile, it is returned and the new one is dropped.
+func (s *Server) addSyncer(ctx context.Context, ws workspace, companyID int32, target specsync.Target) (*specsync.Syncer, error) {
+	if companyID <= 0 || target.ID == "" {
+		return nil, fmt.Errorf("invalid parameters: companyID=%d, %s", companyID, target)
 	}
 
+	key := syncerKey(companyID, target)
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	key := fmt.Sprintf("%d-%s", companyID, taskID)
-	if _, ok := s.syncers[key]; ok {
-		return nil
+	running, ok := s.syncers[key]
+	syncerCtx := s.ctx
+	s.mu.Unlock()
+	if ok {
+		return running, nil
 	}
-	cwd, err := os.Getwd()
+
+	root, err := ws.dir()
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return nil, err
 	}
-	client := devplan.NewClient(devplan.Config{})
-	adapter := specsync.NewClientAdapter(client)
-	specsResp, err := client.GetTaskSpecs(companyID, taskID)
+	adapter := specsync.NewClientAdapter(s.apiClient())
+	fullDir, err := specsync.SpecsDir(ctx, adapter, companyID, target, root)
 	if err != nil {
-		return fmt.Errorf("failed to get task specs: %w", err)
+		return nil, toolError(err)
+	}
+	interval := specsync.DefaultSyncInterval
+	syncer := specsync.NewSyncer(adapter, companyID, target, fullDir, interval)
+	// The syncer outlives the tool call, and refreshes the patterns on its full runs
+	syncer.SetServerPatterns(specPatterns(syncerCtx, adapter, companyID))
+	if target.Kind == specsync.TargetTask {
+		syncer.OnChange(func(specs []specsync.Spec) { s.updateSpecResources(syncerCtx, target.ID, specs) })
 	}
 
-	taskDir := specsResp.GetPathsInfo().GetTaskPaths()[taskID].GetTaskDir()
-	if taskDir == "" {
-		return nil
+	s.mu.Lock()
+	if running, ok := s.syncers[key]; ok {
+		s.mu.Unlock()
+		return running, nil
 	}
-	fullTaskDir := filepath.Join(cwd, taskDir)
-	interval := specsync.DefaultSyncInterval
-	syncer := specsync.NewSyncer(adapter, companyID, taskID, fullTaskDir, interval)
 	s.syncers[key] = syncer
-	syncerCtx := context.Background()
+	s.mu.Unlock()
 	go syncer.RunBackground(syncerCtx)
-	go syncer.TriggerOnce(syncerCtx)
-	slog.Info("Started syncer", "companyID", companyID, "taskID", taskID)
-	return nil
+	slog.Info("Started syncer", "companyID", companyID, "target", target.String())
+	return syncer, nil
+}
+
+// specPatterns returns the patterns of the company deciding which files are specs. Failing to get them only
+// leaves out the files they include, so it is not an error.
+func specPatterns(ctx context.Context, adapter *specsync.ClientAdapter, companyID int32) string {
+	patterns, err := adapter.SpecPatterns(ctx, companyID)
+	if err != nil {
+		slog.Warn("Failed to get spec patterns", "companyID", companyID, "err", err)
+	}
+	return patterns
+}
+
+// discoverTaskSpecs finds the specs of a task in its specs directory by the same rules as the syncer
+func (s *Server) discoverTaskSpecs(ctx context.Context, companyID int32, taskID, dir string) []specsync.Spec {
+	rules, err := specsync.LoadRules(dir, specPatterns(ctx, specsync.NewClientAdapter(s.apiClient()), companyID))
+	if err != nil {
+		slog.Warn("Failed to load spec rules, using the defaults", "dir", dir, "err", err)
+		rules = specsync.DefaultRules()
+	}
+	// Missing task directories just mean the specs were written elsewhere
+	specs, _ := specsync.TaskTarget(taskID).DiscoverMatching(dir, rules)
+	return specs
+}
+
+// syncerList returns all syncers started so far
+func (s *Server) syncerList() []*specsync.Syncer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	syncers := make([]*specsync.Syncer, 0, len(s.syncers))
+	for _, syncer := range s.syncers {
+		syncers = append(syncers, syncer)
+	}
+	return syncers
+}
+
+// runningSyncer returns the syncer of the task or feature, or nil if it was not started
+func (s *Server) runningSyncer(companyID int32, target specsync.Target) *specsync.Syncer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncers[syncerKey(companyID, target)]
+}
+
+func syncerKey(companyID int32, target specsync.Target) string {
+	return fmt.Sprintf("%d-%s-%s", companyID, target.Kind, target.ID)
+}
+
+// toolError makes API errors actionable for the agent
+func toolError(err error) error {
+	if devplan.IsUnauthorized(err) {
+		return fmt.Errorf("%w. The Devplan API key is missing or revoked: ask the user to run `%s` in a terminal", err, devplan.ReauthCommand())
+	}
+	return err
 }
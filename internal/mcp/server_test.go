@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/devplaninc/devplan-cli/internal/utils/apicache"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown_FinalSyncAndFlush(t *testing.T) {
+	server, api := newTestServer(t)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/task/t1/specs", []byte(`{
+		"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}
+	}`)))
+	taskDir := filepath.Join(server.dir, "specs", "t1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	planFile := filepath.Join(taskDir, "plan.md")
+	require.NoError(t, os.WriteFile(planFile, []byte("# Plan"), 0644))
+
+	ctx := context.Background()
+	server.start(ctx)
+	_, err := server.addSyncer(ctx, server.workspace(ctx, nil), 1, specsync.TaskTarget("t1"))
+	require.NoError(t, err)
+	// Written right before the client goes away, before the next background run
+	require.NoError(t, os.WriteFile(planFile, []byte("# Plan v2"), 0644))
+	_, err = server.outbox.Add(1, worklog.WorkLogItem_builder{Message: "queued"}.Build())
+	require.NoError(t, err)
+
+	server.shutdown()
+
+	specs, err := api.Client().GetTaskSpecs(ctx, 1, "t1")
+	require.NoError(t, err)
+	require.Len(t, specs.GetSpecs(), 1)
+	assert.Equal(t, specsync.CalculateChecksumBytes([]byte("# Plan v2")), specs.GetSpecs()[0].GetChecksum())
+	assert.Equal(t, []string{"queued"}, worklogMessages(api.Worklog(1)))
+	items, err := server.outbox.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Error(t, server.ctx.Err(), "background work is stopped")
+}
+
+func TestAddSyncer_LooksUpWithoutBlockingOtherCalls(t *testing.T) {
+	server, _ := newTestServer(t)
+	requested := make(chan struct{}, 2)
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/dev/task/t1/specs") {
+			http.NotFound(w, r)
+			return
+		}
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}}`))
+	}))
+	t.Cleanup(api.Close)
+	server.client = devplan.NewClient(devplan.Config{
+		BaseURL: api.URL,
+		APIKey:  "test-key",
+		Retry:   &devplan.RetryPolicy{MaxAttempts: 1},
+		Cache:   apicache.New(t.TempDir()),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	server.start(ctx)
+
+	syncers := make(chan *specsync.Syncer, 2)
+	for range 2 {
+		go func() {
+			syncer, err := server.addSyncer(ctx, server.workspace(ctx, nil), 1, specsync.TaskTarget("t1"))
+			assert.NoError(t, err)
+			syncers <- syncer
+		}()
+	}
+	<-requested
+	// The specs directory is being looked up, which must not hold the lock other tools need
+	listed := make(chan []*specsync.Syncer)
+	go func() { listed <- server.syncerList() }()
+	select {
+	case list := <-listed:
+		assert.Empty(t, list)
+	case <-time.After(time.Second):
+		t.Fatal("syncerList blocked while a syncer was being added")
+	}
+
+	close(release)
+	first, second := <-syncers, <-syncers
+	require.NotNil(t, first)
+	assert.Same(t, first, second, "concurrent calls get the same syncer")
+	assert.Len(t, server.syncerList(), 1)
+}
@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func (s *Server) addSpecSyncTools() {
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "startSpecSync", Description: "Start syncing the spec files of a Devplan task or feature between the workspace and Devplan. Changed specs are uploaded as they are saved, and specs changed in Devplan are downloaded." + workspaceDefaultNote}, s.startSpecSync)
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "syncSpecsNow", Description: "Sync spec files of a Devplan task or feature right away and report what was uploaded, downloaded, deleted or renamed in the workspace, or is in conflict, e.g. after finishing a plan. Starts spec sync if needed." + workspaceDefaultNote}, s.syncSpecsNow)
+	mcp.AddTool(s.srv, &mcp.Tool{Name: "specSyncStatus", Description: "Show whether spec files reached Devplan: the last sync run, local changes not uploaded yet, conflicts and the last error."}, s.specSyncStatus)
+}
+
+type SpecSyncInput struct {
+	CompanyID int32  `json:"companyId,omitempty" jsonschema:"optional company identifier"`
+	TaskID    string `json:"taskId,omitempty" jsonschema:"optional task identifier"`
+	FeatureID string `json:"featureId,omitempty" jsonschema:"optional feature identifier, to sync feature specs instead of task specs"`
+}
+
+type StartSpecSyncOutput struct {
+	TaskID    string `json:"taskId,omitempty"`
+	FeatureID string `json:"featureId,omitempty"`
+	Dir       string `json:"dir"`
+	// AlreadyRunning is set if the specs of the task were synced before the call
+	AlreadyRunning bool `json:"alreadyRunning"`
+}
+
+type SyncSpecsOutput struct {
+	TaskID     string         `json:"taskId,omitempty"`
+	FeatureID  string         `json:"featureId,omitempty"`
+	Uploaded   int            `json:"uploaded"`
+	Downloaded int            `json:"downloaded"`
+	Skipped    int            `json:"skipped"`
+	Failed     int            `json:"failed"`
+	Errors     []string       `json:"errors,omitempty"`
+	Conflicts  []SpecConflict `json:"conflicts,omitempty"`
+	// Deleted holds the names of synced specs deleted in the workspace, which are kept in Devplan
+	Deleted []string     `json:"deleted,omitempty"`
+	Renamed []SpecRename `json:"renamed,omitempty"`
+	// Migrated holds the specs in subdirectories that older versions uploaded under their file name, now synced under their path
+	Migrated []SpecRename `json:"migrated,omitempty"`
+}
+
+// SpecRename is a synced spec renamed in the workspace. It is uploaded under the new name and the old one is kept in Devplan.
+type SpecRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SpecConflict is a spec changed both in the workspace and in Devplan, which is not synced until it is resolved
+type SpecConflict struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// CopyPath holds the Devplan version of the spec
+	CopyPath string `json:"copyPath,omitempty"`
+}
+
+type SpecSyncStatusInput struct {
+	TaskID    string `json:"taskId,omitempty" jsonschema:"optional task identifier. Defaults to all tasks and features being synced"`
+	FeatureID string `json:"featureId,omitempty" jsonschema:"optional feature identifier. Defaults to all tasks and features being synced"`
+}
+
+type SpecSyncStatusOutput struct {
+	Syncers []SpecSyncStatus `json:"syncers"`
+}
+
+type SpecSyncStatus struct {
+	CompanyID int32  `json:"companyId"`
+	TaskID    string `json:"taskId,omitempty"`
+	FeatureID string `json:"featureId,omitempty"`
+	Dir       string `json:"dir"`
+	// LastRun is when the last sync run finished, empty if none finished yet
+	LastRun     string         `json:"lastRun,omitempty"`
+	Uploaded    int            `json:"uploaded"`
+	Downloaded  int            `json:"downloaded"`
+	Skipped     int            `json:"skipped"`
+	Failed      int            `json:"failed"`
+	LastError   string         `json:"lastError,omitempty"`
+	LastErrorAt string         `json:"lastErrorAt,omitempty"`
+	Pending     []string       `json:"pending,omitempty"`
+	Conflicts   []SpecConflict `json:"conflicts,omitempty"`
+	// Watching is true when files are uploaded as they are saved, false when the directory is polled
+	Watching bool `json:"watching"`
+}
+
+// specSyncTarget resolves the task or feature whose specs are synced. Task specs are synced unless a feature
+// is requested or the workspace has no task. Specs are read from the workspace, so IDs contradicting it are rejected.
+func specSyncTarget(ws workspace, input SpecSyncInput) (int32, specsync.Target, error) {
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return 0, specsync.Target{}, err
+	}
+	if input.FeatureID != "" || (input.TaskID == "" && ws.TaskID == "" && ws.FeatureID != "") {
+		featureID, err := ws.matchWorkspace(input.FeatureID, ws.FeatureID, "featureId")
+		return companyID, specsync.FeatureTarget(featureID), err
+	}
+	taskID, err := ws.matchWorkspace(input.TaskID, ws.TaskID, "taskId")
+	if err != nil {
+		return 0, specsync.Target{}, err
+	}
+	taskID, err = orDefault(taskID, "", "taskId")
+	return companyID, specsync.TaskTarget(taskID), err
+}
+
+// targetIDs returns the task and feature IDs of the target, one of them empty
+func targetIDs(target specsync.Target) (taskID, featureID string) {
+	if target.Kind == specsync.TargetFeature {
+		return "", target.ID
+	}
+	return target.ID, ""
+}
+
+func (s *Server) startSpecSync(ctx context.Context, req *mcp.CallToolRequest, input SpecSyncInput) (*mcp.CallToolResult, StartSpecSyncOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, target, err := specSyncTarget(ws, input)
+	if err != nil {
+		return nil, StartSpecSyncOutput{}, err
+	}
+	running := s.runningSyncer(companyID, target) != nil
+	syncer, err := s.addSyncer(ctx, ws, companyID, target)
+	if err != nil {
+		return nil, StartSpecSyncOutput{}, err
+	}
+	out := StartSpecSyncOutput{Dir: syncer.Status().Dir, AlreadyRunning: running}
+	out.TaskID, out.FeatureID = targetIDs(target)
+	md := fmt.Sprintf("Spec sync of %s `%s` started, specs in %s are uploaded as they change.", target.Kind, target.ID, out.Dir)
+	if running {
+		md = fmt.Sprintf("Spec sync of %s `%s` is already running, specs in %s are uploaded as they change.", target.Kind, target.ID, out.Dir)
+	}
+	return markdownResult(md), out, nil
+}
+
+func (s *Server) syncSpecsNow(ctx context.Context, req *mcp.CallToolRequest, input SpecSyncInput) (*mcp.CallToolResult, SyncSpecsOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, target, err := specSyncTarget(ws, input)
+	if err != nil {
+		return nil, SyncSpecsOutput{}, err
+	}
+	syncer, err := s.addSyncer(ctx, ws, companyID, target)
+	if err != nil {
+		return nil, SyncSpecsOutput{}, err
+	}
+	result := syncer.SyncNow(ctx)
+	out := SyncSpecsOutput{
+		Uploaded:   result.Uploaded,
+		Downloaded: result.Downloaded,
+		Skipped:    result.Skipped,
+		Failed:     result.Failed,
+		Conflicts:  toSpecConflicts(result.Conflicts),
+		Deleted:    result.Deleted,
+	}
+	for _, r := range result.Renamed {
+		out.Renamed = append(out.Renamed, SpecRename{From: r.From, To: r.To})
+	}
+	for _, r := range result.Migrated {
+		out.Migrated = append(out.Migrated, SpecRename{From: r.From, To: r.To})
+	}
+	out.TaskID, out.FeatureID = targetIDs(target)
+	for _, err := range result.Errors {
+		out.Errors = append(out.Errors, toolError(err).Error())
+	}
+	md := fmt.Sprintf("Synced specs of %s `%s`: %d uploaded, %d downloaded, %d unchanged, %d failed.",
+		target.Kind, target.ID, out.Uploaded, out.Downloaded, out.Skipped, out.Failed)
+	for _, e := range out.Errors {
+		md += "\n- " + e
+	}
+	for _, name := range out.Deleted {
+		md += fmt.Sprintf("\n- `%s` was deleted in the workspace and is kept in Devplan.", name)
+	}
+	for _, r := range out.Renamed {
+		md += fmt.Sprintf("\n- `%s` was renamed to `%s` in the workspace, `%s` is kept in Devplan.", r.From, r.To, r.From)
+	}
+	for _, r := range out.Migrated {
+		md += fmt.Sprintf("\n- `%s` is synced under its path, the spec named `%s` by an older version is kept in Devplan.", r.To, r.From)
+	}
+	md += conflictsMarkdown(out.Conflicts)
+	return markdownResult(md), out, nil
+}
+
+func (s *Server) specSyncStatus(_ context.Context, _ *mcp.CallToolRequest, input SpecSyncStatusInput) (*mcp.CallToolResult, SpecSyncStatusOutput, error) {
+	out := SpecSyncStatusOutput{Syncers: []SpecSyncStatus{}}
+	for _, syncer := range s.syncerList() {
+		status := toSpecSyncStatus(syncer.Status())
+		if (input.TaskID != "" && status.TaskID != input.TaskID) || (input.FeatureID != "" && status.FeatureID != input.FeatureID) {
+			continue
+		}
+		out.Syncers = append(out.Syncers, status)
+	}
+	sort.Slice(out.Syncers, func(i, j int) bool {
+		a, b := out.Syncers[i], out.Syncers[j]
+		return a.FeatureID+a.TaskID < b.FeatureID+b.TaskID
+	})
+	return markdownResult(specSyncStatusMarkdown(out.Syncers)), out, nil
+}
+
+func toSpecSyncStatus(status specsync.Status) SpecSyncStatus {
+	out := SpecSyncStatus{
+		CompanyID: status.CompanyID,
+		Dir:       status.Dir,
+		Pending:   status.Pending,
+		Watching:  status.Watching,
+	}
+	out.TaskID, out.FeatureID = targetIDs(status.Target)
+	if !status.LastRun.IsZero() {
+		out.LastRun = status.LastRun.Format(time.RFC3339)
+	}
+	if r := status.LastResult; r != nil {
+		out.Uploaded, out.Downloaded, out.Skipped, out.Failed = r.Uploaded, r.Downloaded, r.Skipped, r.Failed
+	}
+	out.Conflicts = toSpecConflicts(status.Conflicts)
+	if status.LastError != nil {
+		out.LastError = toolError(status.LastError).Error()
+		out.LastErrorAt = status.LastErrorAt.Format(time.RFC3339)
+	}
+	return out
+}
+
+func specSyncStatusMarkdown(syncers []SpecSyncStatus) string {
+	if len(syncers) == 0 {
+		return "Spec sync is not running. Call startSpecSync to start it."
+	}
+	var sb strings.Builder
+	for _, st := range syncers {
+		if st.FeatureID != "" {
+			fmt.Fprintf(&sb, "## Feature `%s`\n\n", st.FeatureID)
+		} else {
+			fmt.Fprintf(&sb, "## Task `%s`\n\n", st.TaskID)
+		}
+		fmt.Fprintf(&sb, "Specs directory: %s\n", st.Dir)
+		if st.Watching {
+			sb.WriteString("Mode: uploading files as they are saved\n")
+		} else {
+			sb.WriteString("Mode: polling the directory\n")
+		}
+		if st.LastRun == "" {
+			sb.WriteString("Last run: not finished yet\n")
+		} else {
+			fmt.Fprintf(&sb, "Last run: %s, %d uploaded, %d downloaded, %d unchanged, %d failed\n",
+				st.LastRun, st.Uploaded, st.Downloaded, st.Skipped, st.Failed)
+		}
+		if len(st.Pending) == 0 {
+			sb.WriteString("Pending changes: none\n")
+		} else {
+			fmt.Fprintf(&sb, "Pending changes: %s\n", strings.Join(st.Pending, ", "))
+		}
+		if st.LastError != "" {
+			fmt.Fprintf(&sb, "Last error at %s: %s\n", st.LastErrorAt, st.LastError)
+		}
+		sb.WriteString(conflictsMarkdown(st.Conflicts))
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func toSpecConflicts(conflicts []specsync.Conflict) []SpecConflict {
+	var result []SpecConflict
+	for _, c := range conflicts {
+		result = append(result, SpecConflict{Name: c.Name, Path: c.Path, CopyPath: c.CopyPath})
+	}
+	return result
+}
+
+// conflictsMarkdown lists conflicts with how to resolve them
+func conflictsMarkdown(conflicts []SpecConflict) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nConflicts, changed both in the workspace and in Devplan and not synced:\n")
+	for _, c := range conflicts {
+		if c.CopyPath != "" {
+			fmt.Fprintf(&sb, "- `%s`: the Devplan version is in %s. Merge it into %s and delete the copy to upload the spec.\n", c.Name, c.CopyPath, c.Path)
+		} else {
+			fmt.Fprintf(&sb, "- `%s` at %s. Run `devplan spec sync --on-conflict local` or `server` in a terminal to keep one version.\n", c.Name, c.Path)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devplaninc/devplan-cli/internal/specsync"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecSyncTools(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/task/t1/specs", []byte(`{
+		"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}
+	}`)))
+	taskDir := filepath.Join(server.dir, "specs", "t1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	planFile := filepath.Join(taskDir, "plan.md")
+	require.NoError(t, os.WriteFile(planFile, []byte("# Plan"), 0644))
+
+	md, status := callTool[SpecSyncStatusOutput](t, session, "specSyncStatus", nil)
+	assert.Empty(t, status.Syncers)
+	assert.Contains(t, md, "startSpecSync")
+
+	_, synced := callTool[SyncSpecsOutput](t, session, "syncSpecsNow", nil)
+	assert.Equal(t, "t1", synced.TaskID)
+	assert.Zero(t, synced.Failed)
+	assert.Equal(t, 1, synced.Uploaded+synced.Skipped, "the first run of the syncer may upload the spec before")
+	assert.Len(t, api.RequestsTo(http.MethodPost, "/api/v1/company/1/dev/task/t1/specs"), 1)
+
+	require.NoError(t, os.WriteFile(planFile, []byte("# Plan v2"), 0644))
+	md, status = callTool[SpecSyncStatusOutput](t, session, "specSyncStatus", map[string]any{"taskId": "t1"})
+	require.Len(t, status.Syncers, 1)
+	assert.Equal(t, []string{"plan.md"}, status.Syncers[0].Pending)
+	assert.NotEmpty(t, status.Syncers[0].LastRun)
+	assert.Contains(t, md, "Pending changes: plan.md")
+
+	_, synced = callTool[SyncSpecsOutput](t, session, "syncSpecsNow", nil)
+	assert.Equal(t, 1, synced.Uploaded)
+	_, status = callTool[SpecSyncStatusOutput](t, session, "specSyncStatus", nil)
+	require.Len(t, status.Syncers, 1)
+	assert.Empty(t, status.Syncers[0].Pending)
+
+	_, started := callTool[StartSpecSyncOutput](t, session, "startSpecSync", nil)
+	assert.True(t, started.AlreadyRunning)
+	assert.Equal(t, taskDir, started.Dir)
+}
+
+func TestSyncSpecsNow_DownloadsAndReportsConflicts(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/task/t1/specs", []byte(fmt.Sprintf(`{
+		"specs": [{"name": "plan.md", "checksum": %q}, {"name": "review.md", "checksum": %q}],
+		"pathsInfo": {"taskPaths": {"t1": {"taskDir": "specs/t1"}}}
+	}`, specsync.CalculateChecksumBytes([]byte("# Plan theirs")), specsync.CalculateChecksumBytes([]byte("# Review"))))))
+	api.Fixtures.SetTaskSpec(1, "t1", company.UploadSpecRequest_builder{Name: "plan.md", Content: "# Plan theirs"}.Build())
+	api.Fixtures.SetTaskSpec(1, "t1", company.UploadSpecRequest_builder{Name: "review.md", Content: "# Review"}.Build())
+	taskDir := filepath.Join(server.dir, "specs", "t1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "plan.md"), []byte("# Plan mine"), 0644))
+
+	md, synced := callTool[SyncSpecsOutput](t, session, "syncSpecsNow", nil)
+	assert.Zero(t, synced.Uploaded, "the conflicting spec is not uploaded")
+	require.Len(t, synced.Conflicts, 1)
+	assert.Equal(t, "plan.md", synced.Conflicts[0].Name)
+	assert.Contains(t, md, "plan.md.conflict")
+	assert.Empty(t, api.RequestsTo(http.MethodPost, "/api/v1/company/1/dev/task/t1/specs"))
+
+	data, err := os.ReadFile(filepath.Join(taskDir, "review.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Review", string(data), "specs only in Devplan are downloaded")
+	data, err = os.ReadFile(filepath.Join(taskDir, "plan.md"+specsync.ConflictSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan theirs", string(data))
+
+	_, status := callTool[SpecSyncStatusOutput](t, session, "specSyncStatus", nil)
+	require.Len(t, status.Syncers, 1)
+	assert.Len(t, status.Syncers[0].Conflicts, 1)
+}
+
+func TestStartSpecSync_Errors(t *testing.T) {
+	session, _, _ := newTestSession(t, nil)
+	ctx := context.Background()
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "startSpecSync", Arguments: map[string]any{"taskId": "t2"}})
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "does not match")
+
+	res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "startSpecSync"})
+	require.NoError(t, err)
+	assert.True(t, res.IsError, "the task has no spec directory")
+	assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "no spec directory")
+}
+
+func TestFeatureSpecSync(t *testing.T) {
+	session, server, api := newTestSession(t, nil)
+	require.NoError(t, api.Fixtures.SetJSON("company/1/dev/user-story/f1/specs", []byte(`{
+		"pathsInfo": {"taskPaths": {"f1": {"taskDir": "specs/f1"}}}
+	}`)))
+	featureDir := filepath.Join(server.dir, "specs", "f1")
+	require.NoError(t, os.MkdirAll(filepath.Join(featureDir, "t1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "design.md"), []byte("# Design"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "t1", "plan.md"), []byte("# Plan"), 0644))
+
+	callTool[FeatureWorkLogReportOutput](t, session, "reportFeatureWorkLog", map[string]any{"message": "planning"})
+	require.NotNil(t, server.runningSyncer(1, specsync.FeatureTarget("f1")), "the feature worklog starts the feature syncer")
+
+	_, synced := callTool[SyncSpecsOutput](t, session, "syncSpecsNow", map[string]any{"featureId": "f1"})
+	assert.Equal(t, "f1", synced.FeatureID)
+	assert.Empty(t, synced.TaskID)
+	assert.Zero(t, synced.Failed)
+	specs, err := api.Client().GetFeatureSpecs(context.Background(), 1, "f1")
+	require.NoError(t, err)
+	require.Len(t, specs.GetSpecs(), 1, "task specs in subdirectories are not feature specs")
+	assert.Equal(t, "design.md", specs.GetSpecs()[0].GetName())
+
+	md, status := callTool[SpecSyncStatusOutput](t, session, "specSyncStatus", map[string]any{"featureId": "f1"})
+	require.Len(t, status.Syncers, 1)
+	assert.Equal(t, featureDir, status.Syncers[0].Dir)
+	assert.Contains(t, md, "## Feature `f1`")
+
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "syncSpecsNow", Arguments: map[string]any{"featureId": "f2"}})
+	require.NoError(t, err)
+	assert.True(t, res.IsError, "the feature contradicts the workspace")
+}
@@ -5,7 +5,7 @@ import (
 	"log/slog"
 	"strings"
 
-	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/specsync"
 	"github.com/devplaninc/devplan-cli/internal/utils/recentactivity"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -13,8 +13,8 @@ import (
 
 type WorkLogReportInput struct {
 	Message           string `json:"message" jsonschema:"message describing what happened"`
-	TaskID            string `json:"taskId,omitempty" jsonschema:"optional task identifier"`
-	CompanyID         int32  `json:"companyId,omitempty" jsonschema:"required company identifier"`
+	TaskID            string `json:"taskId,omitempty" jsonschema:"optional task identifier, defaults to the task of the workspace"`
+	CompanyID         int32  `json:"companyId,omitempty" jsonschema:"company identifier, defaults to the company of the workspace"`
 	Type              string `json:"type,omitempty" jsonschema:"optional worklog type. If present should be one of 'full_workflow', 'research', 'planning', 'coding', 'review', 'address_review', 'analysis', 'commit', 'finalize'"`
 	Stage             string `json:"stage,omitempty" jsonschema:"optional worklog stage. If present should be one of 'started', 'running', 'ended', 'error'"`
 	ActionDescription string `json:"actionDescription,omitempty" jsonschema:"description of the action. Should be a 1-2 words description of what this work lof is about"`
@@ -24,8 +24,16 @@ type WorkLogReportInput struct {
 type WorkLogReportOutput struct {
 }
 
-func (s *Server) reportWorkLog(ctx context.Context, _ *mcp.CallToolRequest, input WorkLogReportInput) (*mcp.CallToolResult, WorkLogReportOutput, error) {
-	cl := devplan.NewClient(devplan.Config{})
+func (s *Server) reportWorkLog(ctx context.Context, req *mcp.CallToolRequest, input WorkLogReportInput) (*mcp.CallToolResult, WorkLogReportOutput, error) {
+	ws := s.workspace(ctx, req.Session)
+	companyID, err := ws.companyID(input.CompanyID)
+	if err != nil {
+		return nil, WorkLogReportOutput{}, err
+	}
+	taskID, err := ws.matchWorkspace(input.TaskID, ws.TaskID, "taskId")
+	if err != nil {
+		return nil, WorkLogReportOutput{}, err
+	}
 	wlType := getWorkloadType(input.Type)
 	customType := ""
 	if wlType == worklog.WorkLogType_WORK_LOG_TYPE_UNSPECIFIED {
@@ -33,29 +41,30 @@ func (s *Server) reportWorkLog(ctx context.Context, _ *mcp.CallToolRequest, inpu
 	}
 	item := worklog.WorkLogItem_builder{
 		Message:           input.Message,
-		CompanyId:         &input.CompanyID,
-		TaskId:            &input.TaskID,
+		CompanyId:         &companyID,
+		TaskId:            &taskID,
 		Type:              wlType,
 		CustomType:        customType,
 		Stage:             input.Stage,
 		ActionDescription: input.ActionDescription,
 		AgentName:         input.AgentName,
 	}.Build()
-	_, err := cl.SubmitWorklogItem(input.CompanyID, item)
+	result, err := s.submitWorklog(ctx, companyID, item)
 	slog.Info("Reporting worklog item", "item", item)
 
 	// Initialize syncer lazily on first MCP call with valid company/task IDs
-	if input.CompanyID > 0 && input.TaskID != "" {
+	if taskID != "" {
 		// Note: We don't fail the worklog submission if syncer initialization fails
-		_ = s.addSyncer(ctx, input.CompanyID, input.TaskID)
-	}
-	if input.TaskID != "" {
-		if err := recentactivity.RecordTaskActivity(input.TaskID, "worklog_upload"); err != nil {
-			slog.Warn("Failed to record recent task activity", "taskID", input.TaskID, "err", err)
+		_, _ = s.addSyncer(ctx, ws, companyID, specsync.TaskTarget(taskID))
+		if err := recentactivity.RecordTaskActivity(taskID, "worklog_upload"); err != nil {
+			slog.Warn("Failed to record recent task activity", "taskID", taskID, "err", err)
 		}
 	}
 
-	return nil, WorkLogReportOutput{}, err
+	if err != nil {
+		return nil, WorkLogReportOutput{}, err
+	}
+	return result, WorkLogReportOutput{}, nil
 }
 
 func getWorkloadType(wlType string) worklog.WorkLogType {
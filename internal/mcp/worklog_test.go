@@ -1,9 +1,14 @@
 package mcp
 
 import (
+	"context"
 	"testing"
 
+	"github.com/devplaninc/devplan-cli/internal/utils/metadata"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetWorkloadType_KnownValuesCaseInsensitive(t *testing.T) {
@@ -39,3 +44,63 @@ func TestGetWorkloadType_UnknownDefaultsToUnspecified(t *testing.T) {
 		}
 	}
 }
+
+func TestReportWorkLog_DefaultsToWorkspace(t *testing.T) {
+	session, _, api := newTestSession(t, nil)
+	t.Setenv("DEVPLAN_LAST_COMPANY_ID", "2")
+
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "reportWorkLog",
+		Arguments: map[string]any{"message": "started"},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	items := api.Worklog(1)
+	require.Len(t, items, 1, "the company of the workspace wins over the last used one")
+	assert.Equal(t, "t1", items[0].GetTaskId())
+
+	res, err = session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "reportFeatureWorkLog",
+		Arguments: map[string]any{"message": "planned"},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	items = api.Worklog(1)
+	require.Len(t, items, 2)
+	assert.Equal(t, "f1", items[1].GetFeatureId())
+}
+
+func TestReportWorkLog_RejectsContradictingIDs(t *testing.T) {
+	session, _, api := newTestSession(t, nil)
+
+	for _, tc := range []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"reportWorkLog", map[string]any{"taskId": "t2"}, "taskId t2 does not match t1"},
+		{"reportWorkLog", map[string]any{"companyId": 2}, "companyId 2 does not match company 1"},
+		{"reportFeatureWorkLog", map[string]any{"featureId": "f2"}, "featureId f2 does not match f1"},
+	} {
+		tc.args["message"] = "started"
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: tc.tool, Arguments: tc.args})
+		require.NoError(t, err)
+		assert.True(t, res.IsError, tc.want)
+		assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, tc.want)
+	}
+	assert.Empty(t, api.Worklog(1))
+}
+
+func TestWorkspace_CompanyFromLastUsedOutsideMetadata(t *testing.T) {
+	server, _ := newTestServer(t)
+	require.NoError(t, metadata.WriteMetadata(server.dir, metadata.Metadata{TaskID: "t1"}))
+
+	ws := server.workspace(context.Background(), nil)
+	assert.False(t, ws.CompanyFromMeta)
+	companyID, err := ws.companyID(0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), companyID)
+	companyID, err = ws.companyID(2)
+	require.NoError(t, err, "without a company in the metadata any company is accepted")
+	assert.Equal(t, int32(2), companyID)
+}
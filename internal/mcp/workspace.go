@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/metadata"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// workspace holds the IDs of the Devplan workspace the server runs in. They are used when the agent omits them.
+type workspace struct {
+	// Dir is the directory holding .devplan_meta, or the working directory outside a workspace.
+	// It is empty for HTTP sessions whose client shares no workspace root.
+	Dir       string
+	CompanyID int32
+	// CompanyFromMeta is set when CompanyID comes from the workspace metadata rather than the last used company
+	CompanyFromMeta bool
+	ProjectID       string
+	FeatureID       string
+	TaskID          string
+	RepoName        string
+}
+
+// workspace returns the workspace of the session. Over stdio it is the working directory of the process.
+// Over HTTP the process serves agents in different workspaces, so it is the root shared by the client
+// of the session, and nothing is inferred if there is none: the agent has to pass the IDs.
+func (s *Server) workspace(ctx context.Context, session *mcp.ServerSession) workspace {
+	var dir string
+	var err error
+	if s.sessionRoots {
+		dir, err = sessionRoot(ctx, session)
+		if err != nil {
+			slog.Warn("Failed to get workspace root of the session", "err", err)
+		}
+		if dir == "" {
+			return workspace{}
+		}
+	} else if dir, err = s.workDir(); err != nil {
+		slog.Warn("Failed to get workspace directory", "err", err)
+		return workspace{CompanyID: prefs.GetLastCompanyID()}
+	}
+	ws := workspace{Dir: dir, CompanyID: prefs.GetLastCompanyID()}
+	meta, metaDir, err := metadata.FindMetadata(dir)
+	if err != nil {
+		slog.Warn("Failed to read workspace metadata", "dir", dir, "err", err)
+	}
+	if meta == nil {
+		return ws
+	}
+	ws.Dir = metaDir
+	if meta.CompanyID > 0 {
+		ws.CompanyID = meta.CompanyID
+		ws.CompanyFromMeta = true
+	}
+	ws.ProjectID = meta.ProjectID
+	ws.FeatureID = meta.StoryID
+	ws.TaskID = meta.TaskID
+	ws.RepoName = meta.RepoName
+	return ws
+}
+
+// sessionRoot returns the workspace root shared by the client of the session, or "" if it shares none.
+// Of several roots, the one inside a Devplan workspace is used.
+func sessionRoot(ctx context.Context, session *mcp.ServerSession) (string, error) {
+	if session == nil {
+		return "", nil
+	}
+	if params := session.InitializeParams(); params == nil || params.Capabilities == nil || params.Capabilities.RootsV2 == nil {
+		return "", nil
+	}
+	res, err := session.ListRoots(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to list roots: %w", err)
+	}
+	var dirs []string
+	for _, root := range res.Roots {
+		u, err := url.Parse(root.URI)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			slog.Warn("Ignoring workspace root", "uri", root.URI)
+			continue
+		}
+		dirs = append(dirs, filepath.FromSlash(u.Path))
+	}
+	if len(dirs) == 1 {
+		return dirs[0], nil
+	}
+	for _, dir := range dirs {
+		if meta, _, _ := metadata.FindMetadata(dir); meta != nil {
+			return dir, nil
+		}
+	}
+	return "", nil
+}
+
+// dir returns the workspace directory, which local specs are read from
+func (w workspace) dir() (string, error) {
+	if w.Dir == "" {
+		return "", fmt.Errorf("the workspace directory is unknown: the MCP client has to share its workspace root")
+	}
+	return w.Dir, nil
+}
+
+// companyID returns id, or the workspace company if id is not set.
+// A workspace belongs to a single company, so an id contradicting its metadata is rejected.
+func (w workspace) companyID(id int32) (int32, error) {
+	if id > 0 {
+		if w.CompanyFromMeta && id != w.CompanyID {
+			return 0, fmt.Errorf("companyId %d does not match company %d of the workspace at %s", id, w.CompanyID, w.Dir)
+		}
+		return id, nil
+	}
+	if w.CompanyID > 0 {
+		return w.CompanyID, nil
+	}
+	return 0, fmt.Errorf("companyId is required, it could not be determined from the workspace")
+}
+
+// orDefault returns value, or the workspace default if value is empty
+func orDefault(value, workspaceValue, name string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if workspaceValue != "" {
+		return workspaceValue, nil
+	}
+	return "", fmt.Errorf("%s is required, it could not be determined from the workspace", name)
+}
+
+// matchWorkspace returns value, or the workspace default if value is empty, and rejects a value contradicting
+// the workspace. It is used when writing data, e.g. worklog entries, so they are not reported under another task.
+func (w workspace) matchWorkspace(value, workspaceValue, name string) (string, error) {
+	if value == "" {
+		return workspaceValue, nil
+	}
+	if workspaceValue != "" && value != workspaceValue {
+		return "", fmt.Errorf("%s %s does not match %s of the workspace at %s", name, value, workspaceValue, w.Dir)
+	}
+	return value, nil
+}
@@ -1,6 +1,9 @@
 package specsync
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/devplaninc/devplan-cli/internal/devplan"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
 )
@@ -15,10 +18,35 @@ func NewClientAdapter(client *devplan.Client) *ClientAdapter {
 	return &ClientAdapter{client: client}
 }
 
-func (a *ClientAdapter) GetTaskSpecs(companyID int32, taskID string) (*company.GetTaskSpecsResponse, error) {
-	return a.client.GetTaskSpecs(companyID, taskID)
+func (a *ClientAdapter) GetSpecs(ctx context.Context, companyID int32, target Target) (*company.GetTaskSpecsResponse, error) {
+	if target.Kind == TargetFeature {
+		return a.client.GetFeatureSpecs(ctx, companyID, target.ID)
+	}
+	return a.client.GetTaskSpecs(ctx, companyID, target.ID)
+}
+
+func (a *ClientAdapter) GetSpec(ctx context.Context, companyID int32, target Target, name string) (*company.UploadSpecRequest, error) {
+	if target.Kind == TargetFeature {
+		return a.client.GetFeatureSpec(ctx, companyID, target.ID, name)
+	}
+	return a.client.GetTaskSpec(ctx, companyID, target.ID, name)
+}
+
+func (a *ClientAdapter) UploadSpec(ctx context.Context, companyID int32, target Target, req *company.UploadSpecRequest) error {
+	if target.Kind == TargetFeature {
+		return a.client.UploadFeatureSpec(ctx, companyID, target.ID, req)
+	}
+	return a.client.UploadTaskSpec(ctx, companyID, target.ID, req)
 }
 
-func (a *ClientAdapter) UploadTaskSpec(companyID int32, taskID string, req *company.UploadSpecRequest) error {
-	return a.client.UploadTaskSpec(companyID, taskID, req)
+// SpecPatterns returns the patterns of PatternsRule of the company, empty if the company has none
+func (a *ClientAdapter) SpecPatterns(ctx context.Context, companyID int32) (string, error) {
+	resp, err := a.client.GetDevRule(ctx, companyID, PatternsRule)
+	if err != nil {
+		if devplan.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get spec patterns: %w", err)
+	}
+	return resp.GetRule(), nil
 }
@@ -5,42 +5,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"golang.org/x/exp/slices"
 )
 
-var inputSpecs = []string{"prd.md", "tech_brief.md", "requirements.md", "instructions.md", "dependencies.md"}
-
 // DiscoverTaskSpecs walks the specs directory and finds all artifact files
 func DiscoverTaskSpecs(taskDir string) ([]Spec, error) {
-	var specs []Spec
-
-	err := filepath.Walk(taskDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories and hidden files
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip hidden files
-		if strings.HasPrefix(info.Name(), ".") {
-			return nil
-		}
-		if slices.Contains(inputSpecs, info.Name()) {
-			return nil
-		}
+	return TaskTarget("").Discover(taskDir)
+}
 
-		// Only include markdown files as specs
-		if !strings.HasSuffix(info.Name(), ".md") {
-			return nil
-		}
+// DiscoverFeatureSpecs finds the artifact files of a feature. Only files directly in the feature directory
+// are feature specs: subdirectories hold the specs of its tasks, which are synced by their own syncers.
+func DiscoverFeatureSpecs(featureDir string) ([]Spec, error) {
+	return FeatureTarget("").Discover(featureDir)
+}
 
+func discoverSpecs(dir string, recursive bool, rules *Rules) ([]Spec, error) {
+	var specs []Spec
+	err := walkSpecs(dir, recursive, rules, func(path, rel string, _ os.FileInfo) error {
 		// Calculate checksum
 		checksum, data, err := calculateChecksum(path)
 		if err != nil {
@@ -48,18 +28,54 @@ func DiscoverTaskSpecs(taskDir string) ([]Spec, error) {
 		}
 
 		specs = append(specs, Spec{
-			Name:     info.Name(),
+			Name:     rel,
 			Path:     path,
 			Checksum: checksum,
 			Content:  data,
 		})
-
 		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk specs directory %s: %w", taskDir, err)
+		return nil, err
 	}
-
 	return specs, nil
 }
+
+// walkSpecs calls fn for every file in dir that is a spec according to the rules
+func walkSpecs(dir string, recursive bool, rules *Rules, fn func(path, rel string, info os.FileInfo) error) error {
+	return walkFiles(dir, recursive, func(path, rel string, info os.FileInfo) error {
+		if spec, _ := rules.Match(rel); !spec {
+			return nil
+		}
+		return fn(path, rel, info)
+	})
+}
+
+// walkFiles calls fn for every file in dir with its slash-separated path relative to dir, skipping hidden directories
+// and, unless recursive, subdirectories
+func walkFiles(dir string, recursive bool, fn func(path, rel string, info os.FileInfo) error) error {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip hidden directories
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") || (!recursive && path != dir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(rel), info)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to walk specs directory %s: %w", dir, err)
+	}
+	return nil
+}
@@ -58,7 +58,7 @@ func TestDiscoverSpecs(t *testing.T) {
 		for _, s := range specs {
 			names[s.Name] = true
 		}
-		assert.True(t, names["plan.md"])
+		assert.True(t, names["STORY-123/TASK-456/plan.md"], "nested specs are named by their path")
 	})
 
 	t.Run("ignores non-markdown files", func(t *testing.T) {
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	gosync "sync"
 	"sync/atomic"
 	"testing"
@@ -31,6 +34,7 @@ type testServer struct {
 	server      *httptest.Server
 	specs       []serverSpec
 	uploads     map[string]string // name -> content
+	uploadPaths []string
 	uploadCount atomic.Int32
 	mu          gosync.Mutex
 }
@@ -45,6 +49,21 @@ func newTestServer() *testServer {
 
 	// Specs endpoint
 	mux.HandleFunc("/api/v1/company/", func(w http.ResponseWriter, r *http.Request) {
+		// GET of a single spec returns its uploaded content
+		if r.Method == "GET" && strings.HasSuffix(path.Dir(r.URL.Path), "/specs") {
+			name := path.Base(r.URL.Path)
+			ts.mu.Lock()
+			content, ok := ts.uploads[name]
+			ts.mu.Unlock()
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"name": name, "content": content, "checksum": CalculateChecksumBytes([]byte(content))})
+			return
+		}
+
 		// GET returns existing specs
 		if r.Method == "GET" {
 			ts.mu.Lock()
@@ -71,6 +90,7 @@ func newTestServer() *testServer {
 			}
 			ts.mu.Lock()
 			ts.uploads[req.Name] = req.Content
+			ts.uploadPaths = append(ts.uploadPaths, r.URL.Path)
 			ts.mu.Unlock()
 			ts.uploadCount.Add(1)
 			w.WriteHeader(http.StatusOK)
@@ -93,8 +113,16 @@ type testClient struct {
 	baseURL string
 }
 
-func (c *testClient) GetTaskSpecs(companyID int32, taskID string) (*company.GetTaskSpecsResponse, error) {
-	resp, err := http.Get(c.baseURL + "/api/v1/company/1/dev/task/task-123/specs")
+// specsPath returns the API path of the specs of the target
+func specsPath(target Target) string {
+	if target.Kind == TargetFeature {
+		return "/api/v1/company/1/dev/user-story/" + target.ID + "/specs"
+	}
+	return "/api/v1/company/1/dev/task/" + target.ID + "/specs"
+}
+
+func (c *testClient) GetSpecs(_ context.Context, companyID int32, target Target) (*company.GetTaskSpecsResponse, error) {
+	resp, err := http.Get(c.baseURL + specsPath(target))
 	if err != nil {
 		return nil, err
 	}
@@ -120,7 +148,27 @@ func (c *testClient) GetTaskSpecs(companyID int32, taskID string) (*company.GetT
 	return company.GetTaskSpecsResponse_builder{Specs: specs}.Build(), nil
 }
 
-func (c *testClient) UploadTaskSpec(companyID int32, taskID string, req *company.UploadSpecRequest) error {
+func (c *testClient) GetSpec(_ context.Context, companyID int32, target Target, name string) (*company.UploadSpecRequest, error) {
+	resp, err := http.Get(c.baseURL + specsPath(target) + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var body struct {
+		Name     string `json:"name"`
+		Content  string `json:"content"`
+		Checksum string `json:"checksum"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return company.UploadSpecRequest_builder{Name: body.Name, Content: body.Content, Checksum: body.Checksum}.Build(), nil
+}
+
+func (c *testClient) UploadSpec(_ context.Context, companyID int32, target Target, req *company.UploadSpecRequest) error {
 	body := struct {
 		Name     string `json:"name"`
 		Content  string `json:"content"`
@@ -135,7 +183,7 @@ func (c *testClient) UploadTaskSpec(companyID int32, taskID string, req *company
 		return err
 	}
 
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/company/1/dev/task/task-123/specs", bytes.NewReader(data))
+	httpReq, err := http.NewRequest("POST", c.baseURL+specsPath(target), bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
@@ -184,7 +232,7 @@ func TestIntegration_FullSyncFlow(t *testing.T) {
 
 	// Create client and syncer
 	client := &testClient{baseURL: ts.server.URL}
-	syncer := NewSyncer(client, 1, "task-123", specsDir, time.Second)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
 
 	// Run sync
 	result := syncer.TriggerOnce(context.Background())
@@ -216,7 +264,7 @@ func TestIntegration_BackgroundSyncLifecycle(t *testing.T) {
 
 	// Create client and syncer with short interval
 	client := &testClient{baseURL: ts.server.URL}
-	syncer := NewSyncer(client, 1, "task-123", specsDir, 50*time.Millisecond)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, 50*time.Millisecond)
 
 	// Start background sync
 	ctx, cancel := context.WithCancel(context.Background())
@@ -269,7 +317,7 @@ func TestIntegration_ConcurrentMCPAndBackground(t *testing.T) {
 
 	// Create client and syncer
 	client := &testClient{baseURL: ts.server.URL}
-	syncer := NewSyncer(client, 1, "task-123", specsDir, 50*time.Millisecond)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, 50*time.Millisecond)
 
 	// Start background sync
 	ctx, cancel := context.WithCancel(context.Background())
@@ -343,7 +391,7 @@ func TestIntegration_EmptySpecsDir(t *testing.T) {
 	}
 
 	// Create syncer
-	syncer := NewSyncer(client, 1, "task-123", specsDir, time.Second)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
 
 	// Trigger sync
 	result := syncer.TriggerOnce(context.Background())
@@ -354,4 +402,108 @@ func TestIntegration_EmptySpecsDir(t *testing.T) {
 	assert.Equal(t, 0, result.Failed)
 }
 
+func TestIntegration_FeatureSyncFlow(t *testing.T) {
+	ts := newTestServer()
+	defer ts.close()
+
+	featureDir := filepath.Join(t.TempDir(), "specs", "STORY-123")
+	taskDir := filepath.Join(featureDir, "TASK-456")
+	err := os.MkdirAll(taskDir, 0755)
+	require.NoError(t, err)
+
+	// Feature planning docs, an input spec and a spec of a task of the feature
+	err = os.WriteFile(filepath.Join(featureDir, "design.md"), []byte("# Design"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(featureDir, "rollout.md"), []byte("# Rollout"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(featureDir, "requirements.md"), []byte("# Story"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(taskDir, "plan.md"), []byte("# Task plan"), 0644)
+	require.NoError(t, err)
+
+	// Set up server with one existing spec (to test skip behavior)
+	ts.specs = append(ts.specs, serverSpec{Name: "rollout.md", Checksum: CalculateChecksumBytes([]byte("# Rollout"))})
+
+	client := &testClient{baseURL: ts.server.URL}
+	syncer := NewSyncer(client, 1, FeatureTarget("story-123"), featureDir, time.Second)
+
+	result := syncer.TriggerOnce(context.Background())
+
+	assert.Equal(t, 1, result.Uploaded) // design.md
+	assert.Equal(t, 1, result.Skipped)  // rollout.md, requirements.md is an input spec and plan.md belongs to the task
+	assert.Equal(t, 0, result.Failed)
+	assert.Equal(t, map[string]string{"design.md": "# Design"}, ts.uploads)
+	assert.Equal(t, []string{"/api/v1/company/1/dev/user-story/story-123/specs"}, ts.uploadPaths)
+}
+
+func TestIntegration_FeatureBackgroundSyncLifecycle(t *testing.T) {
+	ts := newTestServer()
+	defer ts.close()
+
+	featureDir := filepath.Join(t.TempDir(), "specs")
+	err := os.MkdirAll(featureDir, 0755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(featureDir, "design.md"), []byte("# Design"), 0644)
+	require.NoError(t, err)
+
+	client := &testClient{baseURL: ts.server.URL}
+	syncer := NewSyncer(client, 1, FeatureTarget("story-123"), featureDir, 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		syncer.RunBackground(ctx)
+		close(done)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Background sync did not stop within timeout")
+	}
+	assert.Greater(t, ts.uploadCount.Load(), int32(0))
+}
+
+func TestIntegration_FeatureDiscoverySkipsTaskDirs(t *testing.T) {
+	t.Parallel()
+	featureDir := filepath.Join(t.TempDir(), "STORY-123")
+	taskDir := filepath.Join(featureDir, "TASK-456")
+	err := os.MkdirAll(taskDir, 0755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(featureDir, "design.md"), []byte("# Design"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(featureDir, "prd.md"), []byte("# PRD"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(taskDir, "plan.md"), []byte("# Task"), 0644)
+	require.NoError(t, err)
+
+	specs, err := FeatureTarget("story-123").Discover(featureDir)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	assert.Equal(t, "design.md", specs[0].Name)
+
+	specs, err = TaskTarget("task-456").Discover(taskDir)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	assert.Equal(t, "plan.md", specs[0].Name)
+}
+
+func TestIntegration_FeatureEmptySpecsDir(t *testing.T) {
+	featureDir := filepath.Join(t.TempDir(), "specs")
+	err := os.MkdirAll(featureDir, 0755)
+	require.NoError(t, err)
+
+	client := &mockClient{specs: []*artifacts.SpecDetails{}}
+	syncer := NewSyncer(client, 1, FeatureTarget("story-123"), featureDir, time.Second)
+
+	result := syncer.TriggerOnce(context.Background())
+
+	assert.Equal(t, 0, result.Uploaded)
+	assert.Equal(t, 0, result.Skipped)
+	assert.Equal(t, 0, result.Failed)
+}
+
 // makeSpecDetails is defined in sync_test.go and shared across test files
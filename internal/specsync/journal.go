@@ -0,0 +1,184 @@
+package specsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/metadata"
+)
+
+const (
+	// journalFile is the name of the journal in the .devplan_meta directory of the specs directory
+	journalFile = "specsync.json"
+	// journalVersion 2 names specs by their path instead of their file name
+	journalVersion = 2
+	// racyWindow is how long after their last modification files are hashed again on every run,
+	// as a write within the same clock tick would not change their size or modification time
+	racyWindow = 2 * time.Second
+)
+
+// journal records the sync state of the specs of a target in .devplan_meta/specsync.json of the specs directory.
+// It lets a restarted syncer tell synced specs from new ones, notice specs deleted or renamed while it was not running,
+// keep conflicts until they are resolved and skip hashing files that did not change.
+type journal struct {
+	Version int    `json:"version"`
+	Target  string `json:"target"`
+	// ServerCheckedAt is when the specs on the server were last looked up
+	ServerCheckedAt time.Time                `json:"serverCheckedAt,omitempty"`
+	Specs           map[string]*journalEntry `json:"specs"`
+}
+
+// journalEntry is the sync state of a spec, keyed by its name in the journal
+type journalEntry struct {
+	// Path of the spec relative to the specs directory
+	Path string `json:"path"`
+	// Checksum is the base: the checksum of the spec both locally and on the server when it was last synced
+	Checksum string    `json:"checksum,omitempty"`
+	SyncedAt time.Time `json:"syncedAt,omitempty"`
+	// ServerChecksum is the checksum of the spec on the server as of the last lookup
+	ServerChecksum string `json:"serverChecksum,omitempty"`
+	// LocalChecksum, Size and ModTime describe the local file when it was last hashed
+	LocalChecksum string    `json:"localChecksum,omitempty"`
+	Size          int64     `json:"size,omitempty"`
+	ModTime       time.Time `json:"modTime,omitempty"`
+	// Deleted is set when the local file was deleted after the spec was synced. The spec stays on the server.
+	Deleted bool `json:"deleted,omitempty"`
+	// RenamedTo is the name of the spec the deleted file was renamed to
+	RenamedTo string `json:"renamedTo,omitempty"`
+	// LegacyName is the file name older versions uploaded the spec under, which is not downloaded
+	LegacyName string           `json:"legacyName,omitempty"`
+	Conflict   *journalConflict `json:"conflict,omitempty"`
+}
+
+// journalConflict records a spec changed both locally and on the server
+type journalConflict struct {
+	LocalChecksum  string `json:"localChecksum"`
+	ServerChecksum string `json:"serverChecksum"`
+	// Copied is set when the server version was written next to the spec with ConflictSuffix
+	Copied bool `json:"copied,omitempty"`
+}
+
+func journalPath(dir string) string {
+	return filepath.Join(metadata.GetDevplanDir(dir), journalFile)
+}
+
+// loadJournal reads the journal of the specs directory. A missing, unreadable or foreign journal is replaced
+// by an empty one, which only costs a full sync: the journal is a cache of what the server and the files hold.
+func loadJournal(dir string, target Target) (*journal, []byte) {
+	empty := &journal{Version: journalVersion, Target: target.String(), Specs: make(map[string]*journalEntry)}
+	path := journalPath(dir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read spec sync journal", "path", path, "err", err)
+		}
+		return empty, nil
+	}
+	j := &journal{}
+	if err := json.Unmarshal(data, j); err != nil {
+		slog.Warn("Ignoring invalid spec sync journal", "path", path, "err", err)
+		return empty, nil
+	}
+	if j.Version == 1 && j.Target == empty.Target {
+		j.migrateNames()
+	}
+	if j.Version != journalVersion || j.Target != empty.Target {
+		slog.Warn("Ignoring spec sync journal of another version or target", "path", path, "target", j.Target)
+		return empty, nil
+	}
+	if j.Specs == nil {
+		j.Specs = make(map[string]*journalEntry)
+	}
+	return j, data
+}
+
+// migrateNames renames the entries of specs in subdirectories, which version 1 named by their file name, by their path.
+// Their base stays, so they are uploaded under the new name unless they changed on the server.
+func (j *journal) migrateNames() {
+	specs := make(map[string]*journalEntry, len(j.Specs))
+	for name, e := range j.Specs {
+		if e.Path != "" && e.Path != name {
+			e.LegacyName = name
+			e.ServerChecksum = ""
+			name = e.Path
+		}
+		specs[name] = e
+	}
+	j.Specs = specs
+	j.Version = journalVersion
+}
+
+// legacy reports whether a spec on the server has the legacy name of an entry
+func (j *journal) legacy(name string) bool {
+	for _, e := range j.Specs {
+		if e.LegacyName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the journal atomically unless it equals saved, and returns the written data.
+// Nothing is written while the specs directory does not exist.
+func (j *journal) save(dir string, saved []byte) ([]byte, error) {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return saved, err
+	}
+	if bytes.Equal(data, saved) {
+		return saved, nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return saved, nil
+	}
+	if err := metadata.EnsureGitignore(dir); err != nil {
+		return saved, err
+	}
+	if err := writeFileAtomic(journalPath(dir), data); err != nil {
+		return saved, err
+	}
+	return data, nil
+}
+
+// entry returns the entry of a spec, adding it if needed
+func (j *journal) entry(name string) *journalEntry {
+	e, ok := j.Specs[name]
+	if !ok {
+		e = &journalEntry{}
+		j.Specs[name] = e
+	}
+	return e
+}
+
+// base returns the checksum of a spec as of its last sync, empty if it was never synced
+func (j *journal) base(name string) string {
+	if e, ok := j.Specs[name]; ok {
+		return e.Checksum
+	}
+	return ""
+}
+
+// cachedChecksum returns the checksum of a local file if it is unchanged since it was last hashed
+func (e *journalEntry) cachedChecksum(rel string, info os.FileInfo) (string, bool) {
+	if e == nil || e.LocalChecksum == "" || e.Path != rel || e.ModTime.IsZero() {
+		return "", false
+	}
+	return e.LocalChecksum, e.Size == info.Size() && e.ModTime.Equal(info.ModTime())
+}
+
+// setLocal remembers the checksum of the local file of a spec
+func (e *journalEntry) setLocal(rel, checksum string, info os.FileInfo) {
+	e.Path = rel
+	e.LocalChecksum = checksum
+	e.Size = info.Size()
+	e.ModTime = info.ModTime()
+	if time.Since(e.ModTime) < racyWindow {
+		e.ModTime = time.Time{}
+	}
+	e.Deleted = false
+	e.RenamedTo = ""
+}
@@ -0,0 +1,168 @@
+package specsync
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOldFile writes a file modified a minute ago, so its checksum is cached in the journal
+func writeOldFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	old := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(path, old, old))
+}
+
+func TestSyncer_JournalKeepsBasesAcrossRestarts(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	planPath := filepath.Join(specsDir, "plan.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan v1"), 0644))
+	client := &mockClient{}
+	client.setServerSpec("plan.md", "# Plan v1")
+	NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.FileExists(t, filepath.Join(specsDir, ".devplan_meta", journalFile))
+	assert.FileExists(t, filepath.Join(specsDir, ".devplan_meta", ".gitignore"))
+
+	// A restarted syncer knows the base, so a spec changed only on the server is not taken for a conflict
+	client.setServerSpec("plan.md", "# Plan v2")
+	result := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, 1, result.Downloaded)
+	data, err := os.ReadFile(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan v2", string(data))
+
+	// Conflicts are kept until they are resolved
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan mine"), 0644))
+	client.setServerSpec("plan.md", "# Plan theirs")
+	result = NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	require.Len(t, result.Conflicts, 1)
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan merged"), 0644))
+	require.NoError(t, os.Remove(planPath+ConflictSuffix))
+	result = NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, 1, result.Uploaded, "the merged spec is uploaded after a restart")
+}
+
+func TestSyncer_JournalTracksDeletionsAndRenames(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "notes.md"), []byte("# Notes"), 0644))
+	client := &mockClient{}
+	client.setServerSpec("plan.md", "# Plan")
+	client.setServerSpec("notes.md", "# Notes")
+	NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+
+	// Changed while the syncer was not running
+	require.NoError(t, os.Remove(filepath.Join(specsDir, "plan.md")))
+	require.NoError(t, os.Rename(filepath.Join(specsDir, "notes.md"), filepath.Join(specsDir, "design.md")))
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	result := syncer.TriggerOnce(context.Background())
+	assert.Equal(t, []string{"plan.md"}, result.Deleted)
+	assert.Equal(t, []Rename{{From: "notes.md", To: "design.md"}}, result.Renamed)
+	assert.Equal(t, 0, result.Downloaded, "deleted specs are not pulled again")
+	assert.Equal(t, []string{"design.md"}, client.uploaded())
+	assert.NoFileExists(t, filepath.Join(specsDir, "plan.md"))
+	assert.NoFileExists(t, filepath.Join(specsDir, "notes.md"))
+
+	// Reported once
+	result = syncer.TriggerOnce(context.Background())
+	assert.Empty(t, result.Deleted)
+	assert.Empty(t, result.Renamed)
+}
+
+func TestSyncer_ResumeSkipsServerLookup(t *testing.T) {
+	specsDir := t.TempDir()
+	writeOldFile(t, filepath.Join(specsDir, "plan.md"), "# Plan")
+	client := &mockClient{}
+	NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	require.Equal(t, int32(1), client.specsCalls.Load())
+
+	// Restarted with the journal fresh and no local changes
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Hour)
+	startWatching(t, syncer)
+	assert.Equal(t, int32(1), client.specsCalls.Load())
+	assert.Equal(t, 1, syncer.Status().LastResult.Skipped)
+	assert.Empty(t, syncer.Status().Pending)
+
+	// A spec changed while the syncer was not running is synced right away
+	writeOldFile(t, filepath.Join(specsDir, "plan.md"), "# Plan v2")
+	syncer = NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Hour)
+	startWatching(t, syncer)
+	assert.Equal(t, int32(2), client.specsCalls.Load())
+	assert.Equal(t, []string{"plan.md", "plan.md"}, client.uploaded())
+}
+
+func TestSyncer_UploadsCachedSpecs(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	writeOldFile(t, filepath.Join(specsDir, "plan.md"), "# Plan")
+	client := &mockClient{}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	syncer.TriggerOnce(context.Background())
+
+	// Not on the server and found by its cached checksum, so its content is read for the upload
+	result := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.Equal(t, 1, result.Uploaded)
+	assert.Empty(t, result.Errors)
+}
+
+func TestSyncer_IgnoresInvalidJournal(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	journalPath := filepath.Join(specsDir, ".devplan_meta", journalFile)
+	require.NoError(t, os.MkdirAll(filepath.Dir(journalPath), 0755))
+	require.NoError(t, os.WriteFile(journalPath, []byte("{not json"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+
+	result := NewSyncer(&mockClient{}, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.Equal(t, 1, result.Uploaded)
+	data, err := os.ReadFile(journalPath)
+	require.NoError(t, err)
+	var j journal
+	require.NoError(t, json.Unmarshal(data, &j))
+	assert.Equal(t, "task task-123", j.Target)
+	assert.Equal(t, "plan.md", j.Specs["plan.md"].Path)
+}
+
+func TestSyncer_MigratesJournalV1(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(specsDir, "research"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "research", "notes.md"), []byte("# Notes v1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+	v1 := `{"version": 1, "target": "task task-123", "specs": {
+		"notes.md": {"path": "research/notes.md", "checksum": "` + CalculateChecksumBytes([]byte("# Notes v1")) + `"},
+		"plan.md": {"path": "plan.md", "checksum": "` + CalculateChecksumBytes([]byte("# Plan")) + `"}}}`
+	journalPath := filepath.Join(specsDir, ".devplan_meta", journalFile)
+	require.NoError(t, os.MkdirAll(filepath.Dir(journalPath), 0755))
+	require.NoError(t, os.WriteFile(journalPath, []byte(v1), 0644))
+
+	// The server still has the spec under its file name, changed since the last sync
+	client := &mockClient{}
+	client.setServerSpec("notes.md", "# Notes v2")
+	client.setServerSpec("plan.md", "# Plan")
+	result := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.Empty(t, result.Conflicts)
+	assert.Empty(t, result.Migrated, "the journal already knew the spec")
+	assert.Equal(t, []string{"research/notes.md"}, client.uploaded())
+	assert.Equal(t, 0, result.Downloaded)
+	assert.NoFileExists(t, filepath.Join(specsDir, "notes.md"))
+
+	data, err := os.ReadFile(journalPath)
+	require.NoError(t, err)
+	var j journal
+	require.NoError(t, json.Unmarshal(data, &j))
+	assert.Equal(t, journalVersion, j.Version)
+	require.Contains(t, j.Specs, "research/notes.md")
+	assert.Equal(t, "notes.md", j.Specs["research/notes.md"].LegacyName)
+}
@@ -0,0 +1,49 @@
+package specsync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// PullResult holds results of pulling specs
+type PullResult struct {
+	// Written holds the paths of the specs written
+	Written []string
+	// Excluded holds the names of the specs excluded by the rules
+	Excluded []string
+}
+
+// Pull downloads the specs of the target into its specs directory, overwriting local files. Specs excluded by the rules
+// are not written, as they would not be synced either.
+func Pull(ctx context.Context, client Client, companyID int32, target Target, dir string, rules *Rules) (*PullResult, error) {
+	resp, err := client.GetSpecs(ctx, companyID, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s specs: %w", target.Kind, err)
+	}
+	result := &PullResult{}
+	for _, spec := range resp.GetSpecs() {
+		path, ok := specPath(dir, spec.GetName())
+		if isSpec, _ := rules.Match(spec.GetName()); !ok || !isSpec {
+			result.Excluded = append(result.Excluded, spec.GetName())
+			continue
+		}
+		full, err := client.GetSpec(ctx, companyID, target, spec.GetName())
+		if err != nil {
+			return result, fmt.Errorf("failed to download spec %s: %w", spec.GetName(), err)
+		}
+		if err := writeFileAtomic(path, []byte(full.GetContent())); err != nil {
+			return result, err
+		}
+		result.Written = append(result.Written, path)
+	}
+	return result, nil
+}
+
+// specPath returns the local path of a spec on the server, false if its name points outside the specs directory
+func specPath(dir, name string) (string, bool) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", false
+	}
+	return filepath.Join(dir, filepath.FromSlash(name)), true
+}
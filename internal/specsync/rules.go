@@ -0,0 +1,178 @@
+package specsync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// IgnoreFile holds gitignore-style patterns of files in the specs directory that are not synced.
+	// Patterns starting with ! include files, e.g. other file types than markdown.
+	IgnoreFile = ".devplanignore"
+	// PatternsRule is the company dev rule holding patterns of files synced as specs, one per line.
+	// Patterns starting with ! exclude files. They apply before IgnoreFile, which has the last word.
+	PatternsRule = "spec-files"
+)
+
+// inputSpecs are written from Devplan documents for agents to read, and are not specs of the task
+var inputSpecs = []string{"prd.md", "tech_brief.md", "requirements.md", "instructions.md", "dependencies.md"}
+
+var (
+	defaultPatterns = builtinPatterns("default", "*.md\n!"+strings.Join(inputSpecs, "\n!"), true)
+	// fixedPatterns can't be overridden: hidden files hold sync state and temporary files, and conflict copies
+	// hold the server versions of specs
+	fixedPatterns = builtinPatterns("always", ".*\n*"+ConflictSuffix, false)
+)
+
+// Rules decide which files of a specs directory are specs. Like in gitignore, the last matching pattern wins:
+// patterns of PatternsRule override the defaults, and patterns of IgnoreFile override both.
+type Rules struct {
+	patterns []rulePattern
+}
+
+// FileMatch is a file of a specs directory with the rule deciding whether it is a spec
+type FileMatch struct {
+	Path string
+	// Rel is the slash-separated path relative to the specs directory
+	Rel    string
+	Spec   bool
+	Reason string
+}
+
+type rulePattern struct {
+	// source is where the pattern comes from, e.g. ".devplanignore:3"
+	source   string
+	text     string
+	include  bool
+	dirOnly  bool
+	anchored bool
+	segments []string
+}
+
+// DefaultRules returns the rules of a specs directory without PatternsRule and IgnoreFile: markdown files except
+// hidden files and input specs
+func DefaultRules() *Rules {
+	return &Rules{patterns: append(append([]rulePattern{}, defaultPatterns...), fixedPatterns...)}
+}
+
+// LoadRules returns the rules of a specs directory from the patterns of PatternsRule and the IgnoreFile of the directory
+func LoadRules(dir string, serverPatterns string) (*Rules, error) {
+	rules := &Rules{patterns: append([]rulePattern{}, defaultPatterns...)}
+	rules.patterns = append(rules.patterns, parsePatterns(PatternsRule, serverPatterns, true)...)
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+	rules.patterns = append(rules.patterns, parsePatterns(IgnoreFile, string(data), false)...)
+	rules.patterns = append(rules.patterns, fixedPatterns...)
+	return rules, nil
+}
+
+// Match reports whether the file at rel, a slash-separated path relative to the specs directory, is a spec,
+// and why
+func (r *Rules) Match(rel string) (bool, string) {
+	spec, reason := false, "no pattern includes it"
+	for _, p := range r.patterns {
+		if p.matches(rel) {
+			spec, reason = p.include, p.String()
+		}
+	}
+	return spec, reason
+}
+
+// ListFiles returns all files in dir that may be specs of a target, with whether they are. Hidden directories
+// are skipped, and so are subdirectories unless recursive.
+func ListFiles(dir string, recursive bool, rules *Rules) ([]FileMatch, error) {
+	var files []FileMatch
+	err := walkFiles(dir, recursive, func(path, rel string, _ os.FileInfo) error {
+		spec, reason := rules.Match(rel)
+		files = append(files, FileMatch{Path: path, Rel: rel, Spec: spec, Reason: reason})
+		return nil
+	})
+	return files, err
+}
+
+// parsePatterns parses gitignore-style patterns. Patterns include files if include is set, and ! inverts them.
+func parsePatterns(source, content string, include bool) []rulePattern {
+	var patterns []rulePattern
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := rulePattern{source: fmt.Sprintf("%s:%d", source, i+1), text: line, include: include}
+		if strings.HasPrefix(line, "!") {
+			p.include = !include
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// Like in gitignore, patterns with a slash are relative to the directory, others match names at any depth
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		p.segments = strings.Split(line, "/")
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// builtinPatterns parses patterns of the CLI, whose source has no line numbers
+func builtinPatterns(source, content string, include bool) []rulePattern {
+	patterns := parsePatterns(source, content, include)
+	for i := range patterns {
+		patterns[i].source = source
+	}
+	return patterns
+}
+
+func (p rulePattern) String() string {
+	return fmt.Sprintf("%s: %s", p.source, p.text)
+}
+
+// matches reports whether the pattern matches the file at rel or one of its parent directories
+func (p rulePattern) matches(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for n := 1; n <= len(parts); n++ {
+		if p.dirOnly && n == len(parts) {
+			continue
+		}
+		if p.anchored && matchSegments(p.segments, parts[:n]) {
+			return true
+		}
+		if !p.anchored && len(p.segments) == 1 && matchSegment(p.segments[0], parts[n-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where ** matches any number of segments
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(parts) > 0 && matchSegment(pattern[0], parts[0]) && matchSegments(pattern[1:], parts[1:])
+}
+
+func matchSegment(pattern, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
@@ -0,0 +1,149 @@
+package specsync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Match(t *testing.T) {
+	specsDir := t.TempDir()
+	ignore := "# scratch notes\nscratch/\n/draft.md\n**/tmp/*.md\n!keep/**/*.txt\n"
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, IgnoreFile), []byte(ignore), 0644))
+	rules, err := LoadRules(specsDir, "*.mdx\ndiagrams/*.mermaid\n!generated.md")
+	require.NoError(t, err)
+
+	tests := []struct {
+		rel    string
+		spec   bool
+		reason string
+	}{
+		{rel: "plan.md", spec: true, reason: "default: *.md"},
+		{rel: "api/v2/endpoints.md", spec: true, reason: "default: *.md"},
+		{rel: "prd.md", reason: "default: !prd.md"},
+		{rel: "notes.txt", reason: "no pattern includes it"},
+		{rel: "page.mdx", spec: true, reason: "spec-files:1: *.mdx"},
+		{rel: "diagrams/flow.mermaid", spec: true, reason: "spec-files:2: diagrams/*.mermaid"},
+		{rel: "other/flow.mermaid", reason: "no pattern includes it"},
+		{rel: "generated.md", reason: "spec-files:3: !generated.md"},
+		{rel: "scratch/idea.md", reason: ".devplanignore:2: scratch/"},
+		{rel: "api/scratch/idea.md", reason: ".devplanignore:2: scratch/"},
+		{rel: "draft.md", reason: ".devplanignore:3: /draft.md"},
+		{rel: "api/draft.md", spec: true, reason: "default: *.md"},
+		{rel: "a/b/tmp/x.md", reason: ".devplanignore:4: **/tmp/*.md"},
+		{rel: "tmp/x.md", reason: ".devplanignore:4: **/tmp/*.md"},
+		{rel: "keep/a/b/test.txt", spec: true, reason: ".devplanignore:5: !keep/**/*.txt"},
+		{rel: ".draft.md", reason: "always: .*"},
+		{rel: "plan.md.conflict", reason: "always: *.conflict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			spec, reason := rules.Match(tt.rel)
+			assert.Equal(t, tt.spec, spec)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestSyncer_AppliesRules(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(specsDir, "scratch"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "page.mdx"), []byte("# Page"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "scratch", "idea.md"), []byte("# Idea"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, IgnoreFile), []byte("scratch/\nremote.md\n"), 0644))
+
+	client := &mockClient{}
+	client.setServerSpec("remote.md", "# Remote")
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	syncer.SetServerPatterns("*.mdx")
+	result := syncer.TriggerOnce(context.Background())
+	assert.ElementsMatch(t, []string{"plan.md", "page.mdx"}, client.uploaded())
+	assert.Equal(t, 0, result.Downloaded, "specs excluded locally are not pulled")
+	assert.NoFileExists(t, filepath.Join(specsDir, "remote.md"))
+}
+
+func TestSyncer_WatchReloadsIgnoreFile(t *testing.T) {
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, IgnoreFile), []byte("draft.md\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "draft.md"), []byte("# Draft"), 0644))
+
+	client := &mockClient{}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Hour)
+	syncer.debounce = 20 * time.Millisecond
+	startWatching(t, syncer)
+	assert.Empty(t, client.uploaded())
+
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, IgnoreFile), []byte(""), 0644))
+	require.Eventually(t, func() bool { return len(client.uploaded()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestPull(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Old plan"), 0644))
+	client := &mockClient{}
+	client.setServerSpec("plan.md", "# Plan")
+	client.setServerSpec("flow.mermaid", "graph TD")
+	client.setServerSpec("notes.txt", "notes")
+	client.setServerSpec("../escape.md", "# Escape")
+	rules, err := LoadRules(specsDir, "*.mermaid")
+	require.NoError(t, err)
+
+	result, err := Pull(context.Background(), client, 1, TaskTarget("task-123"), specsDir, rules)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(specsDir, "plan.md"), filepath.Join(specsDir, "flow.mermaid")}, result.Written)
+	assert.ElementsMatch(t, []string{"notes.txt", "../escape.md"}, result.Excluded)
+	data, err := os.ReadFile(filepath.Join(specsDir, "plan.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan", string(data))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(specsDir), "escape.md"))
+}
+
+// patternsClient is a mockClient that also serves the patterns of PatternsRule
+type patternsClient struct {
+	*mockClient
+	patterns atomic.Pointer[string]
+	err      error
+}
+
+func (c *patternsClient) SpecPatterns(_ context.Context, _ int32) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	if patterns := c.patterns.Load(); patterns != nil {
+		return *patterns, nil
+	}
+	return "", nil
+}
+
+func TestSyncer_RefreshesServerPatterns(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "page.mdx"), []byte("# Page"), 0644))
+
+	client := &patternsClient{mockClient: &mockClient{}}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	syncer.TriggerOnce(context.Background())
+	assert.Empty(t, client.uploaded())
+
+	// The company rule changed while the syncer runs
+	patterns := "*.mdx"
+	client.patterns.Store(&patterns)
+	syncer.TriggerOnce(context.Background())
+	assert.Equal(t, []string{"page.mdx"}, client.uploaded())
+
+	// Patterns that can't be fetched don't change the rules
+	client.err = errors.New("unavailable")
+	syncer.TriggerOnce(context.Background())
+	spec, _ := syncer.currentRules().Match("page.mdx")
+	assert.True(t, spec)
+}
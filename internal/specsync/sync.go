@@ -2,31 +2,58 @@ package specsync
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
-	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/artifacts"
 )
 
 const DefaultSyncInterval = 10 * time.Second
 
-// shouldUpload determines if an artifact needs to be uploaded
-func shouldUpload(spec Spec, specs []*artifacts.SpecDetails) bool {
-	var existing *artifacts.SpecDetails
-	for _, s := range specs {
-		if s.GetName() == spec.Name {
-			existing = s
-			break
+// action is what a sync run does with a spec
+type action int
+
+const (
+	actionNone action = iota
+	actionUpload
+	actionDownload
+	actionConflict
+)
+
+// syncAction decides how to sync a spec from its local and server checksums and the base checksum,
+// which both had when the spec was last synced. Empty checksums mean the spec is missing or was never synced.
+func syncAction(local, server, base string) action {
+	switch {
+	case local == server:
+		return actionNone
+	case server == "":
+		return actionUpload
+	case local == "":
+		if base != "" {
+			// Deleted locally after it was synced
+			return actionNone
 		}
+		return actionDownload
+	case base == "":
+		// Both sides have the spec, but it's unknown which of them changed
+		return actionConflict
+	case local == base:
+		return actionDownload
+	case server == base:
+		return actionUpload
+	default:
+		return actionConflict
 	}
-	if existing == nil {
-		// Spec not on server, needs upload
-		return true
-	}
-	// Upload if checksums differ
-	return existing.GetChecksum() != spec.Checksum
 }
 
 func (s *Syncer) uploadSpec(ctx context.Context, spec Spec) error {
@@ -50,11 +77,13 @@ func (s *Syncer) uploadSpec(ctx context.Context, spec Spec) error {
 		Content:  string(spec.Content),
 		Checksum: spec.Checksum,
 	}.Build()
-	return s.client.UploadTaskSpec(s.companyID, s.taskID, req)
+	return s.client.UploadSpec(ctx, s.companyID, s.target, req)
 }
 
-// runSync executes one sync run and returns the result
-func (s *Syncer) runSync(ctx context.Context) *SyncResult {
+// runSync executes one sync run comparing all local specs with the server, and returns the result.
+// With resume, the server lookup is skipped if the journal shows that no spec changed locally since
+// the server was looked up within the reconcile interval, e.g. when the MCP server is restarted.
+func (s *Syncer) runSync(ctx context.Context, resume bool) *SyncResult {
 	slog.Debug("Running sync")
 	defer func() {
 		if r := recover(); r != nil {
@@ -63,46 +92,443 @@ func (s *Syncer) runSync(ctx context.Context) *SyncResult {
 		slog.Debug("Sync finished")
 	}()
 	result := &SyncResult{}
+	s.ensureJournal()
+
+	// A resumed run may skip the server, so it keeps the patterns set when the syncer was created
+	if !resume {
+		s.refreshPatterns(ctx)
+	}
 
 	// Discover local specs
-	localSpecs, err := DiscoverTaskSpecs(s.taskDir)
-	slog.Debug("Specs found", "specs", localSpecs)
+	rules, err := s.loadRules()
 	if err != nil {
 		result.Failed = 1
 		result.Errors = append(result.Errors, err)
 		return result
 	}
-
-	if len(localSpecs) == 0 {
-		slog.Debug("Running sync: no local specs")
+	localSpecs, err := s.discover(rules)
+	slog.Debug("Specs found", "specs", len(localSpecs))
+	if err != nil {
+		result.Failed = 1
+		result.Errors = append(result.Errors, err)
 		return result
 	}
+	s.setLocal(localSpecs)
+	s.trackDeletions(localSpecs, result)
+	s.trackRenames(localSpecs, result)
+	s.detectChanges(localSpecs)
+	if resume && s.upToDate(localSpecs, result) {
+		slog.Debug("Specs unchanged since the last sync, skipping the server lookup", "dir", s.dir)
+		result.Skipped = len(localSpecs)
+		return result
+	}
+	s.syncSpecs(ctx, localSpecs, true, result)
+	return result
+}
+
+// discover finds the local specs like Target.Discover, but reuses the checksums in the journal for files unchanged
+// since they were last hashed. Content of these specs is read only if they are uploaded.
+func (s *Syncer) discover(rules *Rules) ([]Spec, error) {
+	var specs []Spec
+	err := walkSpecs(s.dir, s.target.recursive(), rules, func(path, rel string, info os.FileInfo) error {
+		spec := Spec{Name: rel, Path: path}
+		if checksum, ok := s.journal.Specs[spec.Name].cachedChecksum(rel, info); ok {
+			spec.Checksum = checksum
+			specs = append(specs, spec)
+			return nil
+		}
+		checksum, data, err := calculateChecksum(path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate checksum for %s: %w", path, err)
+		}
+		spec.Checksum, spec.Content = checksum, data
+		s.journal.entry(spec.Name).setLocal(rel, checksum, info)
+		specs = append(specs, spec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// upToDate reports whether the journal shows that the local specs are synced and the server was looked up
+// within the reconcile interval
+func (s *Syncer) upToDate(localSpecs []Spec, result *SyncResult) bool {
+	if len(result.Deleted) > 0 || len(result.Renamed) > 0 || time.Since(s.journal.ServerCheckedAt) > s.reconcileInterval {
+		return false
+	}
+	for _, spec := range localSpecs {
+		entry, ok := s.journal.Specs[spec.Name]
+		if !ok || entry.Checksum != spec.Checksum || entry.Conflict != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// trackDeletions marks synced specs missing from the local specs as deleted, and forgets the ones never synced.
+// Must be called with runMu held.
+func (s *Syncer) trackDeletions(localSpecs []Spec, result *SyncResult) {
+	present := make(map[string]bool, len(localSpecs))
+	for _, spec := range localSpecs {
+		present[spec.Name] = true
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.journal.Specs)) {
+		if !present[name] {
+			s.markDeleted(name, result)
+		}
+	}
+}
+
+// markDeleted records that the local file of a spec was deleted. A synced spec is kept in Devplan
+// and not downloaded again. Must be called with runMu held.
+func (s *Syncer) markDeleted(name string, result *SyncResult) {
+	entry, ok := s.journal.Specs[name]
+	switch {
+	case !ok || entry.Deleted:
+	case entry.Checksum == "":
+		delete(s.journal.Specs, name)
+	default:
+		entry.Deleted = true
+		entry.Conflict = nil
+		result.Deleted = append(result.Deleted, name)
+		slog.Info("Spec deleted locally, keeping it in Devplan", "name", name, "path", entry.Path)
+	}
+}
+
+// trackRenames reports specs never synced with the content of a deleted spec as renames of it.
+// Must be called with runMu held.
+func (s *Syncer) trackRenames(specs []Spec, result *SyncResult) {
+	for _, spec := range specs {
+		if s.journal.base(spec.Name) != "" {
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.journal.Specs)) {
+			entry := s.journal.Specs[name]
+			if !entry.Deleted || entry.RenamedTo != "" || entry.Checksum != spec.Checksum {
+				continue
+			}
+			entry.RenamedTo = spec.Name
+			result.Deleted = slices.DeleteFunc(result.Deleted, func(deleted string) bool { return deleted == name })
+			result.Renamed = append(result.Renamed, Rename{From: name, To: spec.Name})
+			slog.Info("Spec renamed locally, keeping the old name in Devplan", "from", name, "to", spec.Name)
+			break
+		}
+	}
+}
 
-	serverSpecsResp, err := s.client.GetTaskSpecs(s.companyID, s.taskID)
+// syncSpecs looks up the specs on the server and syncs the given local specs with them.
+// With pull, specs found only on the server are downloaded too, unless the rules exclude them.
+func (s *Syncer) syncSpecs(ctx context.Context, localSpecs []Spec, pull bool, result *SyncResult) {
+	serverSpecsResp, err := s.client.GetSpecs(ctx, s.companyID, s.target)
 	if err != nil {
-		result.Failed = len(localSpecs)
+		result.Failed += len(localSpecs)
 		result.Errors = append(result.Errors, err)
-		return result
+		return
+	}
+	s.journal.ServerCheckedAt = time.Now()
+	serverChecksums := make(map[string]string)
+	for _, spec := range serverSpecsResp.GetSpecs() {
+		serverChecksums[spec.GetName()] = spec.GetChecksum()
+		s.setServer(spec.GetName(), spec.GetChecksum())
 	}
 
-	// Process each local spec
+	downloaded := false
+	localNames := make(map[string]bool, len(localSpecs))
 	for _, localSpec := range localSpecs {
-		if !shouldUpload(localSpec, serverSpecsResp.GetSpecs()) {
-			result.Skipped++
+		localNames[localSpec.Name] = true
+		downloaded = s.syncSpec(ctx, localSpec, serverChecksums[localSpec.Name], result) || downloaded
+	}
+	if pull {
+		rules := s.currentRules()
+		for _, spec := range serverSpecsResp.GetSpecs() {
+			if localNames[spec.GetName()] {
+				continue
+			}
+			path, ok := specPath(s.dir, spec.GetName())
+			if isSpec, _ := rules.Match(spec.GetName()); !ok || !isSpec {
+				// Excluded locally, e.g. by IgnoreFile
+				continue
+			}
+			if s.migrateLegacy(spec.GetName(), localSpecs, result) {
+				continue
+			}
+			missing := Spec{Name: spec.GetName(), Path: path}
+			downloaded = s.syncSpec(ctx, missing, spec.GetChecksum(), result) || downloaded
+		}
+	}
+	if downloaded {
+		s.detectChanges(s.localSpecs())
+	}
+}
+
+// migrateLegacy reports whether a spec on the server is named by the file name of a local spec in a subdirectory,
+// as older versions named specs. Such specs are not downloaded: the local spec is uploaded under its path instead.
+// Must be called with runMu held.
+func (s *Syncer) migrateLegacy(name string, localSpecs []Spec, result *SyncResult) bool {
+	if strings.Contains(name, "/") {
+		return false
+	}
+	found := s.journal.legacy(name)
+	for _, spec := range localSpecs {
+		if spec.Name == name || path.Base(spec.Name) != name {
 			continue
 		}
-		slog.Debug("Uploading spec", "path", localSpec.Path)
+		found = true
+		if entry := s.journal.entry(spec.Name); entry.LegacyName != name {
+			entry.LegacyName = name
+			result.Migrated = append(result.Migrated, Rename{From: name, To: spec.Name})
+			slog.Info("Spec uploaded under its file name by an older version, syncing it under its path", "name", name, "path", spec.Name)
+		}
+	}
+	return found
+}
+
+// syncSpec uploads, downloads or reports a conflict for a spec, and returns true if the local file was written.
+// Must be called with runMu held.
+func (s *Syncer) syncSpec(ctx context.Context, spec Spec, serverChecksum string, result *SyncResult) bool {
+	s.resolveConflict(spec)
+	switch syncAction(spec.Checksum, serverChecksum, s.journal.base(spec.Name)) {
+	case actionUpload:
+		s.upload(ctx, spec, result)
+	case actionDownload:
+		return s.download(ctx, spec, result)
+	case actionConflict:
+		return s.handleConflict(ctx, spec, serverChecksum, result)
+	default:
+		if spec.Checksum != "" {
+			result.Skipped++
+			s.setBase(spec, spec.Checksum)
+		}
+	}
+	return false
+}
 
-		err := s.uploadSpec(ctx, localSpec)
+func (s *Syncer) upload(ctx context.Context, spec Spec, result *SyncResult) {
+	slog.Debug("Uploading spec", "path", spec.Path)
+	if spec.Content == nil {
+		// Found unchanged since it was last hashed, so it was not read
+		checksum, data, err := calculateChecksum(spec.Path)
 		if err != nil {
 			result.Failed++
 			result.Errors = append(result.Errors, err)
-			slog.Info("Failed to upload spec", "path", localSpec.Path, "err", err)
-		} else {
-			slog.Info("Spec uploaded", "path", localSpec.Path)
-			result.Uploaded++
+			return
 		}
+		if checksum != spec.Checksum {
+			slog.Info("Spec changed while syncing, skipping upload", "path", spec.Path)
+			result.Skipped++
+			return
+		}
+		spec.Content = data
+	}
+	if err := s.uploadSpec(ctx, spec); err != nil {
+		result.Failed++
+		result.Errors = append(result.Errors, err)
+		slog.Info("Failed to upload spec", "path", spec.Path, "err", err)
+		return
 	}
+	slog.Info("Spec uploaded", "path", spec.Path)
+	s.setServer(spec.Name, spec.Checksum)
+	s.setBase(spec, spec.Checksum)
+	result.Uploaded++
+}
 
-	return result
+// download writes the server version of a spec to its local path. The local file is left alone
+// if it changed since it was discovered, so edits made in the meantime are not lost.
+func (s *Syncer) download(ctx context.Context, spec Spec, result *SyncResult) bool {
+	content, err := s.fetchSpec(ctx, spec.Name)
+	if err == nil {
+		var current string
+		if current, err = currentChecksum(spec.Path); err == nil && current != spec.Checksum {
+			slog.Info("Spec changed while syncing, skipping download", "path", spec.Path)
+			result.Skipped++
+			return false
+		}
+	}
+	if err == nil {
+		err = writeFileAtomic(spec.Path, content)
+	}
+	if err != nil {
+		result.Failed++
+		result.Errors = append(result.Errors, err)
+		slog.Info("Failed to download spec", "path", spec.Path, "err", err)
+		return false
+	}
+	checksum := CalculateChecksumBytes(content)
+	if s.local == nil {
+		s.local = make(map[string]Spec)
+	}
+	s.local[spec.Path] = Spec{Name: spec.Name, Path: spec.Path, Checksum: checksum, Content: content}
+	if info, err := os.Stat(spec.Path); err == nil {
+		s.journal.entry(spec.Name).setLocal(s.relPath(spec.Path), checksum, info)
+	}
+	s.setServer(spec.Name, checksum)
+	s.setBase(spec, checksum)
+	slog.Info("Spec downloaded", "path", spec.Path)
+	result.Downloaded++
+	return true
+}
+
+// handleConflict applies the conflict policy to a spec changed both locally and on the server,
+// and returns true if the local file was written
+func (s *Syncer) handleConflict(ctx context.Context, spec Spec, serverChecksum string, result *SyncResult) bool {
+	switch s.conflictPolicy {
+	case ConflictKeepLocal:
+		s.upload(ctx, spec, result)
+		return false
+	case ConflictKeepServer:
+		return s.download(ctx, spec, result)
+	}
+	conflict := Conflict{Name: spec.Name, Path: spec.Path, LocalChecksum: spec.Checksum, ServerChecksum: serverChecksum}
+	if s.conflictPolicy == ConflictCopy {
+		conflict.CopyPath = spec.Path + ConflictSuffix
+		if !s.hasConflictCopy(spec, serverChecksum) {
+			content, err := s.fetchSpec(ctx, spec.Name)
+			if err == nil {
+				err = writeFileAtomic(conflict.CopyPath, content)
+			}
+			if err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, err)
+				return false
+			}
+		}
+	}
+	entry := s.journal.entry(spec.Name)
+	entry.Path = s.relPath(spec.Path)
+	entry.Conflict = &journalConflict{LocalChecksum: spec.Checksum, ServerChecksum: serverChecksum, Copied: conflict.CopyPath != ""}
+	result.Conflicts = append(result.Conflicts, conflict)
+	slog.Warn("Spec changed locally and on the server", "path", spec.Path, "copy", conflict.CopyPath)
+	return false
+}
+
+// hasConflictCopy reports whether the server version of a conflicting spec was written already
+func (s *Syncer) hasConflictCopy(spec Spec, serverChecksum string) bool {
+	entry, ok := s.journal.Specs[spec.Name]
+	if !ok || entry.Conflict == nil || !entry.Conflict.Copied || entry.Conflict.ServerChecksum != serverChecksum {
+		return false
+	}
+	_, err := os.Stat(spec.Path + ConflictSuffix)
+	return err == nil
+}
+
+// resolveConflict marks a conflict as resolved once its copy is deleted. The server version is then the base,
+// so the merged local spec is uploaded unless the server changed again.
+func (s *Syncer) resolveConflict(spec Spec) {
+	entry, ok := s.journal.Specs[spec.Name]
+	if !ok || entry.Conflict == nil || !entry.Conflict.Copied {
+		return
+	}
+	if _, err := os.Stat(s.absPath(entry.Path) + ConflictSuffix); !os.IsNotExist(err) {
+		return
+	}
+	slog.Info("Spec conflict resolved", "path", spec.Path)
+	s.setBase(spec, entry.Conflict.ServerChecksum)
+}
+
+// setBase records the checksum of a spec that is the same locally and on the server, must be called with runMu held
+func (s *Syncer) setBase(spec Spec, checksum string) {
+	entry := s.journal.entry(spec.Name)
+	entry.Path = s.relPath(spec.Path)
+	if entry.Checksum != checksum {
+		entry.Checksum = checksum
+		entry.SyncedAt = time.Now()
+	}
+	entry.Conflict = nil
+	entry.Deleted = false
+}
+
+// setServer records the checksum of a spec on the server, must be called with runMu held
+func (s *Syncer) setServer(name, checksum string) {
+	s.recordRemote(name, checksum)
+	s.journal.entry(name).ServerChecksum = checksum
+}
+
+func (s *Syncer) fetchSpec(ctx context.Context, name string) ([]byte, error) {
+	spec, err := s.client.GetSpec(ctx, s.companyID, s.target, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download spec %s: %w", name, err)
+	}
+	return []byte(spec.GetContent()), nil
+}
+
+// currentChecksum returns the checksum of a file, or an empty checksum if it does not exist
+func currentChecksum(path string) (string, error) {
+	checksum, _, err := calculateChecksum(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return checksum, err
+}
+
+// conflictList returns the specs in conflict sorted by name, must be called with runMu held
+func (s *Syncer) conflictList() []Conflict {
+	if s.journal == nil {
+		return nil
+	}
+	var conflicts []Conflict
+	for _, name := range slices.Sorted(maps.Keys(s.journal.Specs)) {
+		entry := s.journal.Specs[name]
+		if entry.Conflict == nil {
+			continue
+		}
+		conflict := Conflict{
+			Name:           name,
+			Path:           s.absPath(entry.Path),
+			LocalChecksum:  entry.Conflict.LocalChecksum,
+			ServerChecksum: entry.Conflict.ServerChecksum,
+		}
+		if entry.Conflict.Copied {
+			conflict.CopyPath = conflict.Path + ConflictSuffix
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// relPath returns the path of a spec relative to the specs directory, as recorded in the journal
+func (s *Syncer) relPath(path string) string {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// absPath returns the path of a spec recorded in the journal
+func (s *Syncer) absPath(rel string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(rel))
+}
+
+// writeFileAtomic replaces a file atomically, so the watcher and editors never see it half written
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// Hidden, so it is not taken for a spec while it is written
+	tempFile, err := os.CreateTemp(dir, ".spec-*")
+	if err != nil {
+		return err
+	}
+	tempName := tempFile.Name()
+	defer func() {
+		_ = os.Remove(tempName)
+	}()
+	if err := tempFile.Chmod(0644); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package specsync
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	gosync "sync"
@@ -18,21 +19,34 @@ import (
 // mockClient implements Client for testing
 type mockClient struct {
 	specs       []*artifacts.SpecDetails
+	contents    map[string]string // name -> content of specs on the server
 	uploads     []string
 	uploadErr   error
 	specsErr    error
 	uploadCount atomic.Int32
+	specsCalls  atomic.Int32
 	mu          gosync.Mutex
 }
 
-func (m *mockClient) GetTaskSpecs(companyID int32, taskID string) (*company.GetTaskSpecsResponse, error) {
+func (m *mockClient) GetSpecs(_ context.Context, companyID int32, target Target) (*company.GetTaskSpecsResponse, error) {
+	m.specsCalls.Add(1)
 	if m.specsErr != nil {
 		return nil, m.specsErr
 	}
 	return company.GetTaskSpecsResponse_builder{Specs: m.specs}.Build(), nil
 }
 
-func (m *mockClient) UploadTaskSpec(companyID int32, taskID string, req *company.UploadSpecRequest) error {
+func (m *mockClient) GetSpec(_ context.Context, companyID int32, target Target, name string) (*company.UploadSpecRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content, ok := m.contents[name]
+	if !ok {
+		return nil, fmt.Errorf("spec %s not found", name)
+	}
+	return company.UploadSpecRequest_builder{Name: name, Content: content}.Build(), nil
+}
+
+func (m *mockClient) UploadSpec(_ context.Context, companyID int32, target Target, req *company.UploadSpecRequest) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.uploads = append(m.uploads, req.GetName())
@@ -48,51 +62,28 @@ func makeSpecDetails(name, checksum string) *artifacts.SpecDetails {
 	return spec
 }
 
-func TestShouldUpload(t *testing.T) {
+func TestSyncAction(t *testing.T) {
 	tests := []struct {
-		name        string
-		artifact    Spec
-		serverSpecs []*artifacts.SpecDetails
-		expected    bool
+		name     string
+		local    string
+		server   string
+		base     string
+		expected action
 	}{
-		{
-			name: "artifact not on server - should upload",
-			artifact: Spec{
-				Name:     "new.md",
-				Checksum: "abc123",
-			},
-			serverSpecs: []*artifacts.SpecDetails{},
-			expected:    true,
-		},
-		{
-			name: "artifact changed - should upload",
-			artifact: Spec{
-				Name:     "existing.md",
-				Checksum: "newchecksum",
-			},
-			serverSpecs: []*artifacts.SpecDetails{
-				makeSpecDetails("existing.md", "oldchecksum"),
-			},
-			expected: true,
-		},
-		{
-			name: "artifact unchanged - should skip",
-			artifact: Spec{
-				Name:     "same.md",
-				Checksum: "samechecksum",
-			},
-			serverSpecs: []*artifacts.SpecDetails{
-				makeSpecDetails("same.md", "samechecksum"),
-			},
-			expected: false,
-		},
+		{name: "not on server - upload", local: "abc", expected: actionUpload},
+		{name: "unchanged - nothing", local: "same", server: "same", base: "old", expected: actionNone},
+		{name: "only on server - download", server: "abc", expected: actionDownload},
+		{name: "deleted locally after sync - nothing", server: "abc", base: "abc", expected: actionNone},
+		{name: "changed locally - upload", local: "new", server: "old", base: "old", expected: actionUpload},
+		{name: "changed on server - download", local: "old", server: "new", base: "old", expected: actionDownload},
+		{name: "changed on both - conflict", local: "mine", server: "theirs", base: "old", expected: actionConflict},
+		{name: "differs without base - conflict", local: "mine", server: "theirs", expected: actionConflict},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			result := shouldUpload(tt.artifact, tt.serverSpecs)
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, syncAction(tt.local, tt.server, tt.base))
 		})
 	}
 }
@@ -117,7 +108,7 @@ func TestSyncer_TriggerOnce(t *testing.T) {
 	}
 
 	// Create syncer
-	syncer := NewSyncer(client, 1, "task-123", specsDir, time.Second)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
 
 	// Trigger sync
 	result := syncer.TriggerOnce(context.Background())
@@ -154,7 +145,7 @@ func TestSyncer_TriggerOnce_SkipsUnchanged(t *testing.T) {
 	}
 
 	// Create syncer
-	syncer := NewSyncer(client, 1, "task-123", specsDir, time.Second)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
 
 	// Trigger sync
 	result := syncer.TriggerOnce(context.Background())
@@ -185,7 +176,7 @@ func TestSyncer_TriggerOnce_ConcurrentCalls(t *testing.T) {
 	}
 
 	// Create syncer
-	syncer := NewSyncer(client, 1, "task-123", specsDir, time.Second)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
 
 	// Trigger multiple concurrent syncs
 	var wg gosync.WaitGroup
@@ -222,7 +213,7 @@ func TestSyncer_PerArtifactLocking(t *testing.T) {
 	}
 
 	// Create syncer
-	syncer := NewSyncer(client, 1, "task-123", specsDir, time.Second)
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
 
 	// Upload same artifact concurrently
 	var wg gosync.WaitGroup
@@ -241,3 +232,258 @@ func TestSyncer_PerArtifactLocking(t *testing.T) {
 	// All uploads should succeed (serialized by per-artifact lock)
 	assert.Equal(t, int32(5), client.uploadCount.Load())
 }
+
+func TestSyncer_OnChange(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	testFile := filepath.Join(specsDir, "test.md")
+	require.NoError(t, os.WriteFile(testFile, []byte("# Test"), 0644))
+
+	syncer := NewSyncer(&mockClient{}, 1, TaskTarget("task-123"), specsDir, time.Second)
+	var changes [][]Spec
+	syncer.OnChange(func(specs []Spec) { changes = append(changes, specs) })
+
+	syncer.TriggerOnce(context.Background())
+	require.Len(t, changes, 1)
+	assert.Equal(t, "test.md", changes[0][0].Name)
+
+	syncer.TriggerOnce(context.Background())
+	assert.Len(t, changes, 1, "unchanged specs are not reported")
+
+	require.NoError(t, os.WriteFile(testFile, []byte("# Edited"), 0644))
+	syncer.TriggerOnce(context.Background())
+	require.Len(t, changes, 2)
+	assert.Equal(t, "# Edited", string(changes[1][0].Content))
+
+	require.NoError(t, os.Remove(testFile))
+	syncer.TriggerOnce(context.Background())
+	require.Len(t, changes, 3)
+	assert.Empty(t, changes[2])
+}
+
+func TestSyncer_Status(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	testFile := filepath.Join(specsDir, "test.md")
+	require.NoError(t, os.WriteFile(testFile, []byte("# Test"), 0644))
+	client := &mockClient{uploadErr: assert.AnError}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+
+	status := syncer.Status()
+	assert.True(t, status.LastRun.IsZero())
+	assert.Equal(t, []string{"test.md"}, status.Pending, "specs are pending before the first run")
+
+	syncer.TriggerOnce(context.Background())
+	status = syncer.Status()
+	assert.False(t, status.LastRun.IsZero())
+	require.NotNil(t, status.LastResult)
+	assert.Equal(t, 1, status.LastResult.Failed)
+	assert.ErrorIs(t, status.LastError, assert.AnError)
+	assert.Equal(t, []string{"test.md"}, status.Pending)
+
+	client.uploadErr = nil
+	syncer.TriggerOnce(context.Background())
+	status = syncer.Status()
+	assert.Equal(t, 1, status.LastResult.Uploaded)
+	assert.Empty(t, status.Pending)
+	assert.Error(t, status.LastError, "the last error is kept after a successful run")
+
+	require.NoError(t, os.WriteFile(testFile, []byte("# Edited"), 0644))
+	assert.Equal(t, []string{"test.md"}, syncer.Status().Pending)
+}
+
+func TestSyncer_SyncNowWaitsForRunInProgress(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "test.md"), []byte("# Test"), 0644))
+	syncer := NewSyncer(&mockClient{}, 1, TaskTarget("task-123"), specsDir, time.Second)
+
+	syncer.runMu.Lock()
+	assert.Equal(t, -1, syncer.TriggerOnce(context.Background()).Skipped)
+	done := make(chan *SyncResult)
+	go func() { done <- syncer.SyncNow(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("SyncNow returned while a run was in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	syncer.runMu.Unlock()
+	assert.Equal(t, 1, (<-done).Uploaded)
+}
+
+// setServerSpec adds or replaces a spec on the mock server
+func (m *mockClient) setServerSpec(name, content string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.contents == nil {
+		m.contents = make(map[string]string)
+	}
+	m.contents[name] = content
+	checksum := CalculateChecksumBytes([]byte(content))
+	for _, spec := range m.specs {
+		if spec.GetName() == name {
+			spec.SetChecksum(checksum)
+			return
+		}
+	}
+	m.specs = append(m.specs, makeSpecDetails(name, checksum))
+}
+
+func TestSyncer_DownloadsServerChanges(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	planPath := filepath.Join(specsDir, "plan.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan v1"), 0644))
+
+	client := &mockClient{}
+	client.setServerSpec("plan.md", "# Plan v1")
+	client.setServerSpec("review.md", "# Review")
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+
+	result := syncer.TriggerOnce(context.Background())
+	assert.Equal(t, 1, result.Downloaded, "specs only on the server are pulled")
+	assert.Equal(t, 1, result.Skipped)
+	data, err := os.ReadFile(filepath.Join(specsDir, "review.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Review", string(data))
+
+	client.setServerSpec("plan.md", "# Plan v2")
+	result = syncer.TriggerOnce(context.Background())
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Equal(t, 0, result.Uploaded, "a spec changed only on the server is not overwritten")
+	data, err = os.ReadFile(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan v2", string(data))
+	assert.Empty(t, syncer.Status().Pending)
+
+	// Deleted locally after it was synced, so it is not pulled again
+	require.NoError(t, os.Remove(filepath.Join(specsDir, "review.md")))
+	result = syncer.TriggerOnce(context.Background())
+	assert.Equal(t, 0, result.Downloaded)
+	assert.NoFileExists(t, filepath.Join(specsDir, "review.md"))
+}
+
+func TestSyncer_ConflictCopy(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	planPath := filepath.Join(specsDir, "plan.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan v1"), 0644))
+
+	client := &mockClient{}
+	client.setServerSpec("plan.md", "# Plan v1")
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	syncer.TriggerOnce(context.Background())
+
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan mine"), 0644))
+	client.setServerSpec("plan.md", "# Plan theirs")
+	result := syncer.TriggerOnce(context.Background())
+	require.Len(t, result.Conflicts, 1)
+	conflict := result.Conflicts[0]
+	assert.Equal(t, "plan.md", conflict.Name)
+	assert.Equal(t, planPath+ConflictSuffix, conflict.CopyPath)
+	assert.Equal(t, 0, result.Uploaded, "a conflicting spec is not uploaded")
+	data, err := os.ReadFile(conflict.CopyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan theirs", string(data))
+	data, err = os.ReadFile(planPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan mine", string(data), "the local spec is kept")
+	assert.Len(t, syncer.Status().Conflicts, 1)
+
+	// The conflict stays until the copy is deleted
+	result = syncer.TriggerOnce(context.Background())
+	assert.Len(t, result.Conflicts, 1)
+	assert.Equal(t, int32(0), client.uploadCount.Load())
+
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan merged"), 0644))
+	require.NoError(t, os.Remove(conflict.CopyPath))
+	result = syncer.TriggerOnce(context.Background())
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, 1, result.Uploaded, "the merged spec is uploaded once the copy is deleted")
+	assert.Empty(t, syncer.Status().Conflicts)
+}
+
+func TestSyncer_ConflictPolicies(t *testing.T) {
+	tests := []struct {
+		policy       ConflictPolicy
+		wantUploaded int
+		wantLocal    string
+		wantConflict bool
+	}{
+		{policy: ConflictRefuse, wantLocal: "# Plan mine", wantConflict: true},
+		{policy: ConflictKeepLocal, wantUploaded: 1, wantLocal: "# Plan mine"},
+		{policy: ConflictKeepServer, wantLocal: "# Plan theirs"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			t.Parallel()
+			specsDir := t.TempDir()
+			planPath := filepath.Join(specsDir, "plan.md")
+			require.NoError(t, os.WriteFile(planPath, []byte("# Plan mine"), 0644))
+			client := &mockClient{}
+			client.setServerSpec("plan.md", "# Plan theirs")
+			syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+			syncer.SetConflictPolicy(tt.policy)
+
+			// Without a base it is unknown which side changed, so the spec is in conflict
+			result := syncer.TriggerOnce(context.Background())
+			assert.Equal(t, tt.wantUploaded, result.Uploaded)
+			assert.Equal(t, tt.wantConflict, len(result.Conflicts) == 1)
+			data, err := os.ReadFile(planPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLocal, string(data))
+			assert.NoFileExists(t, planPath+ConflictSuffix)
+		})
+	}
+}
+
+func TestSyncer_NestedSpecsWithSameFileName(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(specsDir, "research"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(specsDir, "plan"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "research", "notes.md"), []byte("# Research notes"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan", "notes.md"), []byte("# Plan notes"), 0644))
+
+	client := &mockClient{}
+	client.setServerSpec("design/notes.md", "# Design notes")
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	result := syncer.TriggerOnce(context.Background())
+	assert.ElementsMatch(t, []string{"research/notes.md", "plan/notes.md"}, client.uploaded())
+	assert.Equal(t, 1, result.Downloaded)
+	data, err := os.ReadFile(filepath.Join(specsDir, "design", "notes.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Design notes", string(data))
+
+	// Once both are on the server, neither is uploaded again
+	client.setServerSpec("research/notes.md", "# Research notes")
+	client.setServerSpec("plan/notes.md", "# Plan notes")
+	result = syncer.TriggerOnce(context.Background())
+	assert.Equal(t, 0, result.Uploaded)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Empty(t, result.Conflicts)
+}
+
+func TestSyncer_MigratesFileNameSpecs(t *testing.T) {
+	t.Parallel()
+	specsDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(specsDir, "research"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "research", "notes.md"), []byte("# Notes"), 0644))
+
+	// Uploaded by an older version under its file name
+	client := &mockClient{}
+	client.setServerSpec("notes.md", "# Notes")
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second)
+	result := syncer.TriggerOnce(context.Background())
+	assert.Equal(t, []Rename{{From: "notes.md", To: "research/notes.md"}}, result.Migrated)
+	assert.Equal(t, []string{"research/notes.md"}, client.uploaded())
+	assert.Equal(t, 0, result.Downloaded)
+	assert.NoFileExists(t, filepath.Join(specsDir, "notes.md"))
+
+	// Reported once, and the legacy spec is not pulled after the local spec is deleted
+	require.NoError(t, os.Remove(filepath.Join(specsDir, "research", "notes.md")))
+	result = NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Second).TriggerOnce(context.Background())
+	assert.Empty(t, result.Migrated)
+	assert.Equal(t, 0, result.Downloaded)
+	assert.NoFileExists(t, filepath.Join(specsDir, "notes.md"))
+}
@@ -2,41 +2,193 @@ package specsync
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
-// Syncer manages specs synchronization
+// Syncer manages specs synchronization of a task or feature
 type Syncer struct {
 	client    Client
 	companyID int32
-	taskID    string
-	taskDir   string
-	interval  time.Duration
+	target    Target
+	dir       string
+	// interval of full sync runs while polling, when the directory can't be watched
+	interval time.Duration
+	// reconcileInterval of full sync runs while watching, which catch missed events and changes on the server
+	reconcileInterval time.Duration
+	// debounce is how long the watcher waits for more changes before syncing the changed files
+	debounce time.Duration
 
 	// Concurrency control
 	runMu      sync.Mutex // Single-flight guard for sync runs
 	specsLocks sync.Map   // map[specName]*sync.Mutex - Note: grows unbounded over time, acceptable for typical session lengths
+
+	// onChange is called with all local specs when a spec is added, removed or edited. Guarded by runMu.
+	onChange  func(specs []Spec)
+	checksums map[string]string // checksums of local specs seen by the last run
+	local     map[string]Spec   // local specs by path as of the last run, guarded by runMu
+	// journal is the sync state persisted in the specs directory, loaded by the first run and guarded by runMu
+	journal     *journal
+	journalData []byte // journal as last read or written
+
+	conflictPolicy ConflictPolicy
+	// serverPatterns are the patterns of PatternsRule, and rules the rules loaded with them by the last full run
+	serverPatterns atomic.Pointer[string]
+	rules          atomic.Pointer[Rules]
+
+	statusMu    sync.Mutex // Guards the fields below, which are read while a run is in progress
+	lastRun     time.Time
+	lastResult  *SyncResult
+	lastErr     error
+	lastErrAt   time.Time
+	remoteSpecs map[string]string // checksums of specs on the server as of the last run
+	watching    bool
+	// lastConflicts holds the conflicts as of the last run
+	lastConflicts []Conflict
+}
+
+// Status describes the state of a syncer, e.g. to tell whether local specs reached Devplan
+type Status struct {
+	CompanyID int32
+	Target    Target
+	Dir       string
+	// LastRun is when the last sync run finished, zero if none finished yet
+	LastRun    time.Time
+	LastResult *SyncResult
+	// LastError is the error of the last failed run, which may be older than LastRun
+	LastError   error
+	LastErrorAt time.Time
+	// Pending holds the names of local specs that differ from the server as of the last run
+	Pending []string
+	// Watching is true while changed files are synced as they are saved, false while the directory is polled
+	Watching bool
+	// Conflicts holds the specs changed both locally and on the server that are not synced
+	Conflicts []Conflict
 }
 
-// NewSyncer creates a new Syncer instance
-func NewSyncer(client Client, companyID int32, taskID, taskDir string, interval time.Duration) *Syncer {
+// NewSyncer creates a new Syncer instance syncing the specs of the target found in dir
+func NewSyncer(client Client, companyID int32, target Target, dir string, interval time.Duration) *Syncer {
 	if interval == 0 {
 		interval = DefaultSyncInterval
 	}
 	return &Syncer{
-		client:    client,
-		taskDir:   taskDir,
-		companyID: companyID,
-		taskID:    taskID,
-		interval:  interval,
+		client:            client,
+		dir:               dir,
+		companyID:         companyID,
+		target:            target,
+		interval:          interval,
+		reconcileInterval: DefaultReconcileInterval,
+		debounce:          DefaultDebounce,
+		conflictPolicy:    ConflictCopy,
+	}
+}
+
+// SpecsDir returns the specs directory of the target in the workspace at root, as configured in Devplan
+func SpecsDir(ctx context.Context, client Client, companyID int32, target Target, root string) (string, error) {
+	resp, err := client.GetSpecs(ctx, companyID, target)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s specs: %w", target.Kind, err)
+	}
+	// Paths of feature specs are returned under the feature ID
+	dir := resp.GetPathsInfo().GetTaskPaths()[target.ID].GetTaskDir()
+	if dir == "" {
+		return "", fmt.Errorf("%s has no spec directory configured", target)
+	}
+	return filepath.Join(root, dir), nil
+}
+
+// OnChange registers a callback called after a sync run finds that local specs were added, removed or edited.
+// The first run reports all specs found. Must be called before the syncer is started.
+func (s *Syncer) OnChange(fn func(specs []Spec)) {
+	s.onChange = fn
+}
+
+// SetConflictPolicy sets how specs changed both locally and on the server are handled, ConflictCopy by default.
+// Must be called before the syncer is started.
+func (s *Syncer) SetConflictPolicy(policy ConflictPolicy) {
+	s.conflictPolicy = policy
+}
+
+// SetServerPatterns sets the patterns of PatternsRule, which decide with IgnoreFile of the directory which files are specs.
+// Full runs get them again if the client is a PatternsClient, so they only need to be set for the first run.
+func (s *Syncer) SetServerPatterns(patterns string) {
+	s.serverPatterns.Store(&patterns)
+}
+
+// refreshPatterns gets the patterns of PatternsRule again if the client can. The previous patterns are kept
+// if they can't be fetched. Must be called with runMu held.
+func (s *Syncer) refreshPatterns(ctx context.Context) {
+	client, ok := s.client.(PatternsClient)
+	if !ok {
+		return
+	}
+	patterns, err := client.SpecPatterns(ctx, s.companyID)
+	if err != nil {
+		slog.Warn("Failed to refresh spec patterns, keeping the previous ones", "companyID", s.companyID, "err", err)
+		return
+	}
+	s.SetServerPatterns(patterns)
+}
+
+func (s *Syncer) patterns() string {
+	if patterns := s.serverPatterns.Load(); patterns != nil {
+		return *patterns
+	}
+	return ""
+}
+
+// loadRules reloads the rules of the specs directory, e.g. after IgnoreFile was edited
+func (s *Syncer) loadRules() (*Rules, error) {
+	rules, err := LoadRules(s.dir, s.patterns())
+	if err != nil {
+		return nil, err
+	}
+	s.rules.Store(rules)
+	return rules, nil
+}
+
+// currentRules returns the rules loaded by the last full run, or loads them if there was none
+func (s *Syncer) currentRules() *Rules {
+	if rules := s.rules.Load(); rules != nil {
+		return rules
+	}
+	rules, err := LoadRules(s.dir, s.patterns())
+	if err != nil {
+		slog.Warn("Failed to load spec rules, using the defaults", "dir", s.dir, "err", err)
+		return DefaultRules()
+	}
+	return rules
+}
+
+// detectChanges calls onChange if the local specs differ from the ones seen by the previous run
+func (s *Syncer) detectChanges(specs []Spec) {
+	checksums := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		checksums[spec.Name] = spec.Checksum
+	}
+	if s.checksums != nil && maps.Equal(s.checksums, checksums) {
+		return
+	}
+	s.checksums = checksums
+	if s.onChange != nil {
+		s.onChange(specs)
 	}
 }
 
 // TriggerOnce runs a single sync operation
 // Returns immediately if a sync is already in progress
-func (s *Syncer) TriggerOnce(_ context.Context) *SyncResult {
+func (s *Syncer) TriggerOnce(ctx context.Context) *SyncResult {
 	// Try to acquire the run lock
 	if !s.runMu.TryLock() {
 		slog.Debug("Sync already in progress, skipping")
@@ -44,26 +196,190 @@ func (s *Syncer) TriggerOnce(_ context.Context) *SyncResult {
 		return &SyncResult{Skipped: -1} // -1 indicates skipped due to concurrent run
 	}
 	defer s.runMu.Unlock()
+	return s.run(ctx)
+}
+
+// SyncNow runs a single sync operation like TriggerOnce, but waits for a sync in progress instead of skipping,
+// so the result covers all changes made before the call
+func (s *Syncer) SyncNow(ctx context.Context) *SyncResult {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	return s.run(ctx)
+}
+
+// run executes a sync run, must be called with runMu held
+func (s *Syncer) run(ctx context.Context) *SyncResult {
+	// The context is owned by the caller (e.g. the MCP server), so cancelling it aborts in-flight uploads.
+	result := s.runSync(ctx, false)
+	s.recordRun(result)
+	return result
+}
+
+// resume runs the first sync of RunBackground like TriggerOnce, but trusts a recent journal to skip the server lookup
+func (s *Syncer) resume(ctx context.Context) {
+	if !s.runMu.TryLock() {
+		return
+	}
+	defer s.runMu.Unlock()
+	s.recordRun(s.runSync(ctx, true))
+}
+
+// ensureJournal loads the journal on the first run, must be called with runMu held
+func (s *Syncer) ensureJournal() {
+	if s.journal != nil {
+		return
+	}
+	s.journal, s.journalData = loadJournal(s.dir, s.target)
+	for name, entry := range s.journal.Specs {
+		if entry.ServerChecksum != "" {
+			s.recordRemote(name, entry.ServerChecksum)
+		}
+	}
+}
+
+// saveJournal writes the journal if it changed, must be called with runMu held
+func (s *Syncer) saveJournal() {
+	if s.journal == nil {
+		return
+	}
+	data, err := s.journal.save(s.dir, s.journalData)
+	if err != nil {
+		slog.Warn("Failed to save spec sync journal", "dir", s.dir, "err", err)
+	}
+	s.journalData = data
+}
+
+// setLocal replaces the local specs seen by a full run, must be called with runMu held
+func (s *Syncer) setLocal(specs []Spec) {
+	s.local = make(map[string]Spec, len(specs))
+	for _, spec := range specs {
+		s.local[spec.Path] = spec
+	}
+}
 
-	// Using background context to avoid cancelling on command termination.
-	result := s.runSync(context.Background())
+// localSpecs returns the local specs seen so far sorted by path, must be called with runMu held
+func (s *Syncer) localSpecs() []Spec {
+	specs := slices.Collect(maps.Values(s.local))
+	slices.SortFunc(specs, func(a, b Spec) int { return strings.Compare(a.Path, b.Path) })
+	return specs
+}
+
+// recordRun updates the status after a run, must be called with runMu held
+func (s *Syncer) recordRun(result *SyncResult) {
 	if len(result.Errors) > 0 {
 		slog.Error("Failed to sync specs", "errors", result.Errors)
 	}
-	return result
+	s.saveJournal()
+	conflicts := s.conflictList()
+	s.statusMu.Lock()
+	s.lastConflicts = conflicts
+	defer s.statusMu.Unlock()
+	s.lastRun = time.Now()
+	s.lastResult = result
+	if len(result.Errors) > 0 {
+		s.lastErr = errors.Join(result.Errors...)
+		s.lastErrAt = s.lastRun
+	}
 }
 
-// RunBackground starts the background sync loop
-// Blocks until context is canceled
+func (s *Syncer) setWatching(watching bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.watching = watching
+}
+
+// recordRemote remembers the checksum of a spec on the server
+func (s *Syncer) recordRemote(name, checksum string) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if s.remoteSpecs == nil {
+		s.remoteSpecs = make(map[string]string)
+	}
+	s.remoteSpecs[name] = checksum
+}
+
+// Status returns the state of the syncer. Pending changes are found by comparing the local specs
+// with the server state seen by the last run, so all local specs are pending before the first run.
+func (s *Syncer) Status() Status {
+	s.statusMu.Lock()
+	status := Status{
+		CompanyID:   s.companyID,
+		Target:      s.target,
+		Dir:         s.dir,
+		LastRun:     s.lastRun,
+		LastResult:  s.lastResult,
+		LastError:   s.lastErr,
+		LastErrorAt: s.lastErrAt,
+		Watching:    s.watching,
+		Conflicts:   s.lastConflicts,
+	}
+	remote := maps.Clone(s.remoteSpecs)
+	s.statusMu.Unlock()
+
+	localSpecs, err := s.target.DiscoverMatching(s.dir, s.currentRules())
+	if err != nil {
+		slog.Debug("Failed to discover specs for status", "dir", s.dir, "err", err)
+		return status
+	}
+	for _, spec := range localSpecs {
+		if checksum, ok := remote[spec.Name]; !ok || checksum != spec.Checksum {
+			status.Pending = append(status.Pending, spec.Name)
+		}
+	}
+	sort.Strings(status.Pending)
+	return status
+}
+
+// RunBackground syncs the specs until the context is canceled. It runs a full sync, which skips the server lookup
+// if the journal shows no local changes since a recent one, then watches the directory
+// and uploads files as they change, with a full sync every reconcile interval. Where the directory can't be watched,
+// e.g. because it doesn't exist yet or the system is out of watches, it polls with a full sync every interval instead,
+// and switches to watching once the directory can be watched, e.g. after the agent wrote the first spec.
 func (s *Syncer) RunBackground(ctx context.Context) {
+	watcher, err := s.watch()
+	if err != nil {
+		slog.Warn("Failed to watch specs, polling instead", "dir", s.dir, "err", err)
+	} else {
+		s.setWatching(true)
+	}
+	s.resume(ctx)
+	for {
+		if watcher == nil {
+			if watcher = s.poll(ctx); watcher == nil {
+				return
+			}
+			slog.Info("Watching specs", "dir", s.dir)
+			s.setWatching(true)
+			// Files written before the watches were added are only found by a full run
+			s.TriggerOnce(ctx)
+		}
+		stopped := s.runWatcher(ctx, watcher)
+		_ = watcher.Close()
+		s.setWatching(false)
+		if stopped {
+			return
+		}
+		slog.Warn("Spec watcher closed, polling instead", "dir", s.dir)
+		watcher = nil
+	}
+}
+
+// poll runs a full sync every interval until the directory can be watched, returning the watcher,
+// or the context is canceled, returning nil
+func (s *Syncer) poll(ctx context.Context) *fsnotify.Watcher {
 	ticker := time.NewTicker(s.interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 		case <-ticker.C:
+			watcher, err := s.watch()
+			if err == nil {
+				return watcher
+			}
+			slog.Debug("Failed to watch specs", "dir", s.dir, "err", err)
 			s.TriggerOnce(ctx)
 		}
 	}
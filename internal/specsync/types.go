@@ -1,27 +1,149 @@
 package specsync
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
 )
 
+// TargetKind is the kind of Devplan document whose specs are synced
+type TargetKind string
+
+const (
+	TargetTask    TargetKind = "task"
+	TargetFeature TargetKind = "feature"
+)
+
+// Target identifies the task or feature whose specs are synced
+type Target struct {
+	Kind TargetKind
+	ID   string
+}
+
+// TaskTarget returns the target of the specs of a task
+func TaskTarget(taskID string) Target {
+	return Target{Kind: TargetTask, ID: taskID}
+}
+
+// FeatureTarget returns the target of the specs of a feature, e.g. planning docs of a feature workspace
+func FeatureTarget(featureID string) Target {
+	return Target{Kind: TargetFeature, ID: featureID}
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s %s", t.Kind, t.ID)
+}
+
+// Discover finds the local specs of the target in its specs directory by the rules of the directory,
+// without the patterns of PatternsRule
+func (t Target) Discover(dir string) ([]Spec, error) {
+	rules, err := LoadRules(dir, "")
+	if err != nil {
+		return nil, err
+	}
+	return t.DiscoverMatching(dir, rules)
+}
+
+// DiscoverMatching finds the local specs of the target in its specs directory by the given rules
+func (t Target) DiscoverMatching(dir string, rules *Rules) ([]Spec, error) {
+	return discoverSpecs(dir, t.recursive(), rules)
+}
+
+// ListFiles returns the files in the specs directory of the target with whether they are specs by the given rules
+func (t Target) ListFiles(dir string, rules *Rules) ([]FileMatch, error) {
+	return ListFiles(dir, t.recursive(), rules)
+}
+
+// recursive reports whether specs in subdirectories belong to the target. Subdirectories of a feature
+// hold the specs of its tasks.
+func (t Target) recursive() bool {
+	return t.Kind != TargetFeature
+}
+
 // Client interface for artifact operations
 type Client interface {
-	GetTaskSpecs(companyID int32, taskID string) (*company.GetTaskSpecsResponse, error)
-	UploadTaskSpec(companyID int32, taskID string, req *company.UploadSpecRequest) error
+	GetSpecs(ctx context.Context, companyID int32, target Target) (*company.GetTaskSpecsResponse, error)
+	// GetSpec downloads a spec with its content
+	GetSpec(ctx context.Context, companyID int32, target Target, name string) (*company.UploadSpecRequest, error)
+	UploadSpec(ctx context.Context, companyID int32, target Target, req *company.UploadSpecRequest) error
+}
+
+// PatternsClient is a Client that gets the patterns of PatternsRule. Syncers with such a client refresh
+// the patterns on every full run, so changes of the company rule apply without a restart.
+type PatternsClient interface {
+	SpecPatterns(ctx context.Context, companyID int32) (string, error)
 }
 
 // SyncResult holds results of a sync run
 type SyncResult struct {
-	Uploaded int
-	Skipped  int
-	Failed   int
-	Errors   []error
+	Uploaded   int
+	Downloaded int
+	Skipped    int
+	Failed     int
+	Errors     []error
+	// Conflicts holds the specs changed both locally and on the server that were not synced
+	Conflicts []Conflict
+	// Deleted holds the names of synced specs whose local files were deleted. They are kept in Devplan.
+	Deleted []string
+	// Renamed holds the synced specs whose local files were renamed. The spec is uploaded under the new name
+	// and the old one is kept in Devplan.
+	Renamed []Rename
+	// Migrated holds the specs in subdirectories that older versions uploaded under their file name.
+	// They are uploaded under their path, and the specs named by the file name are kept in Devplan and not downloaded.
+	Migrated []Rename
+}
+
+// Rename is a synced spec whose local file was renamed, found by its unchanged content
+type Rename struct {
+	From string
+	To   string
+}
+
+// Conflict is a spec changed both locally and on the server since it was last synced
+type Conflict struct {
+	Name           string
+	Path           string
+	LocalChecksum  string
+	ServerChecksum string
+	// CopyPath is the file holding the server version with ConflictCopy
+	CopyPath string
+}
+
+// ConflictPolicy decides what happens to a spec changed both locally and on the server
+type ConflictPolicy string
+
+const (
+	// ConflictCopy keeps the local spec and writes the server version next to it with ConflictSuffix.
+	// The spec is not uploaded until the copy is deleted, which marks the conflict as resolved.
+	ConflictCopy ConflictPolicy = "copy"
+	// ConflictRefuse keeps the local spec and does not upload it
+	ConflictRefuse ConflictPolicy = "refuse"
+	// ConflictKeepLocal uploads the local spec, overwriting the server version
+	ConflictKeepLocal ConflictPolicy = "local"
+	// ConflictKeepServer downloads the server version, overwriting the local spec
+	ConflictKeepServer ConflictPolicy = "server"
+)
+
+// ConflictSuffix is appended to the path of a spec to get the path of the server version written by ConflictCopy
+const ConflictSuffix = ".conflict"
+
+// ParseConflictPolicy returns the policy with the given name
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(name); p {
+	case ConflictCopy, ConflictRefuse, ConflictKeepLocal, ConflictKeepServer:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q, expected copy, refuse, local or server", name)
 }
 
 // Spec represents a local artifact file
 type Spec struct {
+	// Name identifies the spec in Devplan: its slash-separated path relative to the specs directory,
+	// so specs with the same file name in different subdirectories don't collide
 	Name     string
 	Path     string
 	Checksum string
-	Content  []byte
+	// Content is nil for specs found by a syncer whose files did not change since they were last hashed
+	Content []byte
 }
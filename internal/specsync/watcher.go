@@ -0,0 +1,231 @@
+package specsync
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultReconcileInterval is how often a watching syncer compares all specs with the server
+	DefaultReconcileInterval = 5 * time.Minute
+	// DefaultDebounce is how long a watching syncer waits for more changes, e.g. of an editor saving in steps
+	DefaultDebounce = 300 * time.Millisecond
+)
+
+// newWatcher creates the file watcher, replaced in tests to simulate systems where watches fail
+var newWatcher = fsnotify.NewWatcher
+
+// watch starts watching the specs directory
+func (s *Syncer) watch() (*fsnotify.Watcher, error) {
+	watcher, err := newWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addWatches(watcher, s.dir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// addWatches watches dir and, for tasks, all its subdirectories except hidden ones
+func (s *Syncer) addWatches(watcher *fsnotify.Watcher, dir string) error {
+	if !s.target.recursive() {
+		return watcher.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// runWatcher syncs changed files until the context is canceled, returning true, or the watcher is closed, returning false
+func (s *Syncer) runWatcher(ctx context.Context, watcher *fsnotify.Watcher) bool {
+	reconcile := time.NewTicker(s.reconcileInterval)
+	defer reconcile.Stop()
+	debounce := time.NewTimer(s.debounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	changed := make(map[string]struct{})
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return false
+			}
+			if event.Name == filepath.Join(s.dir, IgnoreFile) {
+				// Files may have become specs or stopped being ones
+				s.TriggerOnce(ctx)
+				continue
+			}
+			if s.handleEvent(watcher, event, changed) {
+				debounce.Reset(s.debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return false
+			}
+			// Events may have been dropped, e.g. when the event queue overflowed
+			slog.Warn("Spec watcher failed, running a full sync", "dir", s.dir, "err", err)
+			s.TriggerOnce(ctx)
+		case <-debounce.C:
+			if !s.syncPaths(ctx, slices.Collect(maps.Keys(changed))) {
+				// A full run is in progress and may have missed the latest changes, so retry after it
+				debounce.Reset(s.debounce)
+				continue
+			}
+			clear(changed)
+		case <-reconcile.C:
+			s.TriggerOnce(ctx)
+		}
+	}
+}
+
+// handleEvent records the path of an event in changed and reports whether it was recorded.
+// New subdirectories of tasks are watched, and the specs written to them before the watch was added are recorded.
+func (s *Syncer) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Event, changed map[string]struct{}) bool {
+	if event.Op == fsnotify.Chmod || !s.inScope(event.Name) {
+		return false
+	}
+	if event.Has(fsnotify.Create) && s.target.recursive() {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := s.addWatches(watcher, event.Name); err != nil {
+				slog.Warn("Failed to watch specs directory", "dir", event.Name, "err", err)
+			}
+			_ = filepath.WalkDir(event.Name, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					changed[path] = struct{}{}
+				}
+				return nil
+			})
+			return true
+		}
+	}
+	changed[event.Name] = struct{}{}
+	return true
+}
+
+// inScope reports whether a path may hold specs of the target: it is inside the directory, not hidden,
+// and for features directly in the directory
+func (s *Syncer) inScope(path string) bool {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !s.target.recursive() && len(parts) > 1 {
+		return false
+	}
+	for _, part := range parts {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// syncPaths syncs the specs at the changed paths, looking up the server only if their content changed
+// or the copy of a conflict was deleted. Returns false without syncing if a run is in progress.
+func (s *Syncer) syncPaths(ctx context.Context, paths []string) bool {
+	if !s.runMu.TryLock() {
+		return false
+	}
+	defer s.runMu.Unlock()
+	if s.local == nil {
+		s.local = make(map[string]Spec)
+	}
+	s.ensureJournal()
+	rules := s.currentRules()
+
+	result := &SyncResult{}
+	changed := make(map[string]Spec)
+	var removed []Spec
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			removed = append(removed, s.removeLocal(path)...)
+			// A deleted conflict copy resolves the conflict of its spec
+			if spec, ok := s.local[strings.TrimSuffix(path, ConflictSuffix)]; ok && strings.HasSuffix(path, ConflictSuffix) {
+				if entry := s.journal.Specs[spec.Name]; entry != nil && entry.Conflict != nil {
+					changed[spec.Path] = spec
+				}
+			}
+			continue
+		}
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if isSpec, _ := rules.Match(s.relPath(path)); !isSpec {
+			continue
+		}
+		checksum, data, err := calculateChecksum(path)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		if s.local[path].Checksum == checksum {
+			// e.g. a file saved without changes
+			continue
+		}
+		spec := Spec{Name: s.relPath(path), Path: path, Checksum: checksum, Content: data}
+		s.local[path] = spec
+		s.journal.entry(spec.Name).setLocal(spec.Name, checksum, info)
+		changed[path] = spec
+	}
+	// A spec may be removed and written again before the changes are synced
+	present := make(map[string]bool, len(s.local))
+	for _, spec := range s.local {
+		present[spec.Name] = true
+	}
+	for _, spec := range removed {
+		if !present[spec.Name] {
+			s.markDeleted(spec.Name, result)
+		}
+	}
+	changedSpecs := slices.Collect(maps.Values(changed))
+	slices.SortFunc(changedSpecs, func(a, b Spec) int { return strings.Compare(a.Path, b.Path) })
+	s.trackRenames(changedSpecs, result)
+	if len(changedSpecs) == 0 && len(removed) == 0 && len(result.Errors) == 0 {
+		return true
+	}
+	slog.Debug("Specs changed", "dir", s.dir, "changed", len(changedSpecs))
+	s.detectChanges(s.localSpecs())
+	if len(changedSpecs) > 0 {
+		s.syncSpecs(ctx, changedSpecs, false, result)
+	}
+	s.recordRun(result)
+	return true
+}
+
+// removeLocal forgets the local specs at or under a removed path and returns them, must be called with runMu held
+func (s *Syncer) removeLocal(path string) []Spec {
+	var removed []Spec
+	for specPath, spec := range s.local {
+		if specPath == path || strings.HasPrefix(specPath, path+string(filepath.Separator)) {
+			delete(s.local, specPath)
+			removed = append(removed, spec)
+		}
+	}
+	return removed
+}
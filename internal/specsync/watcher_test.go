@@ -0,0 +1,164 @@
+package specsync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *mockClient) uploaded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.uploads)
+}
+
+// startWatching runs the syncer in the background and waits for its first run
+func startWatching(t *testing.T, syncer *Syncer) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		syncer.RunBackground(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Eventually(t, func() bool { return !syncer.Status().LastRun.IsZero() }, time.Second, 5*time.Millisecond)
+}
+
+func TestSyncer_WatchUploadsChangedFiles(t *testing.T) {
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+
+	client := &mockClient{}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Hour)
+	syncer.debounce = 20 * time.Millisecond
+	startWatching(t, syncer)
+	assert.True(t, syncer.Status().Watching)
+	assert.Equal(t, []string{"plan.md"}, client.uploaded())
+	assert.Equal(t, int32(1), client.specsCalls.Load())
+
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "design.md"), []byte("# Design"), 0644))
+	require.Eventually(t, func() bool { return len(client.uploaded()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"plan.md", "design.md"}, client.uploaded(), "only the changed file is uploaded")
+	assert.Equal(t, int32(2), client.specsCalls.Load())
+
+	// Saving without changes, hidden files and other files don't reach the server
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "design.md"), []byte("# Design"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, ".draft.md"), []byte("# Draft"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "notes.txt"), []byte("notes"), 0644))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), client.specsCalls.Load())
+
+	// Specs in new subdirectories are picked up, including ones written before the directory was watched
+	nested := filepath.Join(specsDir, "api", "v2")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "endpoints.md"), []byte("# Endpoints"), 0644))
+	require.Eventually(t, func() bool { return slices.Contains(client.uploaded(), "api/v2/endpoints.md") }, time.Second, 5*time.Millisecond)
+}
+
+func TestSyncer_WatchReportsRemovedSpecs(t *testing.T) {
+	specsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "design.md"), []byte("# Design"), 0644))
+
+	changes := make(chan []string, 10)
+	syncer := NewSyncer(&mockClient{}, 1, TaskTarget("task-123"), specsDir, time.Hour)
+	syncer.debounce = 20 * time.Millisecond
+	syncer.OnChange(func(specs []Spec) {
+		var names []string
+		for _, spec := range specs {
+			names = append(names, spec.Name)
+		}
+		changes <- names
+	})
+	startWatching(t, syncer)
+	assert.Equal(t, []string{"design.md", "plan.md"}, <-changes)
+
+	require.NoError(t, os.Remove(filepath.Join(specsDir, "design.md")))
+	select {
+	case names := <-changes:
+		assert.Equal(t, []string{"plan.md"}, names)
+	case <-time.After(time.Second):
+		t.Fatal("removed spec was not reported")
+	}
+}
+
+func TestSyncer_FeatureWatchIgnoresTaskDirs(t *testing.T) {
+	featureDir := t.TempDir()
+	taskDir := filepath.Join(featureDir, "task-1")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+
+	client := &mockClient{}
+	syncer := NewSyncer(client, 1, FeatureTarget("story-123"), featureDir, time.Hour)
+	syncer.debounce = 20 * time.Millisecond
+	startWatching(t, syncer)
+
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "task.md"), []byte("# Task"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(featureDir, "overview.md"), []byte("# Overview"), 0644))
+	require.Eventually(t, func() bool { return len(client.uploaded()) > 0 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"overview.md"}, client.uploaded())
+}
+
+func TestSyncer_PollsWhenWatchFails(t *testing.T) {
+	orig := newWatcher
+	newWatcher = func() (*fsnotify.Watcher, error) { return nil, errors.New("too many open files") }
+	t.Cleanup(func() { newWatcher = orig })
+
+	specsDir := t.TempDir()
+	client := &mockClient{}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, 20*time.Millisecond)
+	startWatching(t, syncer)
+	assert.False(t, syncer.Status().Watching)
+
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+	require.Eventually(t, func() bool { return len(client.uploaded()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestSyncer_WatchesDirectoryCreatedAfterStart(t *testing.T) {
+	specsDir := filepath.Join(t.TempDir(), "specs", "task-123")
+	client := &mockClient{}
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, 20*time.Millisecond)
+	syncer.debounce = 20 * time.Millisecond
+	startWatching(t, syncer)
+	assert.False(t, syncer.Status().Watching)
+
+	require.NoError(t, os.MkdirAll(specsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "plan.md"), []byte("# Plan"), 0644))
+	require.Eventually(t, func() bool { return syncer.Status().Watching }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return len(client.uploaded()) == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(specsDir, "design.md"), []byte("# Design"), 0644))
+	require.Eventually(t, func() bool { return len(client.uploaded()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestSyncer_WatchUploadsWhenConflictCopyIsDeleted(t *testing.T) {
+	specsDir := t.TempDir()
+	planPath := filepath.Join(specsDir, "plan.md")
+	require.NoError(t, os.WriteFile(planPath, []byte("# Plan mine"), 0644))
+
+	client := &mockClient{}
+	client.setServerSpec("plan.md", "# Plan theirs")
+	syncer := NewSyncer(client, 1, TaskTarget("task-123"), specsDir, time.Hour)
+	syncer.debounce = 20 * time.Millisecond
+	startWatching(t, syncer)
+	require.Len(t, syncer.Status().Conflicts, 1)
+	require.FileExists(t, planPath+ConflictSuffix)
+
+	require.NoError(t, os.Remove(planPath+ConflictSuffix))
+	// The conflict is cleared after the upload completes, so wait for both
+	require.Eventually(t, func() bool {
+		return len(client.uploaded()) == 1 && len(syncer.Status().Conflicts) == 0
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,190 @@
+package apicache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+)
+
+const (
+	cacheDirName = "cache"
+	entrySuffix  = ".json"
+	dirMode      = 0700
+	fileMode     = 0600
+)
+
+// Entry is a cached API response
+type Entry struct {
+	Path         string          `json:"path"`
+	StoredAt     time.Time       `json:"storedAt"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"lastModified,omitempty"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// Age returns how long ago the entry was stored or revalidated
+func (e *Entry) Age() time.Duration {
+	return time.Since(e.StoredAt)
+}
+
+// Store keeps API responses on disk, grouped by scope (usually a company)
+type Store struct {
+	dir string
+}
+
+// New creates a store rooted in the given directory
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Default returns the store of the active profile under ~/.devplan/cache.
+// Profiles get separate stores since they may belong to different accounts.
+func Default() (*Store, error) {
+	configDir, err := prefs.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(ProfileDir(configDir, prefs.ActiveProfile())), nil
+}
+
+// ProfileDir returns the cache directory of the profile
+func ProfileDir(configDir, profile string) string {
+	if profile == prefs.DefaultProfile {
+		return filepath.Join(configDir, cacheDirName)
+	}
+	return filepath.Join(configDir, cacheDirName+"-"+profile)
+}
+
+// Dir returns the root directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key builds an entry key from the API base URL and request path
+func Key(baseURL, path string) string {
+	hash := sha256.Sum256([]byte(baseURL + "/" + path))
+	return hex.EncodeToString(hash[:16])
+}
+
+// Get returns the cached entry, if any. Unreadable entries are treated as missing.
+func (s *Store) Get(scope, key string) (*Entry, bool) {
+	data, err := os.ReadFile(s.entryPath(scope, key))
+	if err != nil {
+		return nil, false
+	}
+	entry := &Entry{}
+	if err := json.Unmarshal(data, entry); err != nil || len(entry.Body) == 0 {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores the entry atomically
+func (s *Store) Put(scope, key string, entry *Entry) error {
+	if !json.Valid(entry.Body) {
+		return fmt.Errorf("cannot cache non-JSON response for %s", entry.Path)
+	}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(s.dir, scope)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tempFile, err := os.CreateTemp(dir, "entry-*")
+	if err != nil {
+		return err
+	}
+	tempName := tempFile.Name()
+	defer func() {
+		_ = os.Remove(tempName)
+	}()
+	if err := tempFile.Chmod(fileMode); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if _, err := tempFile.Write(payload); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempName, s.entryPath(scope, key))
+}
+
+// Clear removes all entries of the scope, or the whole cache if scope is empty.
+// Returns the number of removed entries.
+func (s *Store) Clear(scope string) (int, error) {
+	stats, err := s.Stats()
+	if err != nil {
+		return 0, err
+	}
+	target := s.dir
+	removed := stats.Entries
+	if scope != "" {
+		target = filepath.Join(s.dir, scope)
+		removed = stats.Scopes[scope]
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return 0, fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return removed, nil
+}
+
+// Stats describes the cache contents
+type Stats struct {
+	Entries int
+	Bytes   int64
+	// Scopes holds the number of entries per scope
+	Scopes map[string]int
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Stats walks the cache directory and summarizes its contents
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Scopes: make(map[string]int)}
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), entrySuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		stats.Scopes[rel]++
+		modTime := info.ModTime()
+		if stats.Oldest.IsZero() || modTime.Before(stats.Oldest) {
+			stats.Oldest = modTime
+		}
+		if modTime.After(stats.Newest) {
+			stats.Newest = modTime
+		}
+		return nil
+	})
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read cache directory %s: %w", s.dir, err)
+	}
+	return stats, nil
+}
+
+func (s *Store) entryPath(scope, key string) string {
+	return filepath.Join(s.dir, scope, key+entrySuffix)
+}
@@ -0,0 +1,86 @@
+package apicache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePutGet(t *testing.T) {
+	t.Parallel()
+	store := New(t.TempDir())
+	key := Key("https://app.devplan.com", "api/v1/company/1/projects")
+
+	_, ok := store.Get("company-1", key)
+	assert.False(t, ok)
+
+	storedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	require.NoError(t, store.Put("company-1", key, &Entry{
+		Path:     "api/v1/company/1/projects",
+		StoredAt: storedAt,
+		ETag:     `"abc"`,
+		Body:     []byte(`{"projects":[]}`),
+	}))
+
+	entry, ok := store.Get("company-1", key)
+	require.True(t, ok)
+	assert.Equal(t, `"abc"`, entry.ETag)
+	assert.JSONEq(t, `{"projects":[]}`, string(entry.Body))
+	assert.True(t, storedAt.Equal(entry.StoredAt))
+
+	info, err := os.Stat(filepath.Join(store.Dir(), "company-1", key+entrySuffix))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(fileMode), info.Mode().Perm())
+}
+
+func TestStoreRejectsNonJSONBody(t *testing.T) {
+	t.Parallel()
+	store := New(t.TempDir())
+	err := store.Put("user", "key", &Entry{Path: "p", Body: []byte("not json")})
+	assert.Error(t, err)
+}
+
+func TestKeyDependsOnBaseURL(t *testing.T) {
+	t.Parallel()
+	assert.NotEqual(t, Key("https://app.devplan.com", "p"), Key("https://beta.devplan.com", "p"))
+	assert.Equal(t, Key("https://app.devplan.com", "p"), Key("https://app.devplan.com", "p"))
+}
+
+func TestStoreStatsAndClear(t *testing.T) {
+	t.Parallel()
+	store := New(t.TempDir())
+	for _, scope := range []string{"company-1", "company-1", "company-2", "user"} {
+		key := Key("base", scope+time.Now().String())
+		require.NoError(t, store.Put(scope, key, &Entry{Path: "p", StoredAt: time.Now(), Body: []byte(`{}`)}))
+	}
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Entries)
+	assert.Equal(t, 2, stats.Scopes["company-1"])
+	assert.Greater(t, stats.Bytes, int64(0))
+
+	removed, err := store.Clear("company-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	removed, err = store.Clear("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	stats, err = store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+}
+
+func TestStoreStatsMissingDir(t *testing.T) {
+	t.Parallel()
+	store := New(filepath.Join(t.TempDir(), "missing"))
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+}
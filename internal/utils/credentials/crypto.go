@@ -0,0 +1,156 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+)
+
+// EncryptionMode selects how the credentials file is encrypted
+type EncryptionMode string
+
+const (
+	EncryptionNone EncryptionMode = "none"
+	// EncryptionMachine derives the key from the machine id and user name.
+	// It keeps the key unreadable when the file is copied elsewhere, but not from other programs of the same user.
+	EncryptionMachine EncryptionMode = "machine"
+	// EncryptionPassphrase derives the key from the passphrase in DEVPLAN_CREDENTIALS_PASSPHRASE
+	EncryptionPassphrase EncryptionMode = "passphrase"
+
+	// PassphraseEnv holds the passphrase of the credentials file
+	PassphraseEnv = "DEVPLAN_CREDENTIALS_PASSPHRASE"
+
+	saltLen       = 16
+	keyLen        = 32
+	kdfIterations = 200_000
+)
+
+// Encryption configures how FileStore protects secrets
+type Encryption struct {
+	Mode EncryptionMode
+	// Passphrase is required to read or write files encrypted with EncryptionPassphrase
+	Passphrase string
+}
+
+func encryptionFromPrefs() (Encryption, error) {
+	mode := EncryptionMode(prefs.GetCredentialsEncryption())
+	switch mode {
+	case "", EncryptionNone, EncryptionMachine, EncryptionPassphrase:
+	default:
+		return Encryption{}, fmt.Errorf("unknown %s %q, use %q, %q or %q",
+			prefs.CredentialsEncryptionKey, mode, EncryptionNone, EncryptionMachine, EncryptionPassphrase)
+	}
+	return Encryption{Mode: mode, Passphrase: os.Getenv(PassphraseEnv)}, nil
+}
+
+func (e Encryption) secret(mode EncryptionMode) (string, error) {
+	switch mode {
+	case EncryptionMachine:
+		return machineSecret(), nil
+	case EncryptionPassphrase:
+		if e.Passphrase == "" {
+			return "", fmt.Errorf("credentials are encrypted with a passphrase, set %s", PassphraseEnv)
+		}
+		return e.Passphrase, nil
+	default:
+		return "", fmt.Errorf("unknown encryption %q", mode)
+	}
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
+}
+
+func deriveKey(secret string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, secret, salt, kdfIterations, keyLen)
+}
+
+// encrypt seals the secret with AES-GCM and returns the base64 encoded nonce and ciphertext
+func encrypt(key []byte, secret string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decrypt(key []byte, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var ioPlatformUUID = regexp.MustCompile(`"IOPlatformUUID" = "([^"]+)"`)
+
+// machineSecret combines a stable machine identifier with the user name
+func machineSecret() string {
+	userName := ""
+	if u, err := user.Current(); err == nil {
+		userName = u.Username
+	}
+	return "devplan:" + machineID() + ":" + userName
+}
+
+func machineID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		output, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err == nil {
+			if m := ioPlatformUUID.FindSubmatch(output); m != nil {
+				return string(m[1])
+			}
+		}
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
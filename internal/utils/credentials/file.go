@@ -0,0 +1,230 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	fileVersion = 1
+	fileMode    = 0600
+)
+
+// credentialsFile is the on-disk format of FileStore
+type credentialsFile struct {
+	Version    int            `json:"version"`
+	Encryption EncryptionMode `json:"encryption,omitempty"`
+	// Salt is used to derive the encryption key
+	Salt []byte `json:"salt,omitempty"`
+	// Accounts holds secrets by account, base64 encoded nonce and ciphertext when encrypted
+	Accounts map[string]string `json:"accounts"`
+}
+
+// FileStore keeps secrets in a file readable only by the current user, optionally encrypted
+type FileStore struct {
+	path       string
+	encryption Encryption
+
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// NewFileStore creates a store in the given file. New secrets are written with the given encryption.
+func NewFileStore(path string, encryption Encryption) *FileStore {
+	if encryption.Mode == "" {
+		encryption.Mode = EncryptionNone
+	}
+	return &FileStore{path: path, encryption: encryption, keys: make(map[string][]byte)}
+}
+
+func (s *FileStore) Name() string {
+	return s.path
+}
+
+func (s *FileStore) Get(account string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := file.Accounts[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s.decode(file, value)
+}
+
+func (s *FileStore) Set(account, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	secrets[account] = secret
+	return s.write(secrets)
+}
+
+func (s *FileStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[account]; !ok {
+		return nil
+	}
+	delete(secrets, account)
+	if len(secrets) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return s.write(secrets)
+}
+
+func (s *FileStore) read() (*credentialsFile, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return &credentialsFile{Version: fileVersion, Accounts: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	file := &credentialsFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	if file.Version > fileVersion {
+		return nil, fmt.Errorf("%s was written by a newer version of the CLI", s.path)
+	}
+	if file.Accounts == nil {
+		file.Accounts = map[string]string{}
+	}
+	return file, nil
+}
+
+// readAll decrypts all stored secrets, so they can be written back with the current encryption
+func (s *FileStore) readAll() (map[string]string, error) {
+	file, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[string]string, len(file.Accounts))
+	for account, value := range file.Accounts {
+		secret, err := s.decode(file, value)
+		if err != nil {
+			return nil, err
+		}
+		secrets[account] = secret
+	}
+	return secrets, nil
+}
+
+func (s *FileStore) decode(file *credentialsFile, value string) (string, error) {
+	if file.Encryption == "" || file.Encryption == EncryptionNone {
+		return value, nil
+	}
+	key, err := s.key(file.Encryption, file.Salt)
+	if err != nil {
+		return "", err
+	}
+	secret, err := decrypt(key, value)
+	if err != nil {
+		if file.Encryption == EncryptionPassphrase {
+			return "", fmt.Errorf("failed to decrypt %s, check %s: %w", s.path, PassphraseEnv, err)
+		}
+		return "", fmt.Errorf("failed to decrypt %s, it may have been created on another machine: %w", s.path, err)
+	}
+	return secret, nil
+}
+
+func (s *FileStore) write(secrets map[string]string) error {
+	file := &credentialsFile{
+		Version:    fileVersion,
+		Encryption: s.encryption.Mode,
+		Accounts:   make(map[string]string, len(secrets)),
+	}
+	var key []byte
+	if file.Encryption != EncryptionNone {
+		salt, err := newSalt()
+		if err != nil {
+			return err
+		}
+		file.Salt = salt
+		if key, err = s.key(file.Encryption, salt); err != nil {
+			return err
+		}
+	}
+	for account, secret := range secrets {
+		if key == nil {
+			file.Accounts[account] = secret
+			continue
+		}
+		value, err := encrypt(key, secret)
+		if err != nil {
+			return err
+		}
+		file.Accounts[account] = value
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// key derives the encryption key, caching it since the derivation is deliberately slow
+func (s *FileStore) key(mode EncryptionMode, salt []byte) ([]byte, error) {
+	cacheKey := string(mode) + ":" + string(salt)
+	if key, ok := s.keys[cacheKey]; ok {
+		return key, nil
+	}
+	secret, err := s.encryption.secret(mode)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[cacheKey] = key
+	return key, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(dir, ".credentials-*")
+	if err != nil {
+		return err
+	}
+	tempName := tempFile.Name()
+	defer func() {
+		_ = os.Remove(tempName)
+	}()
+	// CreateTemp already uses 0600, keep it explicit since the file holds secrets
+	if err := tempFile.Chmod(fileMode); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
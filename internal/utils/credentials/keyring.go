@@ -0,0 +1,46 @@
+package credentials
+
+import "fmt"
+
+const keyringService = "devplan-cli"
+
+// Keyring is implemented by OS credential managers, e.g. macOS Keychain, Windows Credential Manager
+// or the Secret Service on Linux. Get must return ErrNotFound for missing secrets.
+type Keyring interface {
+	Name() string
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+var registeredKeyring Keyring
+
+// RegisterKeyring makes the keyring available as the "keyring" credential store
+func RegisterKeyring(k Keyring) {
+	registeredKeyring = k
+}
+
+// NewKeyringStore creates a store keeping secrets in the keyring
+func NewKeyringStore(k Keyring) Store {
+	return &keyringStore{keyring: k}
+}
+
+type keyringStore struct {
+	keyring Keyring
+}
+
+func (s *keyringStore) Name() string {
+	return fmt.Sprintf("%s (service %s)", s.keyring.Name(), keyringService)
+}
+
+func (s *keyringStore) Get(account string) (string, error) {
+	return s.keyring.Get(keyringService, account)
+}
+
+func (s *keyringStore) Set(account, secret string) error {
+	return s.keyring.Set(keyringService, account, secret)
+}
+
+func (s *keyringStore) Delete(account string) error {
+	return s.keyring.Delete(keyringService, account)
+}
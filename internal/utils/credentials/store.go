@@ -0,0 +1,142 @@
+// Package credentials stores the Devplan API key outside of the preferences file.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+)
+
+const (
+	// APIKeyEnv overrides the stored API key
+	APIKeyEnv = "DEVPLAN_API_KEY"
+	// legacyAPIKeyEnv is the name of APIKeyEnv in older versions, still accepted when APIKeyEnv is not set
+	legacyAPIKeyEnv = "DEVPLAN_APIKEY"
+
+	fileName = "credentials"
+
+	storeFile    = "file"
+	storeKeyring = "keyring"
+)
+
+// ErrNotFound is returned when no credential is stored for the account
+var ErrNotFound = errors.New("credential not found")
+
+// Store keeps secrets by account name. API keys are stored under the name of their profile.
+type Store interface {
+	// Name describes the backend for messages, e.g. "~/.devplan/credentials"
+	Name() string
+	// Get returns the stored secret or ErrNotFound
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	// Delete removes the secret. Deleting a missing secret is not an error.
+	Delete(account string) error
+}
+
+var warnLegacyEnv sync.Once
+
+// EnvAPIKey returns the API key set in the environment and the name of the variable holding it,
+// or an empty key if none is set
+func EnvAPIKey() (string, string) {
+	if key := strings.TrimSpace(os.Getenv(APIKeyEnv)); key != "" {
+		return key, APIKeyEnv
+	}
+	if key := strings.TrimSpace(os.Getenv(legacyAPIKeyEnv)); key != "" {
+		warnLegacyEnv.Do(func() {
+			slog.Warn("The environment variable is deprecated, use "+APIKeyEnv+" instead", "name", legacyAPIKeyEnv)
+		})
+		return key, legacyAPIKeyEnv
+	}
+	return "", ""
+}
+
+// Default returns the store selected by the credential_store preference.
+// The encrypted file store under ~/.devplan is used unless a keyring is registered and selected.
+func Default() (Store, error) {
+	switch backend := prefs.GetCredentialStore(); backend {
+	case "", storeFile:
+	case storeKeyring:
+		if registeredKeyring == nil {
+			return nil, fmt.Errorf("no OS keyring is available, set %s to %q", prefs.CredentialStoreKey, storeFile)
+		}
+		return NewKeyringStore(registeredKeyring), nil
+	default:
+		return nil, fmt.Errorf("unknown credential store %q", backend)
+	}
+	configDir, err := prefs.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := encryptionFromPrefs()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileStore(filepath.Join(configDir, fileName), encryption), nil
+}
+
+// GetAPIKey returns the API key stored for the profile or an empty key if the profile is not authenticated.
+// A key stored in config.json by older versions is moved to the store on first use of the default profile.
+func GetAPIKey(profile string) (string, error) {
+	store, err := Default()
+	if err != nil {
+		return "", err
+	}
+	if profile != prefs.DefaultProfile {
+		key, err := store.Get(profile)
+		if errors.Is(err, ErrNotFound) {
+			return "", nil
+		}
+		return key, err
+	}
+	return getWithMigration(store, profile, prefs.GetLegacyAPIKey, prefs.RemoveLegacyAPIKey)
+}
+
+// SetAPIKey saves the API key of the profile
+func SetAPIKey(profile, key string) error {
+	store, err := Default()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(profile, key); err != nil {
+		return fmt.Errorf("failed to store API key in %s: %w", store.Name(), err)
+	}
+	return nil
+}
+
+// DeleteAPIKey removes the API key of the profile
+func DeleteAPIKey(profile string) error {
+	store, err := Default()
+	if err != nil {
+		return err
+	}
+	return store.Delete(profile)
+}
+
+func getWithMigration(store Store, account string, legacyKey func() string, removeLegacy func() error) (string, error) {
+	key, err := store.Get(account)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("failed to read API key from %s: %w", store.Name(), err)
+	}
+	key = legacyKey()
+	if key == "" {
+		return "", nil
+	}
+	if err := store.Set(account, key); err != nil {
+		slog.Warn("Failed to migrate API key from config.json", "store", store.Name(), "err", err)
+		return key, nil
+	}
+	if err := removeLegacy(); err != nil {
+		slog.Warn("Failed to remove API key from config.json", "err", err)
+	}
+	slog.Info("Moved API key from config.json", "store", store.Name())
+	return key, nil
+}
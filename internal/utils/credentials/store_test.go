@@ -0,0 +1,192 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const account = "default"
+
+func TestFileStore_RoundTrip(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "credentials")
+	store := NewFileStore(path, Encryption{})
+
+	_, err := store.Get(account)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Set(account, "secret-key"))
+	key, err := store.Get(account)
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", key)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, store.Delete(account))
+	_, err = store.Get(account)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoFileExists(t, path)
+	assert.NoError(t, store.Delete(account))
+}
+
+func TestFileStore_Encryption(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		encryption Encryption
+	}{
+		{name: "machine", encryption: Encryption{Mode: EncryptionMachine}},
+		{name: "passphrase", encryption: Encryption{Mode: EncryptionPassphrase, Passphrase: "correct horse"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "credentials")
+			require.NoError(t, NewFileStore(path, tt.encryption).Set(account, "secret-key"))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "secret-key")
+
+			// A fresh store derives the key again from the same secret
+			key, err := NewFileStore(path, tt.encryption).Get(account)
+			require.NoError(t, err)
+			assert.Equal(t, "secret-key", key)
+		})
+	}
+}
+
+func TestFileStore_WrongPassphrase(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, NewFileStore(path, Encryption{Mode: EncryptionPassphrase, Passphrase: "one"}).Set(account, "k"))
+
+	_, err := NewFileStore(path, Encryption{Mode: EncryptionPassphrase, Passphrase: "two"}).Get(account)
+	assert.ErrorContains(t, err, PassphraseEnv)
+
+	_, err = NewFileStore(path, Encryption{}).Get(account)
+	assert.ErrorContains(t, err, PassphraseEnv)
+}
+
+func TestFileStore_ReencryptsOnWrite(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, NewFileStore(path, Encryption{}).Set("first", "one"))
+
+	store := NewFileStore(path, Encryption{Mode: EncryptionMachine})
+	require.NoError(t, store.Set("second", "two"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"one"`)
+	first, err := store.Get("first")
+	require.NoError(t, err)
+	assert.Equal(t, "one", first)
+}
+
+type memStore struct {
+	secrets map[string]string
+	setErr  error
+}
+
+func (m *memStore) Name() string { return "memory" }
+
+func (m *memStore) Get(account string) (string, error) {
+	if s, ok := m.secrets[account]; ok {
+		return s, nil
+	}
+	return "", ErrNotFound
+}
+
+func (m *memStore) Set(account, secret string) error {
+	if m.setErr != nil {
+		return m.setErr
+	}
+	m.secrets[account] = secret
+	return nil
+}
+
+func (m *memStore) Delete(account string) error {
+	delete(m.secrets, account)
+	return nil
+}
+
+func TestGetWithMigration(t *testing.T) {
+	t.Parallel()
+	legacy := "legacy-key"
+	removed := false
+	store := &memStore{secrets: map[string]string{}}
+	legacyKey := func() string { return legacy }
+	removeLegacy := func() error {
+		removed = true
+		legacy = ""
+		return nil
+	}
+
+	key, err := getWithMigration(store, account, legacyKey, removeLegacy)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-key", key)
+	assert.True(t, removed)
+	assert.Equal(t, "legacy-key", store.secrets[account])
+
+	key, err = getWithMigration(store, account, legacyKey, removeLegacy)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-key", key)
+}
+
+func TestGetWithMigration_KeepsLegacyKeyWhenStoreFails(t *testing.T) {
+	t.Parallel()
+	store := &memStore{secrets: map[string]string{}, setErr: errors.New("read-only")}
+	removed := false
+
+	key, err := getWithMigration(store, account, func() string { return "legacy-key" }, func() error {
+		removed = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-key", key)
+	assert.False(t, removed)
+}
+
+func TestGetWithMigration_NotAuthenticated(t *testing.T) {
+	t.Parallel()
+	key, err := getWithMigration(&memStore{secrets: map[string]string{}}, account, func() string { return "" }, func() error {
+		t.Fatal("nothing to remove")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, key)
+}
+
+func TestEnvAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantKey string
+		wantEnv string
+	}{
+		{name: "unset"},
+		{name: "canonical", env: map[string]string{"DEVPLAN_API_KEY": "key"}, wantKey: "key", wantEnv: "DEVPLAN_API_KEY"},
+		{name: "deprecated", env: map[string]string{"DEVPLAN_APIKEY": "old-key"}, wantKey: "old-key", wantEnv: "DEVPLAN_APIKEY"},
+		{name: "both", env: map[string]string{"DEVPLAN_API_KEY": "key", "DEVPLAN_APIKEY": "old-key"}, wantKey: "key", wantEnv: "DEVPLAN_API_KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(APIKeyEnv, "")
+			t.Setenv(legacyAPIKeyEnv, "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			key, env := EnvAPIKey()
+			assert.Equal(t, tt.wantKey, key)
+			assert.Equal(t, tt.wantEnv, env)
+		})
+	}
+}
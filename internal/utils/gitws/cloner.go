@@ -2,6 +2,7 @@ package gitws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -20,6 +21,7 @@ import (
 	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
 	"github.com/devplaninc/devplan-cli/internal/utils/workspace"
 	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/documents"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/integrations"
 )
 
 type InteractiveCloneResult struct {
@@ -29,13 +31,13 @@ type InteractiveCloneResult struct {
 }
 
 func InteractiveClone(ctx context.Context, targetPicker *picker.TargetCmd, repoName string, branchName string) (InteractiveCloneResult, error) {
-	target, err := picker.Target(targetPicker)
+	target, err := picker.Target(ctx, targetPicker)
 	if err != nil {
 		return InteractiveCloneResult{}, err
 	}
 	project := target.ProjectWithDocs
 
-	repo, err := confirmRepository(repoName, project.GetProject().GetCompanyId())
+	repo, err := confirmRepository(ctx, repoName, project.GetProject().GetCompanyId())
 	if err != nil {
 		return InteractiveCloneResult{}, err
 	}
@@ -161,7 +163,7 @@ func prepareRepository(
 	return worktreePath, repoInfo, nil
 }
 
-func confirmRepository(repoName string, companyID int32) (git.RepoInfo, error) {
+func confirmRepository(ctx context.Context, repoName string, companyID int32) (git.RepoInfo, error) {
 	repo, ok, err := checkIfURL(repoName)
 	if ok {
 		return repo, nil
@@ -169,8 +171,7 @@ func confirmRepository(repoName string, companyID int32) (git.RepoInfo, error) {
 	if err != nil {
 		return git.RepoInfo{}, err
 	}
-	cl := devplan.NewClient(devplan.Config{})
-	repos, err := cl.GetAllRepos(companyID)
+	repos, err := getAllRepos(ctx, companyID)
 	if err != nil {
 		return git.RepoInfo{}, fmt.Errorf("failed to get git repositories: %v", err)
 	}
@@ -391,9 +392,8 @@ func checkIfURL(repoName string) (git.RepoInfo, bool, error) {
 
 // ResolveRepos resolves a list of repository full names to git.RepoInfo objects
 // by matching against the company's available repositories.
-func ResolveRepos(repoNames []string, companyID int32) ([]git.RepoInfo, error) {
-	cl := devplan.NewClient(devplan.Config{})
-	allRepos, err := cl.GetAllRepos(companyID)
+func ResolveRepos(ctx context.Context, repoNames []string, companyID int32) ([]git.RepoInfo, error) {
+	allRepos, err := getAllRepos(ctx, companyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get git repositories: %w", err)
 	}
@@ -479,6 +479,7 @@ func CloneAllRepos(ctx context.Context, repos []git.RepoInfo, parentPath string,
 func generateMetadata(repo git.RepoInfo, target picker.DevTarget, includeTaskInfo bool) metadata.Metadata {
 	project := target.ProjectWithDocs.GetProject()
 	meta := metadata.Metadata{
+		CompanyID:        project.GetCompanyId(),
 		ProjectID:        project.GetId(),
 		ProjectName:      project.GetTitle(),
 		RepoURL:          repo.URLs[0],
@@ -513,3 +514,15 @@ func generateMetadata(repo git.RepoInfo, target picker.DevTarget, includeTaskInf
 
 	return meta
 }
+
+// getAllRepos returns the repositories of the company. If only some integrations failed,
+// the repositories of the others are used.
+func getAllRepos(ctx context.Context, companyID int32) ([]*integrations.GitRepository, error) {
+	repos, err := devplan.NewClient(devplan.Config{}).GetAllRepos(ctx, companyID)
+	var batchErr *devplan.BatchError
+	if errors.As(err, &batchErr) && len(repos) > 0 {
+		out.Pwarnf("Some git integrations could not be loaded: %v\n", err)
+		return repos, nil
+	}
+	return repos, err
+}
@@ -0,0 +1,105 @@
+// Package httplog records HTTP exchanges of the Devplan API client for troubleshooting.
+// Credentials are redacted before entries are written.
+package httplog
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxBodySize limits how much of each request and response body is recorded
+	MaxBodySize = 4 * 1024
+
+	redacted = "[REDACTED]"
+)
+
+// Entry is a single traced HTTP exchange
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	URL     string    `json:"url"`
+	Status  int       `json:"status,omitempty"`
+	Latency Duration  `json:"latency"`
+	// Error is set when no response was received
+	Error          string      `json:"error,omitempty"`
+	RequestHeader  http.Header `json:"requestHeader,omitempty"`
+	RequestBody    string      `json:"requestBody,omitempty"`
+	RequestSize    int         `json:"requestSize"`
+	ResponseHeader http.Header `json:"responseHeader,omitempty"`
+	ResponseBody   string      `json:"responseBody,omitempty"`
+	ResponseSize   int         `json:"responseSize"`
+}
+
+// Duration is a time.Duration encoded as a string, e.g. "120ms"
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// secretHeaders are never recorded
+var secretHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// apiKeyFieldRe matches API keys in JSON bodies, e.g. the response of the login flow
+var apiKeyFieldRe = regexp.MustCompile(`(?i)("api_?key"\s*:\s*)"[^"]*"`)
+
+// redactor removes the credentials of a single exchange
+type redactor struct {
+	secrets []string
+}
+
+func newRedactor(req *http.Request) *redactor {
+	r := &redactor{}
+	auth := req.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok && token != "" {
+		r.secrets = append(r.secrets, token)
+	}
+	return r
+}
+
+func (r *redactor) header(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	result := h.Clone()
+	for _, name := range secretHeaders {
+		if len(result.Values(name)) > 0 {
+			result.Set(name, redacted)
+		}
+	}
+	for name, values := range result {
+		for i, v := range values {
+			values[i] = r.text(v)
+		}
+		result[name] = values
+	}
+	return result
+}
+
+func (r *redactor) text(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// body returns the redacted body, cut to MaxBodySize
+func (r *redactor) body(data []byte) string {
+	s := apiKeyFieldRe.ReplaceAllString(r.text(string(data)), `${1}"`+redacted+`"`)
+	if len(s) > MaxBodySize {
+		return s[:MaxBodySize] + "...(truncated)"
+	}
+	return s
+}
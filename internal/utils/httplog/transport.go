@@ -0,0 +1,83 @@
+package httplog
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Sink receives traced exchanges
+type Sink interface {
+	Write(entry *Entry) error
+}
+
+// Transport records every exchange of the wrapped transport to the sink
+type Transport struct {
+	Base http.RoundTripper
+	Sink Sink
+}
+
+// NewTransport wraps base, or http.DefaultTransport if nil, to trace exchanges to sink
+func NewTransport(base http.RoundTripper, sink Sink) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Sink: sink}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	r := newRedactor(req)
+	entry := &Entry{
+		Time:          start,
+		Method:        req.Method,
+		URL:           r.text(req.URL.String()),
+		RequestHeader: r.header(req.Header),
+		RequestBody:   r.body(reqBody),
+		RequestSize:   len(reqBody),
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		entry.Latency = Duration(time.Since(start))
+		entry.Error = r.text(err.Error())
+		t.write(entry)
+		return nil, err
+	}
+	respBody, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	entry.Latency = Duration(time.Since(start))
+	entry.Status = resp.StatusCode
+	entry.ResponseHeader = r.header(resp.Header)
+	entry.ResponseBody = r.body(respBody)
+	entry.ResponseSize = len(respBody)
+	if readErr != nil {
+		entry.Error = r.text(readErr.Error())
+	}
+	t.write(entry)
+	if readErr != nil {
+		return nil, readErr
+	}
+	return resp, nil
+}
+
+func (t *Transport) write(entry *Entry) {
+	slog.Debug("HTTP exchange", "method", entry.Method, "url", entry.URL, "status", entry.Status,
+		"latency", time.Duration(entry.Latency), "size", entry.ResponseSize)
+	if err := t.Sink.Write(entry); err != nil {
+		slog.Warn("Failed to write HTTP trace", "err", err)
+	}
+}
@@ -0,0 +1,103 @@
+package httplog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memSink struct {
+	entries []*Entry
+}
+
+func (m *memSink) Write(entry *Entry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func TestTransport_RecordsRedactedExchange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"name":"spec"}`, string(body))
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"apiKey": "issued-key", "echo": "secret-key"}`))
+	}))
+	defer srv.Close()
+	sink := &memSink{}
+	client := &http.Client{Transport: NewTransport(nil, sink)}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/specs?key=secret-key", strings.NewReader(`{"name":"spec"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-key")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "issued-key", "the caller still gets the full response")
+
+	require.Len(t, sink.entries, 1)
+	e := sink.entries[0]
+	assert.Equal(t, http.MethodPost, e.Method)
+	assert.Equal(t, srv.URL+"/api/v1/specs?key=[REDACTED]", e.URL)
+	assert.Equal(t, http.StatusCreated, e.Status)
+	assert.Equal(t, "[REDACTED]", e.RequestHeader.Get("Authorization"))
+	assert.Equal(t, "[REDACTED]", e.ResponseHeader.Get("Set-Cookie"))
+	assert.Equal(t, `{"name":"spec"}`, e.RequestBody)
+	assert.Equal(t, `{"apiKey": "[REDACTED]", "echo": "[REDACTED]"}`, e.ResponseBody)
+	assert.Equal(t, len(body), e.ResponseSize)
+	assert.Positive(t, e.Latency)
+}
+
+func TestTransport_TruncatesBodies(t *testing.T) {
+	large := strings.Repeat("x", MaxBodySize*2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(large))
+	}))
+	defer srv.Close()
+	sink := &memSink{}
+	client := &http.Client{Transport: NewTransport(nil, sink)}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Len(t, body, len(large))
+
+	require.Len(t, sink.entries, 1)
+	assert.Equal(t, len(large), sink.entries[0].ResponseSize)
+	assert.Len(t, sink.entries[0].ResponseBody, MaxBodySize+len("...(truncated)"))
+}
+
+func TestTransport_RecordsConnectionErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	sink := &memSink{}
+	client := &http.Client{Transport: NewTransport(nil, sink)}
+
+	_, err := client.Get(srv.URL)
+	require.Error(t, err)
+	require.Len(t, sink.entries, 1)
+	assert.NotEmpty(t, sink.entries[0].Error)
+	assert.Zero(t, sink.entries[0].Status)
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(&Entry{Method: http.MethodGet, URL: "https://app.devplan.com/api/v1/user", Status: 200, Latency: Duration(1500000)}))
+	buf.WriteString("not an entry\n")
+	require.NoError(t, w.Write(&Entry{Method: http.MethodDelete, URL: "https://app.devplan.com/api/v1/apikey/current", Status: 204}))
+
+	entries, err := Read(&buf)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "https://app.devplan.com/api/v1/user", entries[0].URL)
+	assert.Equal(t, Duration(1500000), entries[0].Latency)
+	assert.Equal(t, http.MethodDelete, entries[1].Method)
+}
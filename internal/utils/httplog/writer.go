@@ -0,0 +1,93 @@
+package httplog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const fileName = "http.log"
+
+// Writer writes entries as JSON lines
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.w.Write(append(data, '\n'))
+	return err
+}
+
+var (
+	sinksMu sync.Mutex
+	sinks   = map[string]*Writer{}
+)
+
+// Open returns the writer for the trace destination: prefs.TraceToLog for the rotated
+// ~/.devplan/http.log, or a file path. Writers are shared by all clients of the process.
+func Open(dest string) (*Writer, error) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if w, ok := sinks[dest]; ok {
+		return w, nil
+	}
+	var out io.Writer
+	if dest == prefs.TraceToLog {
+		path, err := DefaultFile()
+		if err != nil {
+			return nil, err
+		}
+		out = &lumberjack.Logger{Filename: path, MaxSize: 10, MaxBackups: 1, MaxAge: 7}
+	} else {
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open HTTP trace file: %w", err)
+		}
+		out = f
+	}
+	w := NewWriter(out)
+	sinks[dest] = w
+	return w, nil
+}
+
+// DefaultFile returns the path of the trace written with `--trace`
+func DefaultFile() (string, error) {
+	configDir, err := prefs.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, fileName), nil
+}
+
+// Read parses the entries of a trace file. Lines that are not entries are skipped.
+func Read(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil || entry.Method == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,236 @@
+package ide
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// MCPServerName is the name of the devplan entry in MCP configs
+const MCPServerName = "devplan"
+
+// MCPScope selects whether the MCP server is registered for a single project or for all projects of the user
+type MCPScope string
+
+const (
+	MCPScopeProject MCPScope = "project"
+	MCPScopeUser    MCPScope = "user"
+)
+
+// MCPServer is a stdio server entry of an MCP config
+type MCPServer struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// mcpServersKey holds the servers in the MCP configs of all supported assistants
+const mcpServersKey = "mcpServers"
+
+// GetMCPConfigPath returns the MCP config file of the assistant. Project configs are relative to root,
+// user configs to the home directory.
+func GetMCPConfigPath(asst Assistant, scope MCPScope, root string) (string, error) {
+	base := root
+	if scope == MCPScopeUser {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = home
+	}
+	switch {
+	case asst == ClaudeAI && scope == MCPScopeProject:
+		return filepath.Join(base, ".mcp.json"), nil
+	case asst == ClaudeAI && scope == MCPScopeUser:
+		return filepath.Join(base, ".claude.json"), nil
+	case asst == CursorAI:
+		return filepath.Join(base, ".cursor", "mcp.json"), nil
+	case asst == JunieAI:
+		return filepath.Join(base, ".junie", "mcp", "mcp.json"), nil
+	case asst == WindsurfAI && scope == MCPScopeUser:
+		return filepath.Join(base, ".codeium", "windsurf", "mcp_config.json"), nil
+	case asst == WindsurfAI:
+		return "", fmt.Errorf("windsurf only supports MCP servers in the user scope, use --scope user")
+	default:
+		return "", fmt.Errorf("unknown assistant or scope: %v, %v", asst, scope)
+	}
+}
+
+// DetectMCPAssistants returns the assistants that are set up in the scope, based on their config directories
+func DetectMCPAssistants(scope MCPScope, root string) ([]Assistant, error) {
+	if scope == MCPScopeProject {
+		var result []Assistant
+		for _, asst := range GetAssistants() {
+			if asst == WindsurfAI {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(root, pathMap[asst])); err == nil {
+				result = append(result, asst)
+			}
+		}
+		return result, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	userDirs := map[Assistant]string{
+		ClaudeAI:   ".claude",
+		CursorAI:   ".cursor",
+		JunieAI:    ".junie",
+		WindsurfAI: filepath.Join(".codeium", "windsurf"),
+	}
+	var result []Assistant
+	for _, asst := range GetAssistants() {
+		if _, err := os.Stat(filepath.Join(home, userDirs[asst])); err == nil {
+			result = append(result, asst)
+		}
+	}
+	return result, nil
+}
+
+// GetMCPServer returns the devplan entry of the MCP config, or nil if the config has none
+func GetMCPServer(configPath string) (*MCPServer, error) {
+	config, servers, err := readMCPConfig(configPath)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	raw, ok := servers[MCPServerName]
+	if !ok {
+		return nil, nil
+	}
+	server := &MCPServer{}
+	if err := json.Unmarshal(raw, server); err != nil {
+		return nil, fmt.Errorf("invalid %s server in %s: %w", MCPServerName, configPath, err)
+	}
+	return server, nil
+}
+
+// InstallMCPServer adds the devplan entry to the MCP config, keeping other servers and settings.
+// The original file is backed up before it is changed. Returns false if the entry was up to date.
+func InstallMCPServer(configPath string, server MCPServer) (bool, error) {
+	current, err := GetMCPServer(configPath)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && reflect.DeepEqual(*current, server) {
+		return false, nil
+	}
+	config, servers, err := readMCPConfig(configPath)
+	if err != nil {
+		return false, err
+	}
+	if config == nil {
+		config = make(map[string]json.RawMessage)
+	}
+	if servers == nil {
+		servers = make(map[string]json.RawMessage)
+	}
+	entry, err := json.Marshal(server)
+	if err != nil {
+		return false, err
+	}
+	servers[MCPServerName] = entry
+	return true, writeMCPConfig(configPath, config, servers)
+}
+
+// UninstallMCPServer removes the devplan entry from the MCP config, keeping other servers and settings.
+// The original file is backed up before it is changed. Returns false if there was no entry.
+func UninstallMCPServer(configPath string) (bool, error) {
+	config, servers, err := readMCPConfig(configPath)
+	if err != nil || config == nil {
+		return false, err
+	}
+	if _, ok := servers[MCPServerName]; !ok {
+		return false, nil
+	}
+	delete(servers, MCPServerName)
+	return true, writeMCPConfig(configPath, config, servers)
+}
+
+// readMCPConfig parses the MCP config, returning nil maps if the file does not exist.
+// Values are kept raw, so settings unknown to devplan are written back unchanged.
+func readMCPConfig(configPath string) (map[string]json.RawMessage, map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read MCP config: %w", err)
+	}
+	config := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse MCP config %s, fix or remove it first: %w", configPath, err)
+		}
+	}
+	servers := make(map[string]json.RawMessage)
+	if raw, ok := config[mcpServersKey]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s of MCP config %s: %w", mcpServersKey, configPath, err)
+		}
+	}
+	return config, servers, nil
+}
+
+func writeMCPConfig(configPath string, config, servers map[string]json.RawMessage) error {
+	serversData, err := json.Marshal(servers)
+	if err != nil {
+		return err
+	}
+	config[mcpServersKey] = serversData
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create MCP config directory: %w", err)
+	}
+	if err := createBackup(configPath); err != nil {
+		return fmt.Errorf("failed to back up MCP config: %w", err)
+	}
+	if err := writeFileAtomic(configPath, append(data, '\n'), mode); err != nil {
+		return fmt.Errorf("failed to write MCP config: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file with a renamed temporary file, so agents rewriting the same config
+// never read a partial file and a crash leaves the previous one
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	// A config linked from e.g. a dotfiles repository stays a link
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tempName := tempFile.Name()
+	defer func() {
+		_ = os.Remove(tempName)
+	}()
+	if err := tempFile.Chmod(mode); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempName, path)
+}
@@ -0,0 +1,155 @@
+package ide
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMCPServer = MCPServer{Command: "devplan", Args: []string{"mcp"}}
+
+func readJSON(t *testing.T, path string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestInstallMCPServer_CreatesConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".cursor", "mcp.json")
+
+	changed, err := InstallMCPServer(configPath, testMCPServer)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	server, err := GetMCPServer(configPath)
+	require.NoError(t, err)
+	require.NotNil(t, server)
+	assert.Equal(t, testMCPServer, *server)
+	assert.NoFileExists(t, configPath+".bak")
+}
+
+func TestInstallMCPServer_MergesIdempotently(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".mcp.json")
+	original := `{"theme": "dark", "mcpServers": {"other": {"command": "other-server", "args": ["--port", "1"]}}}`
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+	changed, err := InstallMCPServer(configPath, testMCPServer)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	backup, err := os.ReadFile(configPath + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup))
+
+	config := readJSON(t, configPath)
+	assert.Equal(t, "dark", config["theme"])
+	servers := config["mcpServers"].(map[string]any)
+	assert.Equal(t, map[string]any{"command": "other-server", "args": []any{"--port", "1"}}, servers["other"])
+	assert.Equal(t, map[string]any{"command": "devplan", "args": []any{"mcp"}}, servers["devplan"])
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the file mode is kept")
+	info, err = os.Stat(configPath + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the backup may hold tokens of other servers")
+	entries, err := os.ReadDir(filepath.Dir(configPath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left")
+
+	require.NoError(t, os.Remove(configPath+".bak"))
+	changed, err = InstallMCPServer(configPath, testMCPServer)
+	require.NoError(t, err)
+	assert.False(t, changed, "an up to date entry is not rewritten")
+	assert.NoFileExists(t, configPath+".bak")
+
+	updated := MCPServer{Command: "devplan", Args: []string{"mcp"}, Env: map[string]string{"DEVPLAN_PROFILE": "beta"}}
+	changed, err = InstallMCPServer(configPath, updated)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	server, err := GetMCPServer(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, updated, *server)
+}
+
+func TestInstallMCPServer_InvalidConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "mcp.json")
+	require.NoError(t, os.WriteFile(configPath, []byte("{not json"), 0644))
+
+	_, err := InstallMCPServer(configPath, testMCPServer)
+	assert.ErrorContains(t, err, "failed to parse MCP config")
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "{not json", string(data), "an invalid config is not overwritten")
+}
+
+func TestUninstallMCPServer(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "mcp.json")
+
+	removed, err := UninstallMCPServer(configPath)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.NoFileExists(t, configPath)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"mcpServers": {"other": {"command": "x"}}}`), 0644))
+	_, err = InstallMCPServer(configPath, testMCPServer)
+	require.NoError(t, err)
+
+	removed, err = UninstallMCPServer(configPath)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	servers := readJSON(t, configPath)["mcpServers"].(map[string]any)
+	assert.Contains(t, servers, "other")
+	assert.NotContains(t, servers, "devplan")
+
+	removed, err = UninstallMCPServer(configPath)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestGetMCPConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := filepath.Join("work", "repo")
+
+	cases := []struct {
+		asst  Assistant
+		scope MCPScope
+		want  string
+	}{
+		{ClaudeAI, MCPScopeProject, filepath.Join(root, ".mcp.json")},
+		{ClaudeAI, MCPScopeUser, filepath.Join(home, ".claude.json")},
+		{CursorAI, MCPScopeProject, filepath.Join(root, ".cursor", "mcp.json")},
+		{CursorAI, MCPScopeUser, filepath.Join(home, ".cursor", "mcp.json")},
+		{JunieAI, MCPScopeProject, filepath.Join(root, ".junie", "mcp", "mcp.json")},
+		{WindsurfAI, MCPScopeUser, filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")},
+	}
+	for _, tc := range cases {
+		got, err := GetMCPConfigPath(tc.asst, tc.scope, root)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+	_, err := GetMCPConfigPath(WindsurfAI, MCPScopeProject, root)
+	assert.Error(t, err)
+}
+
+func TestDetectMCPAssistants(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".cursor"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".codeium", "windsurf"), 0755))
+
+	assistants, err := DetectMCPAssistants(MCPScopeProject, root)
+	require.NoError(t, err)
+	assert.Equal(t, []Assistant{ClaudeAI, CursorAI}, assistants)
+
+	assistants, err = DetectMCPAssistants(MCPScopeUser, root)
+	require.NoError(t, err)
+	assert.Equal(t, []Assistant{WindsurfAI}, assistants)
+}
@@ -188,8 +188,11 @@ func WriteRules(rules []Rule, path string, extension string) error {
 	return nil
 }
 
+// createBackup copies the file next to it with the .bak suffix. The backup gets the mode of the file,
+// since config files, e.g. of MCP servers, may hold tokens.
 func createBackup(filePath string) error {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
 		return nil
 	} else if err != nil {
 		return fmt.Errorf("failed to check file existence: %w", err)
@@ -205,14 +208,17 @@ func createBackup(filePath string) error {
 		_ = srcFile.Close()
 	}(srcFile)
 
-	// Create the backup file
-	dstFile, err := os.Create(backupFilePath)
+	// Create the backup file, an existing backup may have a wider mode
+	dstFile, err := os.OpenFile(backupFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
 	if err != nil {
 		return fmt.Errorf("failed to create backup file: %w", err)
 	}
 	defer func(dstFile *os.File) {
 		_ = dstFile.Close()
 	}(dstFile)
+	if err := dstFile.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set backup file mode: %w", err)
+	}
 
 	_, err = io.Copy(dstFile, srcFile)
 	if err != nil {
@@ -15,14 +15,14 @@ type summariesResult struct {
 	err  error
 }
 
-func RepoSummary(target picker.DevTarget, repo git.RepoInfo) (*integrations.RepositorySummary, error) {
-	ctx, cancel := context.WithCancel(context.Background())
+func RepoSummary(ctx context.Context, target picker.DevTarget, repo git.RepoInfo) (*integrations.RepositorySummary, error) {
+	ctx, cancel := context.WithCancel(ctx)
 
 	cl := devplan.NewClient(devplan.Config{})
 	sumRespChan := make(chan summariesResult, 1)
 	go func() {
 		defer cancel()
-		sumResp, err := cl.GetRepoSummaries(target.ProjectWithDocs.GetProject().GetCompanyId())
+		sumResp, err := cl.GetRepoSummaries(ctx, target.ProjectWithDocs.GetProject().GetCompanyId())
 		if err != nil {
 			sumRespChan <- summariesResult{err: err}
 			return
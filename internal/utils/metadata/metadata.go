@@ -16,6 +16,7 @@ const (
 
 // Metadata contains information stored in .devplan_meta/meta.json
 type Metadata struct {
+	CompanyID   int32  `json:"companyId,omitempty"`
 	TaskID      string `json:"taskId,omitempty"`
 	StoryID     string `json:"storyId,omitempty"`
 	TaskName    string `json:"taskName,omitempty"`
@@ -87,6 +88,26 @@ func ReadMetadata(repoPath string) (*Metadata, error) {
 	return &meta, nil
 }
 
+// FindMetadata reads the metadata of the workspace containing dir, looking in dir and its parents.
+// Returns nil metadata if dir is not inside a Devplan workspace, together with the directory holding .devplan_meta.
+func FindMetadata(dir string) (*Metadata, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		meta, err := ReadMetadata(dir)
+		if err != nil || meta != nil {
+			return meta, dir, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
+
 // EnsureGitignore ensures .devplan_meta/.gitignore exists and contains the correct content
 func EnsureGitignore(repoPath string) error {
 	if err := EnsureDevplanDir(repoPath); err != nil {
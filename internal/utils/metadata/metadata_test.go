@@ -97,6 +97,7 @@ func TestWriteAndReadMetadata(t *testing.T) {
 	t.Run("write and read full metadata", func(t *testing.T) {
 		repoPath := filepath.Join(tempDir, "repo1")
 		meta := Metadata{
+			CompanyID:        42,
 			TaskID:           "task-123",
 			TaskName:         "Add feature",
 			StoryID:          "story-789",
@@ -123,6 +124,7 @@ func TestWriteAndReadMetadata(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, readMeta)
 
+		assert.Equal(t, meta.CompanyID, readMeta.CompanyID)
 		assert.Equal(t, meta.TaskID, readMeta.TaskID)
 		assert.Equal(t, meta.TaskName, readMeta.TaskName)
 		assert.Equal(t, meta.StoryID, readMeta.StoryID)
@@ -334,3 +336,22 @@ func TestMetadataJSONFormat(t *testing.T) {
 		assert.Contains(t, string(actualContent), "{\n  \"projectId\":")
 	})
 }
+
+func TestFindMetadata(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, WriteMetadata(root, Metadata{StoryID: "feature-1"}))
+	nested := filepath.Join(root, "repo", "src")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	meta, dir, err := FindMetadata(nested)
+	require.NoError(t, err)
+	require.NotNil(t, meta)
+	assert.Equal(t, "feature-1", meta.StoryID)
+	assert.Equal(t, root, dir)
+
+	require.NoError(t, WriteMetadata(filepath.Join(root, "repo"), Metadata{TaskID: "task-1"}))
+	meta, dir, err = FindMetadata(nested)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", meta.TaskID)
+	assert.Equal(t, filepath.Join(root, "repo"), dir)
+}
@@ -0,0 +1,388 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	outboxDirName = "outbox"
+	itemSuffix    = ".json"
+	lockFileName  = "flush.lock"
+	dirMode       = 0700
+	fileMode      = 0600
+
+	baseDelay = 30 * time.Second
+	maxDelay  = time.Hour
+	// lockTimeout after which the lock of a crashed flush is taken over
+	lockTimeout = 5 * time.Minute
+)
+
+// ErrFlushInProgress is returned when another process is flushing the outbox
+var ErrFlushInProgress = errors.New("the worklog queue is being flushed by another devplan process")
+
+// Item is a worklog item waiting to be submitted
+type Item struct {
+	// ID is sent as the idempotency key, so an item submitted twice is recorded once
+	ID        string    `json:"id"`
+	CompanyID int32     `json:"companyId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+	// NextAttempt is when the item is due after a failed attempt
+	NextAttempt time.Time `json:"nextAttempt,omitempty"`
+	// Rejected items were refused by the server and are only retried by a forced flush
+	Rejected bool            `json:"rejected,omitempty"`
+	Item     json.RawMessage `json:"item"`
+
+	file string
+}
+
+// WorkLogItem returns the queued worklog item
+func (i *Item) WorkLogItem() (*worklog.WorkLogItem, error) {
+	item := &worklog.WorkLogItem{}
+	u := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err := u.Unmarshal(i.Item, item); err != nil {
+		return nil, fmt.Errorf("failed to parse queued worklog item %s: %w", i.ID, err)
+	}
+	return item, nil
+}
+
+// Submitter sends worklog items, e.g. *devplan.Client
+type Submitter interface {
+	SubmitWorklogItemWithKey(ctx context.Context, companyID int32, item *worklog.WorkLogItem, key string) (*company.SubmitWorkLogResponse, error)
+}
+
+// Store keeps worklog items that could not be submitted on disk, one file per item, in submission order
+type Store struct {
+	dir string
+	now func() time.Time
+}
+
+// New creates a store rooted in the given directory
+func New(dir string) *Store {
+	return &Store{dir: dir, now: time.Now}
+}
+
+// Default returns the store of the active profile under ~/.devplan/outbox.
+// Profiles get separate stores since items are submitted with the profile's API key.
+func Default() (*Store, error) {
+	configDir, err := prefs.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	profile := prefs.ActiveProfile()
+	if profile == prefs.DefaultProfile {
+		return New(filepath.Join(configDir, outboxDirName)), nil
+	}
+	return New(filepath.Join(configDir, outboxDirName+"-"+profile)), nil
+}
+
+// Dir returns the root directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add queues the item after all items queued before
+func (s *Store) Add(companyID int32, item *worklog.WorkLogItem) (*Item, error) {
+	payload, err := protojson.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal worklog item: %w", err)
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	queued := &Item{
+		ID:        id,
+		CompanyID: companyID,
+		CreatedAt: now.UTC(),
+		Item:      payload,
+		// The zero padded timestamp keeps files sorted in submission order
+		file: fmt.Sprintf("%020d-%s%s", now.UnixNano(), id, itemSuffix),
+	}
+	if err := os.MkdirAll(s.dir, dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create worklog queue directory: %w", err)
+	}
+	return queued, s.save(queued)
+}
+
+// List returns the queued items, oldest first. Unreadable files are skipped.
+func (s *Store) List() ([]*Item, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var items []*Item
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), itemSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		item := &Item{}
+		if err := json.Unmarshal(data, item); err != nil || item.ID == "" {
+			continue
+		}
+		item.file = entry.Name()
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].file < items[j].file })
+	return items, nil
+}
+
+// Drop removes the items with the given IDs or unique ID prefixes, or all items if none are given.
+// Returns the number of removed items.
+func (s *Store) Drop(ids ...string) (int, error) {
+	items, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	toRemove := items
+	if len(ids) > 0 {
+		toRemove = nil
+		for _, id := range ids {
+			item, err := findItem(items, id)
+			if err != nil {
+				return 0, err
+			}
+			toRemove = append(toRemove, item)
+		}
+	}
+	removed := 0
+	for _, item := range toRemove {
+		if err := s.remove(item); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func findItem(items []*Item, idPrefix string) (*Item, error) {
+	var found *Item
+	for _, item := range items {
+		if !strings.HasPrefix(item.ID, idPrefix) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("ID %s matches several queued worklog items", idPrefix)
+		}
+		found = item
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no queued worklog item with ID %s", idPrefix)
+	}
+	return found, nil
+}
+
+// FlushOptions controls which items are submitted by Flush
+type FlushOptions struct {
+	// IgnoreBackoff submits items that are not due yet, e.g. when a new item shows the network is back
+	IgnoreBackoff bool
+	// IncludeRejected also resubmits items refused by the server before
+	IncludeRejected bool
+}
+
+// FlushResult summarizes a flush
+type FlushResult struct {
+	// SentIDs holds the IDs of the submitted items
+	SentIDs []string
+	// Pending is the number of items left for a later flush
+	Pending int
+	// Rejected is the number of items refused by the server, which stay queued until dropped
+	Rejected int
+	// Rejections holds the errors of items refused during this flush by item ID
+	Rejections map[string]error
+	// Err is the error that stopped the flush. Items after the failed one stay queued to keep the order.
+	Err error
+}
+
+// Flush submits queued items in order. It stops at the first transient failure and schedules the
+// failed item with exponential backoff. Items refused by the server are marked as rejected and skipped.
+func (s *Store) Flush(ctx context.Context, submitter Submitter, opts FlushOptions) (FlushResult, error) {
+	result := FlushResult{}
+	items, err := s.List()
+	if err != nil || len(items) == 0 {
+		return result, err
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+	// Another process may have flushed the queue before the lock was taken
+	if items, err = s.List(); err != nil {
+		return result, err
+	}
+
+	now := s.now()
+	sent := make(map[string]bool)
+	for _, item := range items {
+		if item.Rejected && !opts.IncludeRejected {
+			continue
+		}
+		if !opts.IgnoreBackoff && now.Before(item.NextAttempt) {
+			// Later items wait for this one to keep the order
+			break
+		}
+		if err := s.submit(ctx, submitter, item); err != nil {
+			item.Attempts++
+			item.LastError = err.Error()
+			item.Rejected = IsRejected(err)
+			if item.Rejected {
+				if result.Rejections == nil {
+					result.Rejections = make(map[string]error)
+				}
+				result.Rejections[item.ID] = err
+			} else {
+				item.NextAttempt = now.Add(backoff(item.Attempts)).UTC()
+				result.Err = err
+			}
+			if err := s.save(item); err != nil {
+				return result, err
+			}
+			if result.Err != nil {
+				break
+			}
+			continue
+		}
+		if err := s.remove(item); err != nil {
+			return result, err
+		}
+		sent[item.ID] = true
+		result.SentIDs = append(result.SentIDs, item.ID)
+	}
+	for _, item := range items {
+		switch {
+		case sent[item.ID]:
+		case item.Rejected:
+			result.Rejected++
+		default:
+			result.Pending++
+		}
+	}
+	return result, nil
+}
+
+func (s *Store) submit(ctx context.Context, submitter Submitter, item *Item) error {
+	wl, err := item.WorkLogItem()
+	if err != nil {
+		return &devplan.APIError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+	_, err = submitter.SubmitWorklogItemWithKey(ctx, item.CompanyID, wl, item.ID)
+	return err
+}
+
+// IsRejected reports whether the server refused the item, so submitting it again would fail the same way.
+// Authentication failures are not rejections: the item can be sent after logging in again.
+func IsRejected(err error) bool {
+	code := devplan.StatusCode(err)
+	if code < 400 || code >= 500 {
+		return false
+	}
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return true
+}
+
+func backoff(attempts int) time.Duration {
+	delay := baseDelay << (attempts - 1)
+	if delay <= 0 || delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+func (s *Store) save(item *Item) error {
+	payload, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(s.dir, "item-*")
+	if err != nil {
+		return err
+	}
+	tempName := tempFile.Name()
+	defer func() {
+		_ = os.Remove(tempName)
+	}()
+	if err := tempFile.Chmod(fileMode); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if _, err := tempFile.Write(payload); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempName, filepath.Join(s.dir, item.file))
+}
+
+func (s *Store) remove(item *Item) error {
+	err := os.Remove(filepath.Join(s.dir, item.file))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// lock makes sure a single process flushes the queue. Items carry idempotency keys, so the lock only
+// avoids duplicate requests, and the lock of a crashed process is taken over after lockTimeout.
+func (s *Store) lock() (func(), error) {
+	path := filepath.Join(s.dir, lockFileName)
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fileMode)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		info, statErr := os.Stat(path)
+		if statErr != nil || s.now().Sub(info.ModTime()) < lockTimeout {
+			break
+		}
+		_ = os.Remove(path)
+	}
+	return nil, ErrFlushInProgress
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate worklog item ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,214 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/devplan"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/services/web/company"
+	"github.com/devplaninc/webapp/golang/pb/api/devplan/types/worklog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubmitter fails submissions of messages listed in errs
+type fakeSubmitter struct {
+	errs     map[string]error
+	messages []string
+	keys     []string
+}
+
+func (f *fakeSubmitter) SubmitWorklogItemWithKey(_ context.Context, _ int32, item *worklog.WorkLogItem, key string) (*company.SubmitWorkLogResponse, error) {
+	if err := f.errs[item.GetMessage()]; err != nil {
+		return nil, err
+	}
+	f.messages = append(f.messages, item.GetMessage())
+	f.keys = append(f.keys, key)
+	return &company.SubmitWorkLogResponse{}, nil
+}
+
+func newTestStore(t *testing.T) (*Store, *time.Time) {
+	t.Helper()
+	store := New(filepath.Join(t.TempDir(), "outbox"))
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	return store, &now
+}
+
+func addItems(t *testing.T, store *Store, messages ...string) []*Item {
+	t.Helper()
+	var items []*Item
+	for _, msg := range messages {
+		item, err := store.Add(1, worklog.WorkLogItem_builder{Message: msg}.Build())
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestStore_AddAndList(t *testing.T) {
+	store, _ := newTestStore(t)
+	added := addItems(t, store, "first", "second", "third")
+
+	items, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	for i, item := range items {
+		assert.Equal(t, added[i].ID, item.ID)
+		assert.Equal(t, int32(1), item.CompanyID)
+	}
+	wl, err := items[1].WorkLogItem()
+	require.NoError(t, err)
+	assert.Equal(t, "second", wl.GetMessage())
+
+	info, err := os.Stat(store.Dir())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(dirMode), info.Mode().Perm())
+}
+
+func TestStore_ListMissingDir(t *testing.T) {
+	store, _ := newTestStore(t)
+	items, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestStore_FlushSendsInOrderWithKeys(t *testing.T) {
+	store, _ := newTestStore(t)
+	added := addItems(t, store, "first", "second")
+	submitter := &fakeSubmitter{}
+
+	result, err := store.Flush(context.Background(), submitter, FlushOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{added[0].ID, added[1].ID}, result.SentIDs)
+	assert.Zero(t, result.Pending)
+	assert.Equal(t, []string{"first", "second"}, submitter.messages)
+	assert.Equal(t, []string{added[0].ID, added[1].ID}, submitter.keys)
+
+	items, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestStore_FlushStopsAtTransientFailure(t *testing.T) {
+	store, now := newTestStore(t)
+	addItems(t, store, "first", "second", "third")
+	submitter := &fakeSubmitter{errs: map[string]error{"second": errors.New("connection reset")}}
+
+	result, err := store.Flush(context.Background(), submitter, FlushOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.SentIDs, 1)
+	assert.Equal(t, 2, result.Pending)
+	assert.ErrorContains(t, result.Err, "connection reset")
+	assert.Equal(t, []string{"first"}, submitter.messages, "items after the failed one wait to keep the order")
+
+	items, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, 1, items[0].Attempts)
+	assert.Equal(t, "connection reset", items[0].LastError)
+	assert.Equal(t, now.Add(baseDelay).Truncate(time.Second), items[0].NextAttempt.Truncate(time.Second))
+
+	// The failed item is not due yet
+	delete(submitter.errs, "second")
+	result, err = store.Flush(context.Background(), submitter, FlushOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.SentIDs)
+	assert.Equal(t, 2, result.Pending)
+
+	result, err = store.Flush(context.Background(), submitter, FlushOptions{IgnoreBackoff: true})
+	require.NoError(t, err)
+	assert.Len(t, result.SentIDs, 2)
+	assert.Equal(t, []string{"first", "second", "third"}, submitter.messages)
+}
+
+func TestStore_FlushSkipsRejected(t *testing.T) {
+	store, _ := newTestStore(t)
+	addItems(t, store, "invalid", "valid")
+	rejection := &devplan.APIError{Method: http.MethodPost, StatusCode: http.StatusBadRequest, Message: "bad item"}
+	submitter := &fakeSubmitter{errs: map[string]error{"invalid": rejection}}
+
+	result, err := store.Flush(context.Background(), submitter, FlushOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.SentIDs, 1)
+	assert.Equal(t, 1, result.Rejected)
+	assert.Len(t, result.Rejections, 1)
+	assert.NoError(t, result.Err)
+
+	items, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.True(t, items[0].Rejected)
+
+	result, err = store.Flush(context.Background(), submitter, FlushOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.SentIDs)
+	assert.Equal(t, 1, result.Rejected)
+
+	delete(submitter.errs, "invalid")
+	result, err = store.Flush(context.Background(), submitter, FlushOptions{IncludeRejected: true})
+	require.NoError(t, err)
+	assert.Len(t, result.SentIDs, 1)
+}
+
+func TestStore_FlushLock(t *testing.T) {
+	store, now := newTestStore(t)
+	addItems(t, store, "first")
+	lockPath := filepath.Join(store.Dir(), lockFileName)
+	require.NoError(t, os.WriteFile(lockPath, nil, fileMode))
+	require.NoError(t, os.Chtimes(lockPath, *now, *now))
+
+	_, err := store.Flush(context.Background(), &fakeSubmitter{}, FlushOptions{})
+	assert.ErrorIs(t, err, ErrFlushInProgress)
+
+	*now = now.Add(lockTimeout)
+	result, err := store.Flush(context.Background(), &fakeSubmitter{}, FlushOptions{})
+	require.NoError(t, err, "a stale lock is taken over")
+	assert.Len(t, result.SentIDs, 1)
+	assert.NoFileExists(t, lockPath)
+}
+
+func TestStore_Drop(t *testing.T) {
+	store, _ := newTestStore(t)
+	added := addItems(t, store, "first", "second", "third")
+
+	removed, err := store.Drop(added[1].ID[:8])
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	_, err = store.Drop("missing")
+	assert.Error(t, err)
+
+	items, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, added[0].ID, items[0].ID)
+	assert.Equal(t, added[2].ID, items[1].ID)
+
+	removed, err = store.Drop()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+}
+
+func TestIsRejected(t *testing.T) {
+	assert.True(t, IsRejected(&devplan.APIError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, IsRejected(&devplan.APIError{StatusCode: http.StatusNotFound}))
+	assert.False(t, IsRejected(&devplan.APIError{StatusCode: http.StatusUnauthorized}))
+	assert.False(t, IsRejected(&devplan.APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, IsRejected(&devplan.APIError{StatusCode: http.StatusBadGateway}))
+	assert.False(t, IsRejected(errors.New("connection refused")))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, baseDelay, backoff(1))
+	assert.Equal(t, 4*baseDelay, backoff(3))
+	assert.Equal(t, maxDelay, backoff(20))
+	assert.Equal(t, maxDelay, backoff(200))
+}
@@ -1,6 +1,7 @@
 package picker
 
 import (
+	"context"
 	"fmt"
 	"slices"
 	"strings"
@@ -89,9 +90,9 @@ func mainGroupID(companyID int32) string {
 	return fmt.Sprintf("%v-projects", companyID)
 }
 
-func Target(cmd *TargetCmd) (DevTarget, error) {
+func Target(ctx context.Context, cmd *TargetCmd) (DevTarget, error) {
 	cl := devplan.NewClient(devplan.Config{})
-	self, err := cl.GetSelf()
+	self, err := cl.GetSelf(ctx)
 	if err != nil {
 		return DevTarget{}, err
 	}
@@ -105,11 +106,11 @@ func Target(cmd *TargetCmd) (DevTarget, error) {
 	if err != nil {
 		return DevTarget{}, err
 	}
-	project, err := selectProject(cl, company.GetId(), projectID)
+	project, err := selectProject(ctx, cl, company.GetId(), projectID)
 	if err != nil {
 		return DevTarget{}, err
 	}
-	templatesRep, err := cl.GetProjectTemplates(company.GetId())
+	templatesRep, err := cl.GetProjectTemplates(ctx, company.GetId())
 	if err != nil {
 		return DevTarget{}, err
 	}
@@ -154,8 +155,8 @@ func Target(cmd *TargetCmd) (DevTarget, error) {
 	return result, nil
 }
 
-func selectProject(cl *devplan.Client, companyID int32, projectID string) (*documents.ProjectWithDocs, error) {
-	prResp, err := cl.GetCompanyProjects(companyID)
+func selectProject(ctx context.Context, cl *devplan.Client, companyID int32, projectID string) (*documents.ProjectWithDocs, error) {
+	prResp, err := cl.GetCompanyProjects(ctx, companyID)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +169,7 @@ func selectProject(cl *devplan.Client, companyID int32, projectID string) (*docu
 		}
 		return nil, fmt.Errorf("project with id %v not found", projectID)
 	}
-	grResp, err := cl.GetGroup(companyID, mainGroupID(companyID))
+	grResp, err := cl.GetGroup(ctx, companyID, mainGroupID(companyID))
 	if err != nil {
 		return nil, err
 	}
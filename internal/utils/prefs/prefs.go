@@ -4,17 +4,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/spf13/viper"
 	"golang.org/x/exp/slices"
 )
 
-// Domain is used to specify which domain to use (app, beta, local)
+// Domain is used to specify which domain to use (app, beta, local).
+// Overrides the domain of the active profile.
 var Domain string
 var InstructionFile string
 var Verbose bool
 
+// Offline makes the CLI serve lookups from the local cache only
+var Offline bool
+
+// Trace enables the HTTP trace of API requests, see GetTrace
+var Trace string
+
+// TraceToLog writes the HTTP trace to the log directory instead of a chosen file
+const TraceToLog = "log"
+
 const (
 	LastCompanyIDKey    = "last_company_id"
 	LastProjectIDKey    = "last_project_id"
@@ -22,6 +33,21 @@ const (
 	GitURLsKey          = "git_urls"
 	LastAssistantConfig = "last_assistant"
 	LastIDEKey          = "last_ide"
+	HTTPTimeoutKey      = "http_timeout"
+	HTTPMaxAttemptsKey  = "http_max_attempts"
+	// HTTPRateLimitKey caps API requests per second to a single host, negative disables the limit
+	HTTPRateLimitKey = "http_rate_limit"
+	// CABundleKey points to a PEM file with CA certificates trusted in addition to the system ones
+	CABundleKey = "ca_bundle"
+	// ClientCertKey and ClientKeyKey point to the PEM certificate and key used for mTLS
+	ClientCertKey = "client_cert"
+	ClientKeyKey  = "client_key"
+	// InsecureSkipVerifyKey disables certificate verification for local domains, e.g. a dev server with a self-signed certificate
+	InsecureSkipVerifyKey = "insecure_skip_verify"
+	// CredentialStoreKey selects where the API key is stored: "file" (default) or "keyring"
+	CredentialStoreKey = "credential_store"
+	// CredentialsEncryptionKey selects how the credentials file is encrypted: "none" (default), "machine" or "passphrase"
+	CredentialsEncryptionKey = "credentials_encryption"
 
 	apiKeyConfig = "apikey"
 )
@@ -74,25 +100,25 @@ const (
 	SSH   GitProtocol = "ssh"
 )
 
-// GetLastCompanyID returns the last selected company ID from config
+// GetLastCompanyID returns the last selected company ID of the active profile
 func GetLastCompanyID() int32 {
-	return int32(viper.GetInt(LastCompanyIDKey))
+	return int32(viper.GetInt(ProfileKey(LastCompanyIDKey)))
 }
 
-// SetLastCompanyID saves the last selected company ID to config
+// SetLastCompanyID saves the last selected company ID of the active profile
 func SetLastCompanyID(id int32) {
-	viper.Set(LastCompanyIDKey, id)
+	viper.Set(ProfileKey(LastCompanyIDKey), id)
 	_ = viper.WriteConfig()
 }
 
-// GetLastProjectID returns the last selected project ID from config
+// GetLastProjectID returns the last selected project ID of the active profile
 func GetLastProjectID() string {
-	return viper.GetString(LastProjectIDKey)
+	return viper.GetString(ProfileKey(LastProjectIDKey))
 }
 
-// SetLastProjectID saves the last selected project ID to config
+// SetLastProjectID saves the last selected project ID of the active profile
 func SetLastProjectID(id string) {
-	viper.Set(LastProjectIDKey, id)
+	viper.Set(ProfileKey(LastProjectIDKey), id)
 	_ = viper.WriteConfig()
 }
 
@@ -137,16 +163,95 @@ func SetLastIDE(ide string) {
 	_ = viper.WriteConfig()
 }
 
-func SetAPIKey(apiKey string) {
-	viper.Set(apiKeyConfig, apiKey)
-	err := viper.WriteConfig()
+// GetLegacyAPIKey returns the API key stored in config.json by older versions of the CLI
+func GetLegacyAPIKey() string {
+	if !viper.InConfig(apiKeyConfig) {
+		return ""
+	}
+	return viper.GetString(apiKeyConfig)
+}
+
+// RemoveLegacyAPIKey deletes the API key from config.json after it was moved to the credential store
+func RemoveLegacyAPIKey() error {
+	return removeConfigKey(func(config map[string]any) {
+		delete(config, apiKeyConfig)
+	})
+}
+
+// GetCredentialStore returns the configured credential store backend
+func GetCredentialStore() string {
+	return viper.GetString(CredentialStoreKey)
+}
+
+// GetCredentialsEncryption returns the configured encryption of the credentials file
+func GetCredentialsEncryption() string {
+	return viper.GetString(CredentialsEncryptionKey)
+}
+
+// IsOffline reports whether offline mode is enabled by the --offline flag or DEVPLAN_OFFLINE env variable
+func IsOffline() bool {
+	return Offline || viper.GetBool("offline")
+}
+
+// GetTrace returns where API requests are traced: TraceToLog, a file path, or an empty string if tracing is disabled.
+// Set with --trace or DEVPLAN_TRACE.
+func GetTrace() string {
+	trace := Trace
+	if trace == "" {
+		trace = viper.GetString("trace")
+	}
+	switch strings.ToLower(trace) {
+	case "", "0", "false", "off":
+		return ""
+	case "1", "true", "on", TraceToLog:
+		return TraceToLog
+	}
+	return trace
+}
+
+// GetHTTPTimeout returns the configured timeout for a single API request, or zero if not set
+func GetHTTPTimeout() time.Duration {
+	return viper.GetDuration(HTTPTimeoutKey)
+}
+
+// GetHTTPMaxAttempts returns the configured number of attempts for retryable API requests, or zero if not set
+func GetHTTPMaxAttempts() int {
+	return viper.GetInt(HTTPMaxAttemptsKey)
+}
+
+// TLSSettings are the TLS options of HTTP requests made by the CLI
+type TLSSettings struct {
+	CABundle           string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+}
+
+// GetTLSSettings returns the configured TLS options. Paths starting with ~ are expanded.
+func GetTLSSettings() TLSSettings {
+	return TLSSettings{
+		CABundle:           expandHome(viper.GetString(CABundleKey)),
+		ClientCert:         expandHome(viper.GetString(ClientCertKey)),
+		ClientKey:          expandHome(viper.GetString(ClientKeyKey)),
+		InsecureSkipVerify: viper.GetBool(InsecureSkipVerifyKey),
+	}
+}
+
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
-		out.Pfailf("\nFailed to write config: %v\n", err)
+		return path
 	}
+	return filepath.Join(home, rest)
 }
 
-func GetAPIKey() string {
-	return viper.GetString(apiKeyConfig)
+// GetHTTPRateLimit returns the configured API requests per second, or zero if not set
+func GetHTTPRateLimit() float64 {
+	return viper.GetFloat64(HTTPRateLimitKey)
 }
 
 // AddExtraGitURL saves git URL used for cloning to re-use later.
@@ -0,0 +1,193 @@
+package prefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultProfile uses the top-level settings of config.json, as before profiles were introduced
+	DefaultProfile = "default"
+	// ProfileEnv selects the active profile for a single command
+	ProfileEnv = "DEVPLAN_PROFILE"
+
+	activeProfileKey = "active_profile"
+	profilesKey      = "profiles"
+	domainKey        = "domain"
+	apiKeyNameKey    = "api_key_name"
+	apiKeyCreatedKey = "api_key_created_at"
+)
+
+// Profile overrides the active profile, e.g. with `devplan auth --profile`
+var Profile string
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ActiveProfile returns the profile used by the command: the one selected by a flag,
+// DEVPLAN_PROFILE or `devplan profile use`, in that order.
+func ActiveProfile() string {
+	if Profile != "" {
+		return Profile
+	}
+	if p := os.Getenv(ProfileEnv); p != "" {
+		return p
+	}
+	if p := viper.GetString(activeProfileKey); p != "" {
+		return p
+	}
+	return DefaultProfile
+}
+
+// SetActiveProfile makes the profile active for subsequent commands
+func SetActiveProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	viper.Set(activeProfileKey, name)
+	return viper.WriteConfig()
+}
+
+// ValidateProfileName checks that the name can be used as a profile name
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ListProfiles returns the names of all profiles, including the default one
+func ListProfiles() []string {
+	names := []string{DefaultProfile}
+	for name := range viper.GetStringMap(profilesKey) {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// ProfileExists reports whether the profile was created
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	_, ok := viper.GetStringMap(profilesKey)[name]
+	return ok
+}
+
+// SaveProfile creates the profile if needed and updates its domain if one is given
+func SaveProfile(name, domain string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if domain == "" && ProfileExists(name) {
+		return nil
+	}
+	if domain == "" {
+		domain = "app"
+	}
+	viper.Set(keyOf(name, domainKey), domain)
+	return viper.WriteConfig()
+}
+
+// RemoveProfile deletes the settings of the profile. The default profile can't be removed.
+func RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the %s profile can't be removed", DefaultProfile)
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	return removeConfigKey(func(config map[string]any) {
+		if profiles, ok := config[profilesKey].(map[string]any); ok {
+			delete(profiles, name)
+		}
+		if config[activeProfileKey] == name {
+			delete(config, activeProfileKey)
+		}
+	})
+}
+
+// ProfileKey returns the config key of a profile specific setting for the active profile
+func ProfileKey(key string) string {
+	return keyOf(ActiveProfile(), key)
+}
+
+// GetProfileCompanyID returns the last selected company ID of the profile
+func GetProfileCompanyID(profile string) int32 {
+	return int32(viper.GetInt(keyOf(profile, LastCompanyIDKey)))
+}
+
+func keyOf(profile, key string) string {
+	if profile == DefaultProfile {
+		return key
+	}
+	return profilesKey + "." + profile + "." + key
+}
+
+// SetAPIKeyInfo saves the name and creation time of the profile's API key. The key itself is kept in the credential store.
+func SetAPIKeyInfo(profile, name string, createdAt time.Time) {
+	viper.Set(keyOf(profile, apiKeyNameKey), name)
+	viper.Set(keyOf(profile, apiKeyCreatedKey), createdAt.UTC().Format(time.RFC3339))
+	_ = viper.WriteConfig()
+}
+
+// GetAPIKeyInfo returns the name and creation time of the profile's API key, if known
+func GetAPIKeyInfo(profile string) (string, time.Time) {
+	createdAt, _ := time.Parse(time.RFC3339, viper.GetString(keyOf(profile, apiKeyCreatedKey)))
+	return viper.GetString(keyOf(profile, apiKeyNameKey)), createdAt
+}
+
+// ClearAPIKeyInfo forgets the API key details of the profile after logout
+func ClearAPIKeyInfo(profile string) {
+	viper.Set(keyOf(profile, apiKeyNameKey), "")
+	viper.Set(keyOf(profile, apiKeyCreatedKey), "")
+	_ = viper.WriteConfig()
+}
+
+// GetDomain returns the domain of the API: the --domain flag, DEVPLAN_API_DOMAIN or the domain of the active profile
+func GetDomain() string {
+	if Domain != "" {
+		return Domain
+	}
+	if d := os.Getenv("DEVPLAN_API_DOMAIN"); d != "" {
+		return d
+	}
+	return GetProfileDomain(ActiveProfile())
+}
+
+// GetProfileDomain returns the domain stored for the profile
+func GetProfileDomain(profile string) string {
+	return viper.GetString(keyOf(profile, domainKey))
+}
+
+// removeConfigKey rewrites config.json with edit applied, since viper can't unset keys
+func removeConfigKey(edit func(config map[string]any)) error {
+	configPath := viper.ConfigFileUsed()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	config := map[string]any{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+	edit(config)
+	data, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return viper.ReadInConfig()
+}
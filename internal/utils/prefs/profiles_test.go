@@ -0,0 +1,95 @@
+package prefs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTempConfig points viper to an empty config file for the duration of the test
+func useTempConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	prevFile := viper.ConfigFileUsed()
+	viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("json")
+	require.NoError(t, viper.ReadInConfig())
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("DEVPLAN_API_DOMAIN", "")
+	Profile, Domain = "", ""
+	t.Cleanup(func() {
+		Profile, Domain = "", ""
+		viper.Reset()
+		viper.SetConfigFile(prevFile)
+		viper.SetConfigType("json")
+		viper.SetEnvPrefix("devplan")
+		viper.AutomaticEnv()
+		_ = viper.ReadInConfig()
+	})
+	return path
+}
+
+func TestProfiles_DefaultUsesTopLevelSettings(t *testing.T) {
+	useTempConfig(t, `{"last_company_id": 7}`)
+
+	assert.Equal(t, DefaultProfile, ActiveProfile())
+	assert.Equal(t, []string{DefaultProfile}, ListProfiles())
+	assert.Equal(t, int32(7), GetLastCompanyID())
+	assert.Equal(t, LastCompanyIDKey, ProfileKey(LastCompanyIDKey))
+}
+
+func TestProfiles_SettingsAreScopedToProfile(t *testing.T) {
+	useTempConfig(t, `{"last_company_id": 7}`)
+
+	require.NoError(t, SaveProfile("work", "beta"))
+	require.NoError(t, SetActiveProfile("work"))
+	assert.Equal(t, "work", ActiveProfile())
+	assert.Equal(t, "beta", GetDomain())
+	assert.Equal(t, int32(0), GetLastCompanyID())
+
+	SetLastCompanyID(12)
+	assert.Equal(t, int32(12), GetProfileCompanyID("work"))
+	assert.Equal(t, int32(7), GetProfileCompanyID(DefaultProfile))
+	assert.Equal(t, []string{DefaultProfile, "work"}, ListProfiles())
+
+	Domain = "local"
+	assert.Equal(t, "local", GetDomain())
+}
+
+func TestProfiles_Selection(t *testing.T) {
+	useTempConfig(t, `{"active_profile": "work", "profiles": {"work": {"domain": "app"}, "beta": {"domain": "beta"}}}`)
+
+	assert.Equal(t, "work", ActiveProfile())
+	t.Setenv(ProfileEnv, "beta")
+	assert.Equal(t, "beta", ActiveProfile())
+	Profile = "other"
+	assert.Equal(t, "other", ActiveProfile())
+	assert.False(t, ProfileExists("other"))
+}
+
+func TestProfiles_Remove(t *testing.T) {
+	path := useTempConfig(t, `{"active_profile": "work", "profiles": {"work": {"domain": "beta"}}}`)
+
+	assert.Error(t, RemoveProfile(DefaultProfile))
+	assert.Error(t, RemoveProfile("missing"))
+	require.NoError(t, RemoveProfile("work"))
+
+	assert.False(t, ProfileExists("work"))
+	assert.Equal(t, DefaultProfile, ActiveProfile())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "work")
+}
+
+func TestValidateProfileName(t *testing.T) {
+	assert.NoError(t, ValidateProfileName("work-2"))
+	assert.Error(t, ValidateProfileName("Work"))
+	assert.Error(t, ValidateProfileName("a.b"))
+	assert.Error(t, ValidateProfileName(""))
+}
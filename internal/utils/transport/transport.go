@@ -0,0 +1,140 @@
+// Package transport builds the HTTP transport shared by the API client, the auth flow and the updater.
+// It honors HTTPS_PROXY/NO_PROXY and the TLS settings of the config: an extra CA bundle,
+// a client certificate for mTLS and skipping verification for local domains.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+)
+
+var defaultTransport = sync.OnceValues(func() (http.RoundTripper, error) {
+	return New(prefs.GetTLSSettings())
+})
+
+// Default returns the transport configured from the preferences. It is created once per process.
+func Default() (http.RoundTripper, error) {
+	return defaultTransport()
+}
+
+// Client returns an HTTP client using the default transport
+func Client(timeout time.Duration) (*http.Client, error) {
+	t, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t, Timeout: timeout}, nil
+}
+
+// New creates a transport with the given TLS settings. Proxies are taken from HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+func New(settings prefs.TLSSettings) (http.RoundTripper, error) {
+	tlsConfig, err := newTLSConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+	strict := newHTTPTransport(tlsConfig)
+	if !settings.InsecureSkipVerify {
+		return strict, nil
+	}
+	insecureConfig := tlsConfig.Clone()
+	insecureConfig.InsecureSkipVerify = true
+	return &localInsecureTransport{strict: strict, insecure: newHTTPTransport(insecureConfig)}, nil
+}
+
+func newHTTPTransport(tlsConfig *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	t.TLSClientConfig = tlsConfig
+	return t
+}
+
+func newTLSConfig(settings prefs.TLSSettings) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if settings.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(settings.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", prefs.CABundleKey, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s %s contains no PEM certificates", prefs.CABundleKey, settings.CABundle)
+		}
+		config.RootCAs = pool
+	}
+	switch {
+	case settings.ClientCert != "" && settings.ClientKey != "":
+		cert, err := tls.LoadX509KeyPair(settings.ClientCert, settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case settings.ClientCert != "" || settings.ClientKey != "":
+		return nil, fmt.Errorf("both %s and %s must be set for mTLS", prefs.ClientCertKey, prefs.ClientKeyKey)
+	}
+	return config, nil
+}
+
+// localInsecureTransport skips certificate verification for local hosts only
+type localInsecureTransport struct {
+	strict   *http.Transport
+	insecure *http.Transport
+}
+
+func (t *localInsecureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if IsLocalHost(req.URL.Hostname()) {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.strict.RoundTrip(req)
+}
+
+// IsLocalHost reports whether the host is a loopback address, localhost or a .local/.localhost/.test domain
+func IsLocalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" {
+		return true
+	}
+	for _, suffix := range []string{".localhost", ".local", ".test"} {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// UsesClientCert reports whether a client certificate is configured for mTLS. curl has no environment
+// variables for client certificates, so downloads that need one must go through the transport.
+func UsesClientCert() bool {
+	settings := prefs.GetTLSSettings()
+	return settings.ClientCert != "" || settings.ClientKey != ""
+}
+
+// CurlEnv returns environment variables making curl, e.g. in the install script, use the same proxy and CA bundle
+func CurlEnv() []string {
+	var env []string
+	// curl only reads the lowercase http_proxy, so mirror the variables Go accepts in either case
+	for _, name := range []string{"https_proxy", "http_proxy", "no_proxy"} {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		if value := os.Getenv(strings.ToUpper(name)); value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	if bundle := prefs.GetTLSSettings().CABundle; bundle != "" {
+		env = append(env, "CURL_CA_BUNDLE="+bundle)
+	}
+	return env
+}
@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, name, blockType string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600))
+	return path
+}
+
+func get(t *testing.T, settings prefs.TLSSettings, url string) error {
+	t.Helper()
+	tr, err := New(settings)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: tr, Timeout: 5 * time.Second}).Get(url)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	return err
+}
+
+func TestNew_CABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	assert.Error(t, get(t, prefs.TLSSettings{}, srv.URL))
+
+	bundle := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	assert.NoError(t, get(t, prefs.TLSSettings{CABundle: bundle}, srv.URL))
+}
+
+func TestNew_InvalidSettings(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0600))
+
+	_, err := New(prefs.TLSSettings{CABundle: empty})
+	assert.ErrorContains(t, err, "no PEM certificates")
+	_, err = New(prefs.TLSSettings{CABundle: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, prefs.CABundleKey)
+	_, err = New(prefs.TLSSettings{ClientCert: empty})
+	assert.ErrorContains(t, err, prefs.ClientKeyKey)
+}
+
+func TestNew_ClientCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+	bundle := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	assert.Error(t, get(t, prefs.TLSSettings{CABundle: bundle}, srv.URL))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	settings := prefs.TLSSettings{
+		CABundle:   bundle,
+		ClientCert: writePEM(t, "client.pem", "CERTIFICATE", certDER),
+		ClientKey:  writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER),
+	}
+	assert.NoError(t, get(t, settings, srv.URL))
+}
+
+func TestNew_InsecureSkipVerifyOnlyForLocalHosts(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	assert.NoError(t, get(t, prefs.TLSSettings{InsecureSkipVerify: true}, srv.URL))
+
+	// The test server answers for example.com too, but its certificate must be verified there
+	tr, err := New(prefs.TLSSettings{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	tr.(*localInsecureTransport).strict.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	_, err = (&http.Client{Transport: tr}).Get("https://example.com")
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestIsLocalHost(t *testing.T) {
+	for host, local := range map[string]bool{
+		"localhost":         true,
+		"127.0.0.1":         true,
+		"::1":               true,
+		"devplan.local":     true,
+		"app.localhost":     true,
+		"api.test":          true,
+		"app.devplan.com":   false,
+		"10.0.0.1":          false,
+		"localhost.evil.io": false,
+	} {
+		assert.Equal(t, local, IsLocalHost(host), host)
+	}
+}
+
+func TestCurlEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy:3128")
+	t.Setenv("https_proxy", "")
+	require.NoError(t, os.Unsetenv("https_proxy"))
+	t.Setenv("NO_PROXY", "localhost")
+	t.Setenv("no_proxy", "internal")
+
+	env := CurlEnv()
+	assert.Contains(t, env, "https_proxy=http://proxy:3128")
+	assert.NotContains(t, env, "no_proxy=localhost")
+}
+
+func TestUsesClientCert(t *testing.T) {
+	t.Setenv("DEVPLAN_CLIENT_CERT", "")
+	t.Setenv("DEVPLAN_CLIENT_KEY", "")
+	assert.False(t, UsesClientCert())
+
+	t.Setenv("DEVPLAN_CLIENT_CERT", "/etc/devplan/client.pem")
+	t.Setenv("DEVPLAN_CLIENT_KEY", "/etc/devplan/client.key")
+	assert.True(t, UsesClientCert())
+}
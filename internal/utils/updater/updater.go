@@ -6,6 +6,7 @@ import (
 	"github.com/Masterminds/semver/v3"
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/devplaninc/devplan-cli/internal/pb/cli"
+	"github.com/devplaninc/devplan-cli/internal/utils/transport"
 	"github.com/devplaninc/devplan-cli/internal/version"
 	"google.golang.org/protobuf/encoding/protojson"
 	"io"
@@ -21,6 +22,8 @@ const (
 
 	// VersionFile is the name of the file that contains the current production version
 	VersionFile = "version.json"
+
+	installScriptURL = "https://beta.devplan.com/api/cli/install"
 )
 
 // Client holds the configuration for the updater
@@ -50,7 +53,7 @@ func (c *Client) GetBinaryURL(version string) string {
 
 // GetVersionConfig returns the current production version
 func (c *Client) GetVersionConfig() (*cli.Version, error) {
-	resp, err := http.Get(c.GetVersionURL())
+	resp, err := httpGet(c.GetVersionURL())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get production version: %w", err)
 	}
@@ -110,16 +113,21 @@ func isNewer(v1, v2 string) (bool, error) {
 
 // Update updates the binary to the specified version
 func (c *Client) Update(targetVersion string) error {
-	if runtime.GOOS == "windows" {
-		// On Windows, we need to use a different approach
-		// For now, we'll keep the original implementation for Windows
-		return c.updateWindows(targetVersion)
+	// The install script can't run on Windows, and its curl can't present the client certificate of mTLS
+	if runtime.GOOS == "windows" || transport.UsesClientCert() {
+		return c.updateBinary(targetVersion)
 	}
 
-	cmd := exec.Command("/bin/bash", "-c", `curl -fsSL https://beta.devplan.com/api/cli/install | bash`)
-
+	// The script is downloaded here rather than with curl, so it goes through the configured proxy and CA bundle
+	script, err := downloadInstallScript()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("/bin/bash")
+	cmd.Stdin = bytes.NewReader(script)
+	cmd.Env = append(os.Environ(), transport.CurlEnv()...)
 	if targetVersion != "" {
-		cmd.Env = append(os.Environ(),
+		cmd.Env = append(cmd.Env,
 			fmt.Sprintf("DEVPLAN_INSTALL_VERSION=%s", targetVersion))
 	}
 
@@ -130,7 +138,7 @@ func (c *Client) Update(targetVersion string) error {
 	cmd.Stdout = os.Stdout
 
 	// Run the command
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		return fmt.Errorf("failed to update: %w\nstderr: %s", err, stderr.String())
 	}
@@ -138,13 +146,13 @@ func (c *Client) Update(targetVersion string) error {
 	return nil
 }
 
-// updateWindows updates the binary on Windows
-func (c *Client) updateWindows(targetVersion string) error {
+// updateBinary downloads the binary with the proxy and TLS settings of the CLI and replaces the current executable
+func (c *Client) updateBinary(targetVersion string) error {
 	// Get the URL for the binary
 	binaryURL := c.GetBinaryURL(targetVersion)
 
 	// Download the binary
-	resp, err := http.Get(binaryURL)
+	resp, err := httpGet(binaryURL)
 	if err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
@@ -188,7 +196,8 @@ func (c *Client) updateWindows(targetVersion string) error {
 	}
 
 	// On Windows, we can't replace the running executable directly
-	// So we rename the current executable and copy the new one
+	// So we rename the current executable and copy the new one. Unix allows renaming it as well,
+	// and the copy is created executable.
 	bakPath := execPath + ".bak"
 	if err := os.Rename(execPath, bakPath); err != nil {
 		return fmt.Errorf("failed to rename current executable: %w", err)
@@ -206,6 +215,30 @@ func (c *Client) updateWindows(targetVersion string) error {
 	return nil
 }
 
+func downloadInstallScript() ([]byte, error) {
+	resp, err := httpGet(installScriptURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download install script: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download install script: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// httpGet downloads the URL using the proxy and TLS settings of the CLI
+func httpGet(url string) (*http.Response, error) {
+	client, err := transport.Client(0)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(url)
+}
+
 // ListAvailableVersions returns a list of all available versions
 func (c *Client) ListAvailableVersions() ([]string, error) {
 	// This is a simplified implementation that would need to be replaced with
@@ -226,7 +259,7 @@ func getBinaryNameForCurrentPlatform() string {
 	return fmt.Sprintf("devplan-%s-%s", goos, arch)
 }
 
-// copyFile copies a file from src to dst
+// copyFile copies an executable from src to dst
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
@@ -236,7 +269,7 @@ func copyFile(src, dst string) error {
 		_ = sourceFile.Close()
 	}(sourceFile)
 
-	destFile, err := os.Create(dst)
+	destFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
 	if err != nil {
 		return err
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/devplaninc/devplan-cli/internal/out"
 	"github.com/devplaninc/devplan-cli/internal/utils/git"
+	"github.com/devplaninc/devplan-cli/internal/utils/prefs"
 	"github.com/spf13/viper"
 )
 
@@ -19,7 +20,8 @@ const (
 )
 
 func GetPath() string {
-	workspaceDir := viper.GetString(workspaceConfigKey)
+	configKey := prefs.ProfileKey(workspaceConfigKey)
+	workspaceDir := viper.GetString(configKey)
 	if workspaceDir == "" {
 		// Use default directory in user's home
 		home, err := os.UserHomeDir()
@@ -29,7 +31,7 @@ func GetPath() string {
 		workspaceDir = filepath.Join(home, defaultWorkspace)
 
 		// Save to config for future use
-		viper.Set(workspaceConfigKey, workspaceDir)
+		viper.Set(configKey, workspaceDir)
 		err = viper.WriteConfig()
 		if err != nil {
 			out.Pfailf("Failed to write config: %v\n", err)